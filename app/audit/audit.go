@@ -0,0 +1,369 @@
+// Package audit records tool calls to a JSONL log rotated by size, and summarizes the log:
+// calls by tool, most read docs and docs requested but never found.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// readTools are tools reading docs by path, their requests mark docs as found
+var readTools = map[string]bool{"read_doc": true, "read_docs": true, "read_doc_at": true, "extract": true}
+
+// Record is a single tool call
+type Record struct {
+	Time      time.Time       `json:"time"`
+	Session   string          `json:"session,omitempty"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Paths     []string        `json:"paths,omitempty"`     // docs served, with source prefix
+	NotFound  []string        `json:"not_found,omitempty"` // requested docs which don't exist, as requested
+	Hash      string          `json:"hash,omitempty"`      // sha256 of the response content
+	Bytes     int             `json:"bytes"`               // size of the response content
+	LatencyMs float64         `json:"latency_ms"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Logger writes records to a file, one JSON object per line. when the file would exceed max size,
+// it is renamed to "path.1", older backups are shifted to "path.2" and so on, up to the backups limit.
+type Logger struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewLogger opens log file for appending, creating it if needed. maxSize <= 0 disables rotation.
+func NewLogger(path string, maxSize int64, backups int) (*Logger, error) {
+	l := &Logger{path: path, maxSize: maxSize, backups: max(backups, 0)}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Log appends record to the log, rotating the file if needed
+func (l *Logger) Log(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return errors.New("audit log is closed")
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the log file
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err // nolint:wrapcheck // file close error is descriptive
+}
+
+// open opens log file and sets current size
+func (l *Logger) open() error {
+	// #nosec G304 - path is provided by the user via cli option
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	l.file, l.size = f, st.Size()
+	return nil
+}
+
+// rotate shifts backups, moves the current file to the first backup and opens a new one.
+// without backups the current file is truncated.
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	l.file = nil
+
+	if l.backups == 0 {
+		if err := os.Truncate(l.path, 0); err != nil {
+			return fmt.Errorf("failed to truncate audit log: %w", err)
+		}
+		return l.open()
+	}
+	for i := l.backups - 1; i > 0; i-- {
+		if err := os.Rename(backupPath(l.path, i), backupPath(l.path, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return l.open()
+}
+
+// backupPath returns path of the n-th rotated file
+func backupPath(path string, n int) string {
+	return path + "." + strconv.Itoa(n)
+}
+
+// ReadRecords reads records of the log and its rotated files, oldest first. lines which can't be
+// decoded, e.g. one cut by a crash, are skipped.
+func ReadRecords(path string) ([]Record, error) {
+	var paths []string
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backupPath(path, i)); err != nil {
+			break
+		}
+		paths = append([]string{backupPath(path, i)}, paths...)
+	}
+	paths = append(paths, path)
+
+	var res []Record
+	for _, p := range paths {
+		records, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, records...)
+	}
+	return res, nil
+}
+
+// readFile reads records of a single log file
+func readFile(path string) ([]Record, error) {
+	// #nosec G304 - path is provided by the user via cli option
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var res []Record
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			continue
+		}
+		res = append(res, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	return res, nil
+}
+
+// ToolStat summarizes calls of a tool
+type ToolStat struct {
+	Tool         string  `json:"tool"`
+	Calls        int     `json:"calls"`
+	Errors       int     `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	Bytes        int     `json:"bytes"`
+}
+
+// DocStat counts requests of a doc
+type DocStat struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// Summary is an aggregated view of the log
+type Summary struct {
+	Calls      int        `json:"calls"`
+	Sessions   int        `json:"sessions"`
+	Errors     int        `json:"errors"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	Tools      []ToolStat `json:"tools"`       // sorted by number of calls
+	MostRead   []DocStat  `json:"most_read"`   // docs served most often
+	NeverFound []DocStat  `json:"never_found"` // requested docs which were not found every time
+}
+
+// Summarize aggregates records, most read and never found docs are limited to top entries
+func Summarize(records []Record, top int) *Summary {
+	res := &Summary{Tools: []ToolStat{}, MostRead: []DocStat{}, NeverFound: []DocStat{}}
+	tools := map[string]*ToolStat{}
+	sessions := map[string]bool{}
+	reads := map[string]int{}
+	failed := map[string]int{}
+	found := map[string]bool{}
+
+	for _, r := range records {
+		if res.From.IsZero() || r.Time.Before(res.From) {
+			res.From = r.Time
+		}
+		if r.Time.After(res.To) {
+			res.To = r.Time
+		}
+		res.Calls++
+		if r.Session != "" {
+			sessions[r.Session] = true
+		}
+
+		ts, ok := tools[r.Tool]
+		if !ok {
+			ts = &ToolStat{Tool: r.Tool}
+			tools[r.Tool] = ts
+		}
+		ts.Calls++
+		ts.Bytes += r.Bytes
+		ts.AvgLatencyMs += r.LatencyMs // sum for now, divided below
+
+		for _, p := range r.NotFound {
+			failed[p]++
+		}
+		for _, p := range foundPaths(r) {
+			found[p] = true
+		}
+		if r.Error != "" {
+			res.Errors++
+			ts.Errors++
+			continue
+		}
+		for _, p := range r.Paths {
+			reads[p]++
+		}
+	}
+	res.Sessions = len(sessions)
+
+	for _, ts := range tools {
+		ts.AvgLatencyMs /= float64(ts.Calls)
+		res.Tools = append(res.Tools, *ts)
+	}
+	sort.Slice(res.Tools, func(i, j int) bool {
+		if res.Tools[i].Calls != res.Tools[j].Calls {
+			return res.Tools[i].Calls > res.Tools[j].Calls
+		}
+		return res.Tools[i].Tool < res.Tools[j].Tool
+	})
+
+	for p := range found {
+		delete(failed, p) // requested path was found at least once
+	}
+	res.MostRead = topDocs(reads, top)
+	res.NeverFound = topDocs(failed, top)
+	return res
+}
+
+// foundPaths returns docs requested by a read tool call which exist, as requested with "source:" prefix
+// if source argument is set. read_docs docs are found unless listed as not found, other read tools
+// have their "path" found if the call succeeded.
+func foundPaths(r Record) []string {
+	type docArg struct {
+		Path   string `json:"path"`
+		Source string `json:"source"`
+	}
+	var args struct {
+		docArg
+		Docs []docArg `json:"docs"`
+	}
+	if !readTools[r.Tool] || len(r.Arguments) == 0 || json.Unmarshal(r.Arguments, &args) != nil {
+		return nil
+	}
+	name := func(d docArg) string {
+		if d.Source != "" {
+			return d.Source + ":" + d.Path
+		}
+		return d.Path
+	}
+
+	var res []string
+	if r.Tool == "read_docs" {
+		for _, d := range args.Docs {
+			if d.Path != "" && !slices.Contains(r.NotFound, name(d)) {
+				res = append(res, name(d))
+			}
+		}
+		return res
+	}
+	if r.Error == "" && args.Path != "" {
+		res = append(res, name(args.docArg))
+	}
+	return res
+}
+
+// topDocs returns up to top docs with the highest counts, ties sorted by path
+func topDocs(counts map[string]int, top int) []DocStat {
+	res := make([]DocStat, 0, len(counts))
+	for p, n := range counts {
+		res = append(res, DocStat{Path: p, Count: n})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Path < res[j].Path
+	})
+	if top > 0 && len(res) > top {
+		res = res[:top]
+	}
+	return res
+}
+
+// WriteText writes summary in human-readable form
+func (s *Summary) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d calls, %d sessions, %d errors", s.Calls, s.Sessions, s.Errors)
+	if s.Calls > 0 {
+		fmt.Fprintf(bw, ", %s - %s", s.From.Format(time.RFC3339), s.To.Format(time.RFC3339))
+	}
+	fmt.Fprintln(bw)
+
+	if len(s.Tools) > 0 {
+		fmt.Fprintln(bw, "\ntools:")
+		for _, t := range s.Tools {
+			fmt.Fprintf(bw, "  %-16s %6d calls %4d errors %8.1fms avg %10d bytes\n", t.Tool, t.Calls, t.Errors, t.AvgLatencyMs, t.Bytes)
+		}
+	}
+	if len(s.MostRead) > 0 {
+		fmt.Fprintln(bw, "\nmost read:")
+		for _, d := range s.MostRead {
+			fmt.Fprintf(bw, "  %6d  %s\n", d.Count, d.Path)
+		}
+	}
+	if len(s.NeverFound) > 0 {
+		fmt.Fprintln(bw, "\nnever found:")
+		for _, d := range s.NeverFound {
+			fmt.Fprintf(bw, "  %6d  %s\n", d.Count, d.Path)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Log(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLogger(path, 0, 0)
+	require.NoError(t, err)
+
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, l.Log(Record{Time: ts, Session: "s1", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"a.md"}`),
+		Paths: []string{"project-docs:a.md"}, Hash: "abc", Bytes: 10, LatencyMs: 1.5}))
+	require.NoError(t, l.Log(Record{Time: ts, Tool: "read_doc", Error: "file not found"}))
+	require.NoError(t, l.Close())
+	require.NoError(t, l.Close(), "second close is a no-op")
+	require.Error(t, l.Log(Record{Tool: "read_doc"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"time":"2024-03-01T12:00:00Z","session":"s1","tool":"read_doc","arguments":{"path":"a.md"},`+
+		`"paths":["project-docs:a.md"],"hash":"abc","bytes":10,"latency_ms":1.5}`, lines[0])
+
+	// reopened log is appended
+	l, err = NewLogger(path, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, l.Log(Record{Time: ts, Tool: "search_docs"}))
+	require.NoError(t, l.Close())
+	records, err := ReadRecords(path)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	_, err = NewLogger(filepath.Join(t.TempDir(), "missing", "audit.jsonl"), 0, 0)
+	require.Error(t, err)
+}
+
+func TestLogger_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	rec := Record{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), Tool: "read_doc"}
+	data, err := json.Marshal(rec)
+	require.NoError(t, err)
+	lineSize := int64(len(data) + 1)
+
+	l, err := NewLogger(path, 2*lineSize, 2)
+	require.NoError(t, err)
+	for i := range 7 {
+		rec.Bytes = i // single digit, all records have the same size
+		require.NoError(t, l.Log(rec))
+	}
+	require.NoError(t, l.Close())
+
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err), "only two backups are kept")
+	records, err := ReadRecords(path)
+	require.NoError(t, err)
+	require.Len(t, records, 5, "two records in each backup and one in the current file")
+	for i, r := range records {
+		assert.Equal(t, i+2, r.Bytes, "records are read oldest first")
+	}
+
+	t.Run("without backups", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		l, err := NewLogger(path, lineSize, 0)
+		require.NoError(t, err)
+		require.NoError(t, l.Log(rec))
+		require.NoError(t, l.Log(rec))
+		require.NoError(t, l.Close())
+		records, err := ReadRecords(path)
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+	})
+}
+
+func TestLogger_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := NewLogger(path, 1024, 100)
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				assert.NoError(t, l.Log(Record{Tool: "read_doc"}))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, l.Close())
+
+	records, err := ReadRecords(path)
+	require.NoError(t, err)
+	assert.Len(t, records, 100)
+}
+
+func TestReadRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tool":"a"}`+"\nnot json\n"+`{"tool":"b"}`+"\n{\"tool\":"), 0600))
+	records, err := ReadRecords(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2, "broken lines are skipped")
+	assert.Equal(t, "b", records[1].Tool)
+
+	_, err = ReadRecords(filepath.Join(t.TempDir(), "missing.jsonl"))
+	require.Error(t, err)
+}
+
+func TestSummarize(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: ts, Session: "s1", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"a.md"}`), Paths: []string{"project-docs:a.md"},
+			Bytes: 100, LatencyMs: 2},
+		{Time: ts.Add(time.Minute), Session: "s1", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"a.md"}`),
+			Paths: []string{"project-docs:a.md", "project-docs:inc.md"}, Bytes: 200, LatencyMs: 4},
+		{Time: ts.Add(2 * time.Minute), Session: "s2", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"b.md"}`),
+			Paths: []string{"commands:b.md"}, Bytes: 50},
+		{Time: ts.Add(3 * time.Minute), Session: "s2", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"gone.md"}`),
+			Error: "file not found", NotFound: []string{"gone.md"}},
+		{Time: ts.Add(4 * time.Minute), Session: "s2", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"gone.md"}`),
+			Error: "file not found", NotFound: []string{"gone.md"}},
+		{Time: ts.Add(5 * time.Minute), Session: "s2", Tool: "read_doc", Arguments: json.RawMessage(`{"path":"b.md"}`), Error: "timeout"},
+		{Time: ts.Add(-time.Minute), Tool: "search_docs", Arguments: json.RawMessage(`{"query":"x"}`), Bytes: 10},
+		{Time: ts, Tool: "extract", Arguments: json.RawMessage(`{"path":"c.md","source":"commands"}`), Error: "file not found",
+			NotFound: []string{"commands:c.md"}},
+		{Time: ts, Tool: "read_doc", Arguments: json.RawMessage(`{"path":"dup.md","strict":true}`), Error: "ambiguous path dup.md"},
+		{Time: ts, Tool: "complete_task", Arguments: json.RawMessage(`{"path":"plan.md","line":3}`), Error: "the plan has changed"},
+		{Time: ts, Tool: "read_doc_at", Arguments: json.RawMessage(`{"path":"later.md","revision":"HEAD"}`), Error: "file not found",
+			NotFound: []string{"later.md"}},
+		{Time: ts, Tool: "read_docs", Arguments: json.RawMessage(`{"docs":[{"path":"later.md"},{"path":"lost.md"}]}`),
+			Paths: []string{"project-docs:later.md"}, NotFound: []string{"lost.md"}},
+	}
+
+	s := Summarize(records, 10)
+	assert.Equal(t, 12, s.Calls)
+	assert.Equal(t, 2, s.Sessions)
+	assert.Equal(t, 7, s.Errors)
+	assert.Equal(t, ts.Add(-time.Minute), s.From)
+	assert.Equal(t, ts.Add(5*time.Minute), s.To)
+	assert.Equal(t, []ToolStat{
+		{Tool: "read_doc", Calls: 7, Errors: 4, AvgLatencyMs: 6.0 / 7, Bytes: 350},
+		{Tool: "complete_task", Calls: 1, Errors: 1},
+		{Tool: "extract", Calls: 1, Errors: 1},
+		{Tool: "read_doc_at", Calls: 1, Errors: 1},
+		{Tool: "read_docs", Calls: 1},
+		{Tool: "search_docs", Calls: 1, Bytes: 10},
+	}, s.Tools)
+	assert.Equal(t, []DocStat{{Path: "project-docs:a.md", Count: 2}, {Path: "commands:b.md", Count: 1},
+		{Path: "project-docs:inc.md", Count: 1}, {Path: "project-docs:later.md", Count: 1}}, s.MostRead)
+	assert.Equal(t, []DocStat{{Path: "gone.md", Count: 2}, {Path: "commands:c.md", Count: 1}, {Path: "lost.md", Count: 1}},
+		s.NeverFound, "only not found docs are counted, later.md was found by read_docs")
+
+	s = Summarize(records, 1)
+	assert.Len(t, s.MostRead, 1)
+	assert.Len(t, s.NeverFound, 1)
+
+	s = Summarize(nil, 10)
+	assert.Equal(t, 0, s.Calls)
+	assert.NotNil(t, s.Tools)
+}
+
+func TestSummary_WriteText(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := &Summary{Calls: 3, Sessions: 1, Errors: 1, From: ts, To: ts.Add(time.Hour),
+		Tools:      []ToolStat{{Tool: "read_doc", Calls: 3, Errors: 1, AvgLatencyMs: 1.25, Bytes: 300}},
+		MostRead:   []DocStat{{Path: "project-docs:a.md", Count: 2}},
+		NeverFound: []DocStat{{Path: "gone.md", Count: 1}}}
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteText(&buf))
+	assert.Equal(t, "3 calls, 1 sessions, 1 errors, 2024-03-01T12:00:00Z - 2024-03-01T13:00:00Z\n\n"+
+		"tools:\n  read_doc              3 calls    1 errors      1.2ms avg        300 bytes\n\n"+
+		"most read:\n       2  project-docs:a.md\n\nnever found:\n       1  gone.md\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, (&Summary{}).WriteText(&buf))
+	assert.Equal(t, "0 calls, 0 sessions, 0 errors\n", buf.String())
+}
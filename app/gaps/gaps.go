@@ -0,0 +1,265 @@
+// Package gaps keeps search queries which found nothing or only weak matches, with counts and
+// the closest docs, to show which documentation is missing.
+package gaps
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxEntries is the number of queries kept by store if not set
+	DefaultMaxEntries = 1000
+	// DefaultSaveDelay is how long changes are collected before the store file is rewritten
+	DefaultSaveDelay = 5 * time.Second
+)
+
+// NearMiss is a doc closest to an unmet query
+type NearMiss struct {
+	Path  string  `json:"path"` // as returned by search, with source prefix
+	Score float64 `json:"score"`
+}
+
+// Gap is an unmet query with its counts
+type Gap struct {
+	Query      string     `json:"query"`
+	Count      int        `json:"count"`
+	FirstSeen  time.Time  `json:"first_seen"`
+	LastSeen   time.Time  `json:"last_seen"`
+	TopScore   float64    `json:"top_score"`             // score of the best match on the last search, zero if nothing found
+	NearMisses []NearMiss `json:"near_misses,omitempty"` // closest docs on the last search
+}
+
+// Store keeps gaps in a JSON file. changes are saved in batches, the file is rewritten once per save delay
+// after a change and on Close. once the store is full, queries not seen for the longest time are dropped.
+type Store struct {
+	path       string
+	maxEntries int
+	saveDelay  time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	gaps   map[string]*Gap // by normalized query
+	dirty  bool            // changed since the last save
+	timer  *time.Timer     // pending save, nil if none
+	closed bool
+
+	saveMu sync.Mutex // serializes file writes
+}
+
+// storeFile is the format of the store file
+type storeFile struct {
+	Gaps []*Gap `json:"gaps"`
+}
+
+// Open loads store from the file, missing file is an empty store. maxEntries <= 0 sets the default.
+func Open(path string, maxEntries int) (*Store, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	s := &Store{path: path, maxEntries: maxEntries, saveDelay: DefaultSaveDelay, now: time.Now, gaps: map[string]*Gap{}}
+
+	// #nosec G304 - path is provided by the user via cli option
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gaps file: %w", err)
+	}
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse gaps file %s: %w", path, err)
+	}
+	for _, g := range f.Gaps {
+		if g != nil && g.Query != "" {
+			s.gaps[normalize(g.Query)] = g
+		}
+	}
+	return s, nil
+}
+
+// Record counts unmet query, keeping its best score and near misses of this search, and schedules a save
+func (s *Store) Record(query string, topScore float64, nearMisses []NearMiss) {
+	key := normalize(query)
+	if key == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now().UTC()
+	g, ok := s.gaps[key]
+	if !ok {
+		g = &Gap{Query: key, FirstSeen: now}
+		s.gaps[key] = g
+	}
+	g.Count++
+	g.LastSeen = now
+	g.TopScore = topScore
+	g.NearMisses = nearMisses
+	s.prune()
+	s.dirty = true
+	if s.timer == nil && !s.closed {
+		s.timer = time.AfterFunc(s.saveDelay, s.scheduledFlush)
+	}
+}
+
+// Flush saves pending changes to the file, if any. failed save is retried by the next flush.
+func (s *Store) Flush() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	data, err := s.marshal()
+	s.dirty = err != nil
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := s.write(data); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close saves pending changes, later changes are kept in memory only
+func (s *Store) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return s.Flush()
+}
+
+// scheduledFlush saves changes after the save delay, failure is logged as there is no caller to return it to
+func (s *Store) scheduledFlush() {
+	if err := s.Flush(); err != nil {
+		slog.Warn("failed to save documentation gaps", "path", s.path, "error", err)
+	}
+}
+
+// Top returns up to n gaps, most frequent first, all gaps if n <= 0
+func (s *Store) Top(n int) []Gap {
+	s.mu.Lock()
+	res := make([]Gap, 0, len(s.gaps))
+	for _, g := range s.gaps {
+		res = append(res, *g)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		if !res[i].LastSeen.Equal(res[j].LastSeen) {
+			return res[i].LastSeen.After(res[j].LastSeen)
+		}
+		return res[i].Query < res[j].Query
+	})
+	if n > 0 && len(res) > n {
+		res = res[:n]
+	}
+	return res
+}
+
+// Len returns the number of gaps in the store
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.gaps)
+}
+
+// prune drops gaps not seen for the longest time to fit max entries, called with lock held
+func (s *Store) prune() {
+	if len(s.gaps) <= s.maxEntries {
+		return
+	}
+	keys := make([]string, 0, len(s.gaps))
+	for k := range s.gaps {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.gaps[keys[i]].LastSeen.Before(s.gaps[keys[j]].LastSeen) })
+	for _, k := range keys[:len(keys)-s.maxEntries] {
+		delete(s.gaps, k)
+	}
+}
+
+// marshal returns the store file content, called with lock held
+func (s *Store) marshal() ([]byte, error) {
+	f := storeFile{Gaps: make([]*Gap, 0, len(s.gaps))}
+	for _, g := range s.gaps {
+		f.Gaps = append(f.Gaps, g)
+	}
+	sort.Slice(f.Gaps, func(i, j int) bool { return f.Gaps[i].Query < f.Gaps[j].Query })
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal gaps: %w", err)
+	}
+	return data, nil
+}
+
+// write writes data to a temp file and renames it over the store file
+func (s *Store) write(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after successful rename
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write gaps file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace gaps file: %w", err)
+	}
+	return nil
+}
+
+// normalize lowercases query and collapses whitespace, so "Deploy  Guide" and "deploy guide" are the same gap
+func normalize(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// WriteText writes gaps in human-readable form, one per line with near misses below
+func WriteText(w io.Writer, gaps []Gap) error {
+	bw := bufio.NewWriter(w)
+	if len(gaps) == 0 {
+		fmt.Fprintln(bw, "no documentation gaps recorded")
+	}
+	for _, g := range gaps {
+		fmt.Fprintf(bw, "%6d  %q  last seen %s, top score %.2f\n", g.Count, g.Query, g.LastSeen.Format(time.RFC3339), g.TopScore)
+		for _, m := range g.NearMisses {
+			fmt.Fprintf(bw, "        ~ %s (%.2f)\n", m.Path, m.Score)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write gaps: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package gaps
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gaps.json")
+	store, err := Open(path, 0)
+	require.NoError(t, err)
+	assert.Empty(t, store.Top(0))
+
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return ts }
+	store.Record("Deploy  Guide", 0, nil)
+	ts = ts.Add(time.Hour)
+	near := []NearMiss{{Path: "project-docs:deployment.md", Score: 0.2}}
+	store.Record("deploy guide", 0.2, near)
+	store.Record("kafka", 0, nil)
+	store.Record("   ", 0, nil) // blank query is ignored
+
+	top := store.Top(0)
+	require.Len(t, top, 2)
+	assert.Equal(t, Gap{Query: "deploy guide", Count: 2, FirstSeen: ts.Add(-time.Hour), LastSeen: ts, TopScore: 0.2,
+		NearMisses: near}, top[0])
+	assert.Equal(t, "kafka", top[1].Query)
+	assert.Len(t, store.Top(1), 1)
+	assert.Equal(t, 2, store.Len())
+
+	// reopened store has the same gaps
+	require.NoError(t, store.Close())
+	reopened, err := Open(path, 0)
+	require.NoError(t, err)
+	assert.Equal(t, top, reopened.Top(0))
+}
+
+func TestStore_Prune(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "gaps.json"), 2)
+	require.NoError(t, err)
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { ts = ts.Add(time.Minute); return ts }
+
+	store.Record("first", 0, nil)
+	store.Record("first", 0, nil)
+	store.Record("second", 0, nil)
+	store.Record("third", 0, nil)
+
+	var queries []string
+	for _, g := range store.Top(0) {
+		queries = append(queries, g.Query)
+	}
+	assert.ElementsMatch(t, []string{"second", "third"}, queries, "least recently seen query dropped")
+}
+
+func TestOpen_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	bad := filepath.Join(tmpDir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte("{not json"), 0600))
+	_, err := Open(bad, 0)
+	require.ErrorContains(t, err, "failed to parse gaps file")
+
+	store, err := Open(filepath.Join(tmpDir, "missing", "gaps.json"), 0)
+	require.NoError(t, err, "missing file is an empty store")
+	store.Record("query", 0, nil)
+	require.Error(t, store.Flush(), "directory doesn't exist")
+	require.Error(t, store.Flush(), "failed save is retried")
+}
+
+func TestStore_SaveDelay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gaps.json")
+	store, err := Open(path, 0)
+	require.NoError(t, err)
+	store.saveDelay = 50 * time.Millisecond
+
+	store.Record("kafka", 0, nil)
+	store.Record("deploy", 0, nil)
+	assert.NoFileExists(t, path, "changes are saved after the delay")
+	require.Eventually(t, func() bool {
+		reopened, err := Open(path, 0)
+		return err == nil && reopened.Len() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// pending changes are saved on close
+	store.saveDelay = time.Hour
+	store.Record("redis", 0, nil)
+	require.NoError(t, store.Close())
+	reopened, err := Open(path, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reopened.Len())
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteText(&buf, nil))
+	assert.Equal(t, "no documentation gaps recorded\n", buf.String())
+
+	buf.Reset()
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, WriteText(&buf, []Gap{
+		{Query: "deploy guide", Count: 3, LastSeen: ts, TopScore: 0.2, NearMisses: []NearMiss{{Path: "project-docs:deployment.md", Score: 0.2}}},
+		{Query: "kafka", Count: 1, LastSeen: ts},
+	}))
+	assert.Equal(t, `     3  "deploy guide"  last seen 2024-03-01T12:00:00Z, top score 0.20
+        ~ project-docs:deployment.md (0.20)
+     1  "kafka"  last seen 2024-03-01T12:00:00Z, top score 0.00
+`, buf.String())
+}
@@ -0,0 +1,256 @@
+package gitrepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tree entry modes
+const (
+	modeDir       = 0o40000
+	modeSubmodule = 0o160000
+	modeSymlink   = 0o120000
+)
+
+// Signature is author or committer of a commit
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+// Commit is a parsed commit object
+type Commit struct {
+	Hash      Hash
+	Tree      Hash
+	Parents   []Hash
+	Author    Signature
+	Committer Signature
+	Message   string
+}
+
+// Subject returns the first line of commit message
+func (c *Commit) Subject() string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	return strings.TrimSpace(subject)
+}
+
+// TreeEntry is a single entry of a tree object
+type TreeEntry struct {
+	Name string
+	Mode uint32
+	Hash Hash
+}
+
+// IsDir checks if entry is a subtree
+func (e TreeEntry) IsDir() bool {
+	return e.Mode == modeDir
+}
+
+// IsFile checks if entry is a regular file blob (not a symlink or submodule)
+func (e TreeEntry) IsFile() bool {
+	return e.Mode != modeDir && e.Mode != modeSubmodule && e.Mode != modeSymlink
+}
+
+// ReadCommit reads and parses commit object
+func (r *Repo) ReadCommit(h Hash) (*Commit, error) {
+	typ, data, err := r.ReadObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != ObjCommit {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", h, typ)
+	}
+	c, err := parseCommit(data)
+	if err != nil {
+		return nil, fmt.Errorf("commit %s: %w", h, err)
+	}
+	c.Hash = h
+	return c, nil
+}
+
+// parseCommit parses commit headers and message
+func parseCommit(data []byte) (*Commit, error) {
+	c := &Commit{}
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+	c.Message = string(message)
+
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		var err error
+		switch key {
+		case "tree":
+			c.Tree, err = ParseHash(value)
+		case "parent":
+			var p Hash
+			if p, err = ParseHash(value); err == nil {
+				c.Parents = append(c.Parents, p)
+			}
+		case "author":
+			c.Author, err = parseSignature(value)
+		case "committer":
+			c.Committer, err = parseSignature(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", key, err)
+		}
+	}
+	if c.Tree.IsZero() {
+		return nil, errors.New("missing tree header")
+	}
+	return c, nil
+}
+
+// parseSignature parses "Name <email> 1700000000 +0200"
+func parseSignature(s string) (Signature, error) {
+	lt, gt := strings.LastIndex(s, "<"), strings.LastIndex(s, ">")
+	if lt < 0 || gt < lt {
+		return Signature{}, fmt.Errorf("invalid signature %q", s)
+	}
+	sig := Signature{Name: strings.TrimSpace(s[:lt]), Email: s[lt+1 : gt]}
+
+	fields := strings.Fields(s[gt+1:])
+	if len(fields) < 1 {
+		return sig, nil
+	}
+	ts, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Signature{}, fmt.Errorf("invalid timestamp in signature %q", s)
+	}
+	loc := time.UTC
+	if len(fields) > 1 && len(fields[1]) == 5 {
+		hours, errH := strconv.Atoi(fields[1][1:3])
+		mins, errM := strconv.Atoi(fields[1][3:5])
+		if errH == nil && errM == nil {
+			offset := hours*3600 + mins*60
+			if fields[1][0] == '-' {
+				offset = -offset
+			}
+			loc = time.FixedZone(fields[1], offset)
+		}
+	}
+	sig.When = time.Unix(ts, 0).In(loc)
+	return sig, nil
+}
+
+// ReadTree reads and parses tree object
+func (r *Repo) ReadTree(h Hash) ([]TreeEntry, error) {
+	typ, data, err := r.ReadObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != ObjTree {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", h, typ)
+	}
+
+	// entries are "<octal mode> <name>\x00<20-byte hash>"
+	var entries []TreeEntry
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || nul+21 > len(data) {
+			return nil, fmt.Errorf("malformed tree %s", h)
+		}
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed tree %s: invalid mode %q", h, data[:sp])
+		}
+		e := TreeEntry{Name: string(data[sp+1 : nul]), Mode: uint32(mode)}
+		copy(e.Hash[:], data[nul+1:nul+21])
+		entries = append(entries, e)
+		data = data[nul+21:]
+	}
+	return entries, nil
+}
+
+// FindEntry looks up a slash-separated path in the tree, returns ErrNotFound if it doesn't exist
+func (r *Repo) FindEntry(tree Hash, p string) (TreeEntry, error) {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return TreeEntry{Name: "", Mode: modeDir, Hash: tree}, nil
+	}
+
+	current := TreeEntry{Mode: modeDir, Hash: tree}
+	for _, part := range strings.Split(p, "/") {
+		if !current.IsDir() {
+			return TreeEntry{}, fmt.Errorf("path %q: %w", p, ErrNotFound)
+		}
+		entries, err := r.ReadTree(current.Hash)
+		if err != nil {
+			return TreeEntry{}, err
+		}
+		found := false
+		for _, e := range entries {
+			if e.Name == part {
+				current, found = e, true
+				break
+			}
+		}
+		if !found {
+			return TreeEntry{}, fmt.Errorf("path %q: %w", p, ErrNotFound)
+		}
+	}
+	return current, nil
+}
+
+// ReadFileAt returns content of the file at path in the given commit
+func (r *Repo) ReadFileAt(commit Hash, p string) ([]byte, error) {
+	c, err := r.ReadCommit(commit)
+	if err != nil {
+		return nil, err
+	}
+	e, err := r.FindEntry(c.Tree, p)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsFile() {
+		return nil, fmt.Errorf("path %q is not a file: %w", p, ErrNotFound)
+	}
+	return r.ReadBlob(e.Hash)
+}
+
+// SkipDir can be returned from WalkFunc to skip a subtree
+var SkipDir = errors.New("skip this directory") //nolint:revive,staticcheck // mirrors fs.SkipDir
+
+// WalkFunc is called for every entry found by Walk, p is slash-separated path relative to the walk root
+type WalkFunc func(p string, e TreeEntry) error
+
+// Walk visits all entries of the tree under dir (empty for the root) in tree order, parents before children
+func (r *Repo) Walk(tree Hash, dir string, fn WalkFunc) error {
+	root, err := r.FindEntry(tree, dir)
+	if err != nil {
+		return err
+	}
+	if !root.IsDir() {
+		return fmt.Errorf("path %q is not a directory", dir)
+	}
+	return r.walkTree(root.Hash, "", fn)
+}
+
+func (r *Repo) walkTree(tree Hash, prefix string, fn WalkFunc) error {
+	entries, err := r.ReadTree(tree)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := path.Join(prefix, e.Name)
+		err := fn(p, e)
+		if errors.Is(err, SkipDir) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			if err := r.walkTree(e.Hash, p, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
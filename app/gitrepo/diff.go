@@ -0,0 +1,217 @@
+package gitrepo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxDiffCost limits the number of edits searched by the diff algorithm, larger changes
+// are reported as removal of all old lines followed by all new ones
+const maxDiffCost = 2048
+
+// edit is a single line of the edit script
+type edit struct {
+	op   byte // ' ' for unchanged, '-' for removed and '+' for added line
+	line string
+}
+
+// UnifiedDiff returns unified diff between old and new content with the given number of context lines,
+// in the same format as "git diff". returns empty string if contents are equal.
+func UnifiedDiff(oldName, newName string, oldContent, newContent []byte, context int) string {
+	if bytes.Equal(oldContent, newContent) {
+		return ""
+	}
+	edits := diffLines(splitLines(oldContent), splitLines(newContent))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// positions of edits in old and new content, used for hunk headers
+	oldPos, newPos := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.op != '+' {
+			oldPos[i+1]++
+		}
+		if e.op != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// hunk starts with context before the first change and extends while changes are close enough
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(edits))
+
+		oldCount, newCount := oldPos[end]-oldPos[start], newPos[end]-newPos[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldPos[start], oldCount), hunkRange(newPos[start], newCount))
+		for _, e := range edits[start:end] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats "start,count" of hunk header, start is 1-based and count is omitted if 1
+func hunkRange(pos, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", pos)
+	case 1:
+		return fmt.Sprintf("%d", pos+1)
+	default:
+		return fmt.Sprintf("%d,%d", pos+1, count)
+	}
+}
+
+// splitLines splits content into lines keeping line endings, last line may have no newline
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		idx := bytes.IndexByte(content, '\n')
+		if idx < 0 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:idx+1]))
+		content = content[idx+1:]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, using Myers algorithm
+// on lines between common prefix and suffix
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	res := make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		res = append(res, edit{op: ' ', line: line})
+	}
+	res = append(res, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		res = append(res, edit{op: ' ', line: line})
+	}
+	return res
+}
+
+// myers finds the shortest edit script with the greedy forward algorithm, keeping a frontier per step for backtracking
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	// v[d] holds furthest x on each diagonal k in [-d, d] after d edits, indexed by k+d
+	var trace [][]int
+	prev := []int{0}
+	found := false
+	for d := 0; d <= n+m && d <= maxDiffCost; d++ {
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			switch {
+			case d == 0:
+				x = 0
+			case k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]):
+				x = prev[k+1+d-1] // step down from diagonal k+1, insertion
+			default:
+				x = prev[k-1+d-1] + 1 // step right from diagonal k-1, deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+d] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		trace = append(trace, v)
+		prev = v
+		if found {
+			break
+		}
+	}
+	if !found {
+		return replaceAll(a, b)
+	}
+
+	// backtrack from the end, collecting edits in reverse order
+	var rev []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		p := trace[d-1]
+		var prevK int
+		if k == -d || (k != d && p[k-1+d-1] < p[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := p[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, edit{op: ' ', line: a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			rev = append(rev, edit{op: '+', line: b[y-1]})
+		} else {
+			rev = append(rev, edit{op: '-', line: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		rev = append(rev, edit{op: ' ', line: a[x-1]})
+		x, y = x-1, y-1
+	}
+
+	res := make([]edit, len(rev))
+	for i, e := range rev {
+		res[len(rev)-1-i] = e
+	}
+	return res
+}
+
+// replaceAll returns edit script removing all lines of a and adding all lines of b
+func replaceAll(a, b []string) []edit {
+	res := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		res = append(res, edit{op: '-', line: line})
+	}
+	for _, line := range b {
+		res = append(res, edit{op: '+', line: line})
+	}
+	return res
+}
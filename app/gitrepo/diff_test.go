@@ -0,0 +1,95 @@
+package gitrepo
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		context  int
+		want     string
+	}{
+		{name: "equal", old: "a\nb\n", new: "a\nb\n", context: 3, want: ""},
+		{name: "changed line", old: "a\nb\nc\n", new: "a\nB\nc\n", context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{name: "added to empty", old: "", new: "a\nb\n", context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{name: "removed all", old: "a\n", new: "", context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -1 +0,0 @@\n-a\n"},
+		{name: "no newline at end", old: "a\nb", new: "a\nb\n", context: 3,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{name: "separate hunks", old: "1\n2\n3\n4\n5\n6\n7\n8\n9\n", new: "0\n1\n2\n3\n4\n5\n6\n7\n8\n", context: 1,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -1 +1,2 @@\n+0\n 1\n@@ -8,2 +9 @@\n 8\n-9\n"},
+		{name: "merged hunks", old: "1\n2\n3\n4\n5\n", new: "1\nX\n3\nY\n5\n", context: 1,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -1,5 +1,5 @@\n 1\n-2\n+X\n 3\n-4\n+Y\n 5\n"},
+		{name: "insert in the middle", old: "a\nb\nc\nd\ne\nf\ng\n", new: "a\nb\nc\nnew\nd\ne\nf\ng\n", context: 2,
+			want: "--- a/doc.md\n+++ b/doc.md\n@@ -2,4 +2,5 @@\n b\n c\n+new\n d\n e\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff("a/doc.md", "b/doc.md", []byte(tt.old), []byte(tt.new), tt.context)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDiffLines_Minimal(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42)) // nolint:gosec // deterministic test data
+	randomLines := func() []string {
+		res := make([]string, rnd.Intn(30))
+		for i := range res {
+			res[i] = string(rune('a' + rnd.Intn(4)))
+		}
+		return res
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		edits := diffLines(a, b)
+
+		// edit script must reproduce both sides
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range edits {
+			if e.op != '+' {
+				gotA = append(gotA, e.line)
+			}
+			if e.op != '-' {
+				gotB = append(gotB, e.line)
+			}
+			if e.op != ' ' {
+				changes++
+			}
+		}
+		assert.Equal(t, strings.Join(a, ","), strings.Join(gotA, ","))
+		assert.Equal(t, strings.Join(b, ","), strings.Join(gotB, ","))
+
+		// and be the shortest one
+		assert.Equal(t, len(a)+len(b)-2*lcsLen(a, b), changes, "a=%v b=%v", a, b)
+	}
+}
+
+// lcsLen returns length of the longest common subsequence
+func lcsLen(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
@@ -0,0 +1,100 @@
+package gitrepo
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+)
+
+// Log returns up to limit commits reachable from start which changed the file or directory at path, newest first.
+// history is simplified the same way as "git log -- path": a merge that kept the path as in one of its parents
+// is skipped and only that parent is followed. limit <= 0 means no limit.
+func (r *Repo) Log(ctx context.Context, start Hash, path string, limit int) ([]*Commit, error) {
+	first, err := r.ReadCommit(start)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Commit
+	queue := &commitQueue{first}
+	seen := map[Hash]bool{start: true}
+	for queue.Len() > 0 && (limit <= 0 || len(res) < limit) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+		default:
+		}
+
+		c := heap.Pop(queue).(*Commit) // nolint:forcetypeassert // queue holds commits only
+		entry, err := r.entryHash(c.Tree, path)
+		if err != nil {
+			return nil, err
+		}
+
+		parents := make([]*Commit, 0, len(c.Parents))
+		sameAs := -1 // index of the first parent with identical entry
+		for _, ph := range c.Parents {
+			p, err := r.ReadCommit(ph)
+			if err != nil {
+				return nil, err
+			}
+			pEntry, err := r.entryHash(p.Tree, path)
+			if err != nil {
+				return nil, err
+			}
+			if sameAs < 0 && pEntry == entry {
+				sameAs = len(parents)
+			}
+			parents = append(parents, p)
+		}
+
+		follow := parents
+		switch {
+		case sameAs >= 0:
+			follow = parents[sameAs : sameAs+1]
+		case len(parents) > 0 || !entry.IsZero():
+			// changed compared to all parents, root commit counts if it added the path
+			res = append(res, c)
+		}
+
+		for _, p := range follow {
+			if !seen[p.Hash] {
+				seen[p.Hash] = true
+				heap.Push(queue, p)
+			}
+		}
+	}
+	return res, nil
+}
+
+// entryHash returns object name of the path in the tree, zero hash if the path doesn't exist
+func (r *Repo) entryHash(tree Hash, path string) (Hash, error) {
+	e, err := r.FindEntry(tree, path)
+	if errors.Is(err, ErrNotFound) {
+		return Hash{}, nil
+	}
+	if err != nil {
+		return Hash{}, err
+	}
+	return e.Hash, nil
+}
+
+// commitQueue orders commits by committer date, newest first
+type commitQueue []*Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) {
+	*q = append(*q, x.(*Commit)) // nolint:forcetypeassert // queue holds commits only
+}
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,87 @@
+package gitrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_Log(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+
+	step := 0
+	commit := func(files map[string]string, msg string) {
+		t.Helper()
+		for name, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o750))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+		step++
+		date := fmt.Sprintf("GIT_COMMITTER_DATE=2024-01-%02dT10:00:00Z", step)
+		runGitEnv(t, dir, []string{date, "GIT_AUTHOR_" + date[4:]}, "add", ".")
+		runGitEnv(t, dir, []string{date, "GIT_AUTHOR_" + date[4:]}, "commit", "-q", "-m", msg)
+	}
+
+	commit(map[string]string{"docs/runbook.md": "v1\n", "other.md": "x\n"}, "add runbook")
+	commit(map[string]string{"other.md": "y\n"}, "touch other")
+	commit(map[string]string{"docs/runbook.md": "v2\n"}, "update runbook")
+
+	// feature branch changes runbook, main changes other file, then merge
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	commit(map[string]string{"docs/runbook.md": "v3\n"}, "runbook on feature")
+	runGit(t, dir, "checkout", "-q", "main")
+	commit(map[string]string{"other.md": "z\n"}, "other on main")
+	step++
+	runGitEnv(t, dir, []string{fmt.Sprintf("GIT_COMMITTER_DATE=2024-01-%02dT10:00:00Z", step)},
+		"merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+	head, err := repo.ResolveRevision("HEAD")
+	require.NoError(t, err)
+
+	subjects := func(commits []*Commit) []string {
+		res := make([]string, 0, len(commits))
+		for _, c := range commits {
+			res = append(res, c.Subject())
+		}
+		return res
+	}
+
+	commits, err := repo.Log(context.Background(), head, "docs/runbook.md", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"runbook on feature", "update runbook", "add runbook"}, subjects(commits),
+		"merge kept runbook from feature and is skipped")
+
+	commits, err = repo.Log(context.Background(), head, "docs/runbook.md", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"runbook on feature", "update runbook"}, subjects(commits))
+
+	commits, err = repo.Log(context.Background(), head, "other.md", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other on main", "touch other", "add runbook"}, subjects(commits))
+
+	commits, err = repo.Log(context.Background(), head, "docs", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"runbook on feature", "update runbook", "add runbook"}, subjects(commits), "directory history")
+
+	commits, err = repo.Log(context.Background(), head, "missing.md", 0)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.Log(ctx, head, "docs/runbook.md", 0)
+	require.ErrorIs(t, err, context.Canceled)
+}
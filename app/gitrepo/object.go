@@ -0,0 +1,178 @@
+package gitrepo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ObjectType is a git object type, values match pack file type codes
+type ObjectType int
+
+// object types
+const (
+	ObjCommit   ObjectType = 1
+	ObjTree     ObjectType = 2
+	ObjBlob     ObjectType = 3
+	ObjTag      ObjectType = 4
+	objOfsDelta ObjectType = 6
+	objRefDelta ObjectType = 7
+)
+
+// objectCacheSize is the number of decoded objects kept in memory, mostly delta bases and trees
+const objectCacheSize = 256
+
+// String returns type name as used in object headers
+func (t ObjectType) String() string {
+	switch t {
+	case ObjCommit:
+		return "commit"
+	case ObjTree:
+		return "tree"
+	case ObjBlob:
+		return "blob"
+	case ObjTag:
+		return "tag"
+	case objOfsDelta:
+		return "ofs-delta"
+	case objRefDelta:
+		return "ref-delta"
+	default:
+		return "unknown(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+// parseObjectType converts type name from loose object header
+func parseObjectType(name string) (ObjectType, error) {
+	switch name {
+	case "commit":
+		return ObjCommit, nil
+	case "tree":
+		return ObjTree, nil
+	case "blob":
+		return ObjBlob, nil
+	case "tag":
+		return ObjTag, nil
+	default:
+		return 0, fmt.Errorf("unknown object type %q", name)
+	}
+}
+
+// ReadObject returns type and content of the object, looking in loose objects and packs
+func (r *Repo) ReadObject(h Hash) (ObjectType, []byte, error) {
+	if obj, ok := r.cache.get(h); ok {
+		return obj.typ, obj.data, nil
+	}
+
+	typ, data, err := r.readLoose(h)
+	if errors.Is(err, ErrNotFound) {
+		typ, data, err = r.readPacked(h)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	r.cache.put(h, cachedObject{typ: typ, data: data})
+	return typ, data, nil
+}
+
+// ReadBlob returns content of a blob object
+func (r *Repo) ReadBlob(h Hash) ([]byte, error) {
+	typ, data, err := r.ReadObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != ObjBlob {
+		return nil, fmt.Errorf("object %s is a %s, not a blob", h, typ)
+	}
+	return data, nil
+}
+
+// readLoose reads zlib-compressed object stored as objects/xx/yyyy...
+func (r *Repo) readLoose(h Hash) (ObjectType, []byte, error) {
+	name := h.String()
+	for _, dir := range r.objectDirs {
+		f, err := os.Open(filepath.Join(dir, name[:2], name[2:])) // #nosec G304 - path built from object hash
+		if err != nil {
+			continue
+		}
+		typ, data, err := decodeLoose(f)
+		f.Close()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read loose object %s: %w", name, err)
+		}
+		return typ, data, nil
+	}
+	return 0, nil, fmt.Errorf("object %s: %w", name, ErrNotFound)
+}
+
+// decodeLoose decodes "<type> <size>\x00<content>" loose object format
+func decodeLoose(rd io.Reader) (ObjectType, []byte, error) {
+	zr, err := zlib.NewReader(rd)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid zlib stream: %w", err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+
+	header, data, ok := bytes.Cut(raw, []byte{0})
+	if !ok {
+		return 0, nil, errors.New("missing object header")
+	}
+	typName, sizeStr, ok := bytes.Cut(header, []byte(" "))
+	if !ok {
+		return 0, nil, fmt.Errorf("invalid object header %q", header)
+	}
+	typ, err := parseObjectType(string(typName))
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := strconv.Atoi(string(sizeStr))
+	if err != nil || size != len(data) {
+		return 0, nil, fmt.Errorf("object size mismatch, header %q, actual %d", sizeStr, len(data))
+	}
+	return typ, data, nil
+}
+
+// cachedObject is a decoded object kept in objectCache
+type cachedObject struct {
+	typ  ObjectType
+	data []byte
+}
+
+// objectCache is a small bounded cache of decoded objects. it is cleared when full,
+// which is good enough for repeated reads of the same trees and delta bases.
+type objectCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[Hash]cachedObject
+}
+
+func newObjectCache(size int) *objectCache {
+	return &objectCache{size: size, items: make(map[Hash]cachedObject, size)}
+}
+
+func (c *objectCache) get(h Hash) (cachedObject, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obj, ok := c.items[h]
+	return obj, ok
+}
+
+func (c *objectCache) put(h Hash, obj cachedObject) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.items) >= c.size {
+		c.items = make(map[Hash]cachedObject, c.size)
+	}
+	c.items[h] = obj
+}
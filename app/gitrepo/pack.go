@@ -0,0 +1,383 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	idxV2Magic    = "\377tOc"
+	idxHeaderSize = 8
+	fanoutSize    = 256 * 4
+	maxDeltaDepth = 64
+)
+
+// packFile is a pack with its index, loaded from objects/pack/pack-*.{idx,pack}
+type packFile struct {
+	path    string
+	file    *os.File
+	size    int64
+	version int
+	count   int
+	fanout  [256]uint32
+	idx     []byte // raw index content
+}
+
+// readPacked looks for object in pack files, reloading pack list once if it is not found,
+// because new packs may appear after gc or fetch.
+func (r *Repo) readPacked(h Hash) (ObjectType, []byte, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		packs, err := r.loadPacks(attempt > 0)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, p := range packs {
+			if offset, ok := p.find(h); ok {
+				return r.readPackObject(p, offset, 0)
+			}
+		}
+	}
+	return 0, nil, fmt.Errorf("object %s: %w", h, ErrNotFound)
+}
+
+// loadPacks opens all pack indexes, reload forces re-reading of pack directories
+func (r *Repo) loadPacks(reload bool) ([]*packFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.packsLoaded && !reload {
+		return r.packs, nil
+	}
+
+	known := make(map[string]*packFile, len(r.packs))
+	for _, p := range r.packs {
+		known[p.path] = p
+	}
+
+	var packs []*packFile
+	for _, dir := range r.objectDirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "pack", "pack-*.idx"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list packs: %w", err)
+		}
+		sort.Strings(matches)
+		for _, idxPath := range matches {
+			packPath := strings.TrimSuffix(idxPath, ".idx") + ".pack"
+			if p, ok := known[packPath]; ok {
+				packs = append(packs, p)
+				delete(known, packPath)
+				continue
+			}
+			p, err := openPack(idxPath, packPath)
+			if err != nil {
+				return nil, err
+			}
+			packs = append(packs, p)
+		}
+	}
+
+	// close packs removed since the last load, e.g. by gc
+	for _, p := range known {
+		_ = p.close()
+	}
+
+	r.packs, r.packsLoaded = packs, true
+	return packs, nil
+}
+
+// openPack reads pack index and opens pack file for random access
+func openPack(idxPath, packPath string) (*packFile, error) {
+	idx, err := os.ReadFile(idxPath) // #nosec G304 - path inside git objects dir
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack index: %w", err)
+	}
+
+	p := &packFile{path: packPath, idx: idx, version: 1}
+	fanoutStart := 0
+	if bytes.HasPrefix(idx, []byte(idxV2Magic)) {
+		if len(idx) < idxHeaderSize || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+			return nil, fmt.Errorf("unsupported pack index version in %s", idxPath)
+		}
+		p.version, fanoutStart = 2, idxHeaderSize
+	}
+	if len(idx) < fanoutStart+fanoutSize {
+		return nil, fmt.Errorf("truncated pack index %s", idxPath)
+	}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(idx[fanoutStart+i*4:])
+	}
+	p.count = int(p.fanout[255])
+
+	// validate index size to avoid out of range reads later
+	minSize := fanoutStart + fanoutSize + p.count*24
+	if p.version == 2 {
+		minSize = fanoutStart + fanoutSize + p.count*(20+4+4)
+	}
+	if len(idx) < minSize {
+		return nil, fmt.Errorf("truncated pack index %s", idxPath)
+	}
+
+	f, err := os.Open(packPath) // #nosec G304 - path inside git objects dir
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat pack: %w", err)
+	}
+	p.file, p.size = f, info.Size()
+	return p, nil
+}
+
+func (p *packFile) close() error {
+	if p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+	return err // nolint:wrapcheck // os error is descriptive
+}
+
+// hashAt returns object name of i-th index entry
+func (p *packFile) hashAt(i int) []byte {
+	if p.version == 2 {
+		start := idxHeaderSize + fanoutSize + i*20
+		return p.idx[start : start+20]
+	}
+	start := fanoutSize + i*24 + 4
+	return p.idx[start : start+20]
+}
+
+// find returns pack offset of the object
+func (p *packFile) find(h Hash) (int64, bool) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(p.fanout[h[0]-1])
+	}
+	hi := int(p.fanout[h[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool { return bytes.Compare(p.hashAt(lo+i), h[:]) >= 0 })
+	if i >= hi || !bytes.Equal(p.hashAt(i), h[:]) {
+		return 0, false
+	}
+	return p.offsetAt(i), true
+}
+
+// findPrefix returns names of all objects starting with hex prefix
+func (p *packFile) findPrefix(prefix string) []Hash {
+	// compare on full bytes of the prefix, odd trailing digit is checked on hex form
+	raw, err := hex.DecodeString(prefix[:len(prefix)&^1])
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+	lo := 0
+	if raw[0] > 0 {
+		lo = int(p.fanout[raw[0]-1])
+	}
+	hi := int(p.fanout[raw[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool { return bytes.Compare(p.hashAt(lo+i), raw) >= 0 })
+
+	var res []Hash
+	for ; i < hi && bytes.HasPrefix(p.hashAt(i), raw); i++ {
+		var h Hash
+		copy(h[:], p.hashAt(i))
+		if strings.HasPrefix(h.String(), prefix) {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+// offsetAt returns pack offset of i-th index entry
+func (p *packFile) offsetAt(i int) int64 {
+	if p.version == 1 {
+		return int64(binary.BigEndian.Uint32(p.idx[fanoutSize+i*24:]))
+	}
+	offsetsStart := idxHeaderSize + fanoutSize + p.count*(20+4)
+	off := binary.BigEndian.Uint32(p.idx[offsetsStart+i*4:])
+	if off&0x80000000 == 0 {
+		return int64(off)
+	}
+	// large offsets are stored in a separate table of 8-byte values
+	largeStart := offsetsStart + p.count*4 + int(off&0x7fffffff)*8
+	if largeStart+8 > len(p.idx) {
+		return -1
+	}
+	return int64(binary.BigEndian.Uint64(p.idx[largeStart:])) // nolint:gosec // pack offsets fit int64
+}
+
+// readPackObject reads object at offset, resolving deltas
+func (r *Repo) readPackObject(p *packFile, offset int64, depth int) (ObjectType, []byte, error) {
+	if depth > maxDeltaDepth {
+		return 0, nil, errors.New("delta chain is too deep")
+	}
+	if offset < 0 || offset >= p.size {
+		return 0, nil, fmt.Errorf("invalid pack offset %d in %s", offset, p.path)
+	}
+
+	rd := bufio.NewReader(io.NewSectionReader(p.file, offset, p.size-offset))
+
+	// entry header: type and inflated size encoded as varint
+	b, err := rd.ReadByte()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read pack entry: %w", err)
+	}
+	typ := ObjectType((b >> 4) & 7)
+	size := int64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		if b, err = rd.ReadByte(); err != nil {
+			return 0, nil, fmt.Errorf("failed to read pack entry: %w", err)
+		}
+		size |= int64(b&0x7f) << shift
+	}
+
+	switch typ {
+	case ObjCommit, ObjTree, ObjBlob, ObjTag:
+		data, err := inflate(rd, size)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to inflate %s at %d: %w", typ, offset, err)
+		}
+		return typ, data, nil
+
+	case objOfsDelta:
+		// base offset is relative to this entry, encoded with the "+1 per continuation byte" varint
+		if b, err = rd.ReadByte(); err != nil {
+			return 0, nil, fmt.Errorf("failed to read delta offset: %w", err)
+		}
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = rd.ReadByte(); err != nil {
+				return 0, nil, fmt.Errorf("failed to read delta offset: %w", err)
+			}
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+		delta, err := inflate(rd, size)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to inflate delta at %d: %w", offset, err)
+		}
+		baseType, base, err := r.readPackObject(p, offset-rel, depth+1)
+		if err != nil {
+			return 0, nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	case objRefDelta:
+		var baseHash Hash
+		if _, err = io.ReadFull(rd, baseHash[:]); err != nil {
+			return 0, nil, fmt.Errorf("failed to read delta base: %w", err)
+		}
+		delta, err := inflate(rd, size)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to inflate delta at %d: %w", offset, err)
+		}
+		baseType, base, err := r.ReadObject(baseHash)
+		if err != nil {
+			return 0, nil, fmt.Errorf("delta base %s: %w", baseHash, err)
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	default:
+		return 0, nil, fmt.Errorf("unsupported pack entry type %d at %d", typ, offset)
+	}
+}
+
+// inflate decompresses zlib stream expecting exactly size bytes
+func inflate(rd io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(rd)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // wrapped by caller
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err // nolint:wrapcheck // wrapped by caller
+	}
+	return data, nil
+}
+
+// applyDelta reconstructs object from base and git delta instructions
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, n := deltaVarint(delta)
+	if n == 0 || srcSize != len(base) {
+		return nil, errors.New("delta base size mismatch")
+	}
+	delta = delta[n:]
+	dstSize, n := deltaVarint(delta)
+	if n == 0 {
+		return nil, errors.New("invalid delta header")
+	}
+	delta = delta[n:]
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			// insert next op bytes literally
+			if op == 0 || int(op) > len(delta) {
+				return nil, errors.New("invalid delta insert instruction")
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		// copy from base, offset and size bytes are present according to op bits
+		var offset, size int
+		for i := 0; i < 4; i++ {
+			if op&(1<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errors.New("truncated delta copy instruction")
+				}
+				offset |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if op&(0x10<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errors.New("truncated delta copy instruction")
+				}
+				size |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, errors.New("delta copy out of base bounds")
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+
+	if len(out) != dstSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+	return out, nil
+}
+
+// deltaVarint reads little-endian base-128 size used in delta headers, returns value and bytes consumed
+func deltaVarint(data []byte) (value, n int) {
+	for shift := 0; n < len(data); shift += 7 {
+		b := data[n]
+		n++
+		value |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, n
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,427 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when an object, ref or path doesn't exist in the repository
+var ErrNotFound = errors.New("not found")
+
+const (
+	// maxSymrefDepth limits symbolic ref chains, e.g. HEAD -> refs/heads/main
+	maxSymrefDepth = 5
+	// minShortHash is the minimal length of abbreviated object name
+	minShortHash = 4
+)
+
+// Hash is a SHA-1 object name
+type Hash [20]byte
+
+// ParseHash parses 40-character hex object name
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 2*len(h) {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object name %q: %w", s, err)
+	}
+	return h, nil
+}
+
+// String returns hex representation of the hash
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// IsZero checks if hash is not set
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// Repo provides read-only access to a local git repository, working tree or bare.
+// It reads refs and the object database (loose objects and packs) directly, without the git binary.
+// Repo is safe for concurrent use.
+type Repo struct {
+	gitDir     string   // directory with HEAD, .git or bare repository itself
+	commonDir  string   // directory with refs, packed-refs and objects, differs from gitDir for linked worktrees
+	objectDirs []string // objects directory and alternates
+
+	mu          sync.Mutex
+	packs       []*packFile
+	packsLoaded bool
+	cache       *objectCache
+}
+
+// Open opens repository at path. path can be a working tree (with .git directory or gitdir file)
+// or a bare repository directory.
+func Open(path string) (*Repo, error) {
+	gitDir, err := findGitDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	commonDir := gitDir
+	// linked worktrees keep refs and objects in the main repository
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil { // #nosec G304 - path inside git dir
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	r := &Repo{gitDir: gitDir, commonDir: commonDir, cache: newObjectCache(objectCacheSize)}
+	r.objectDirs = append(r.objectDirs, filepath.Join(commonDir, "objects"))
+	r.objectDirs = append(r.objectDirs, readAlternates(filepath.Join(commonDir, "objects"))...)
+	return r, nil
+}
+
+// GitDir returns the git directory of the repository
+func (r *Repo) GitDir() string {
+	return r.gitDir
+}
+
+// Close releases open pack files
+func (r *Repo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, p := range r.packs {
+		if err := p.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	r.packs, r.packsLoaded = nil, false
+	return errors.Join(errs...)
+}
+
+// findGitDir locates git directory for a working tree or bare repository path
+func findGitDir(path string) (string, error) {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	switch {
+	case err == nil && info.IsDir():
+		return dotGit, nil
+	case err == nil:
+		// .git file points to the real git dir, used by worktrees and submodules
+		data, err := os.ReadFile(dotGit) // #nosec G304 - path is provided by configuration
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", dotGit, err)
+		}
+		line := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(line, "gitdir:") {
+			return "", fmt.Errorf("invalid gitdir file %s", dotGit)
+		}
+		dir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(path, dir)
+		}
+		return dir, nil
+	}
+
+	// bare repository has HEAD and objects at the top level
+	if isGitDir(path) {
+		return path, nil
+	}
+	return "", fmt.Errorf("not a git repository: %s", path)
+}
+
+// isGitDir checks if directory looks like a git directory
+func isGitDir(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(dir, "objects"))
+	return err == nil && info.IsDir()
+}
+
+// FindRepoRoot walks up from path looking for a directory containing .git,
+// returns the working tree root or ErrNotFound.
+func FindRepoRoot(path string) (string, error) {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no git repository above %s: %w", path, ErrNotFound)
+		}
+		dir = parent
+	}
+}
+
+// readAlternates returns additional object directories listed in objects/info/alternates
+func readAlternates(objectsDir string) []string {
+	data, err := os.ReadFile(filepath.Join(objectsDir, "info", "alternates")) // #nosec G304 - path inside git dir
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(objectsDir, line)
+		}
+		res = append(res, line)
+	}
+	return res
+}
+
+// ResolveRef resolves ref name (HEAD, branch, tag, remote branch or full ref) to the object it points to
+func (r *Repo) ResolveRef(name string) (Hash, error) {
+	candidates := []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name,
+		"refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"}
+	for _, c := range candidates {
+		h, err := r.readRef(c, 0)
+		if err == nil {
+			return h, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return Hash{}, err
+		}
+	}
+	return Hash{}, fmt.Errorf("ref %q: %w", name, ErrNotFound)
+}
+
+// readRef reads loose ref file or packed-refs entry, following symbolic refs
+func (r *Repo) readRef(name string, depth int) (Hash, error) {
+	if depth > maxSymrefDepth {
+		return Hash{}, fmt.Errorf("symbolic ref %q is too deep", name)
+	}
+	if name == "" || strings.Contains(name, "..") || filepath.IsAbs(name) {
+		return Hash{}, fmt.Errorf("invalid ref name %q", name)
+	}
+
+	// per-worktree refs (HEAD) live in git dir, shared refs in common dir
+	dirs := []string{r.gitDir}
+	if r.commonDir != r.gitDir {
+		dirs = append(dirs, r.commonDir)
+	}
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))) // #nosec G304 - ref name is validated
+		if err != nil {
+			continue
+		}
+		line := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(line, "ref:"); ok {
+			return r.readRef(strings.TrimSpace(target), depth+1)
+		}
+		if h, err := ParseHash(line); err == nil {
+			return h, nil
+		}
+		// not a ref file, e.g. a directory-like name or config file
+	}
+
+	return r.packedRef(name)
+}
+
+// packedRef looks up ref in packed-refs file
+func (r *Repo) packedRef(name string) (Hash, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs")) // #nosec G304 - path inside git dir
+	if err != nil {
+		return Hash{}, fmt.Errorf("ref %q: %w", name, ErrNotFound)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, ref, ok := strings.Cut(line, " ")
+		if ok && ref == name {
+			return ParseHash(hash)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Hash{}, fmt.Errorf("failed to read packed-refs: %w", err)
+	}
+	return Hash{}, fmt.Errorf("ref %q: %w", name, ErrNotFound)
+}
+
+// ResolveRevision resolves a revision to a commit. Supported forms are full and abbreviated (4+ characters) object names,
+// ref names (HEAD, branches, tags, remote branches) and ancestry suffixes ~N, ^ and ^N, e.g. main~2 or v1.0^2.
+// Annotated tags are peeled to the commit they point to.
+func (r *Repo) ResolveRevision(rev string) (Hash, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return Hash{}, errors.New("empty revision")
+	}
+
+	// split base name from ancestry suffixes
+	idx := strings.IndexAny(rev, "~^")
+	base, suffix := rev, ""
+	if idx > 0 {
+		base, suffix = rev[:idx], rev[idx:]
+	}
+
+	h, err := r.resolveBase(base)
+	if err != nil {
+		return Hash{}, err
+	}
+	if h, err = r.peelToCommit(h); err != nil {
+		return Hash{}, fmt.Errorf("revision %q: %w", rev, err)
+	}
+
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		// read optional number after the operator
+		n := 0
+		for n < len(suffix) && suffix[n] >= '0' && suffix[n] <= '9' {
+			n++
+		}
+		count := 1
+		if n > 0 {
+			if count, err = strconv.Atoi(suffix[:n]); err != nil {
+				return Hash{}, fmt.Errorf("invalid revision %q: %w", rev, err)
+			}
+			suffix = suffix[n:]
+		}
+
+		if op == '~' {
+			for i := 0; i < count; i++ {
+				if h, err = r.nthParent(h, 1); err != nil {
+					return Hash{}, fmt.Errorf("revision %q: %w", rev, err)
+				}
+			}
+			continue
+		}
+		if op != '^' {
+			return Hash{}, fmt.Errorf("invalid revision %q", rev)
+		}
+		if count == 0 {
+			continue // rev^0 is the commit itself
+		}
+		if h, err = r.nthParent(h, count); err != nil {
+			return Hash{}, fmt.Errorf("revision %q: %w", rev, err)
+		}
+	}
+	return h, nil
+}
+
+// resolveBase resolves revision without ancestry suffixes. refs take precedence over abbreviated object names.
+func (r *Repo) resolveBase(base string) (Hash, error) {
+	if h, err := ParseHash(base); err == nil {
+		return h, nil
+	}
+	h, err := r.ResolveRef(base)
+	if errors.Is(err, ErrNotFound) && isShortHash(base) {
+		return r.resolveShortHash(strings.ToLower(base))
+	}
+	return h, err
+}
+
+// isShortHash checks if s looks like an abbreviated object name
+func isShortHash(s string) bool {
+	if len(s) < minShortHash || len(s) >= 2*len(Hash{}) {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveShortHash finds the object with name starting with hex prefix in loose objects and packs
+func (r *Repo) resolveShortHash(prefix string) (Hash, error) {
+	found := map[Hash]bool{}
+
+	for _, dir := range r.objectDirs {
+		entries, err := os.ReadDir(filepath.Join(dir, prefix[:2]))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), prefix[2:]) {
+				if h, err := ParseHash(prefix[:2] + e.Name()); err == nil {
+					found[h] = true
+				}
+			}
+		}
+	}
+
+	packs, err := r.loadPacks(true)
+	if err != nil {
+		return Hash{}, err
+	}
+	for _, p := range packs {
+		for _, h := range p.findPrefix(prefix) {
+			found[h] = true
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Hash{}, fmt.Errorf("object %s: %w", prefix, ErrNotFound)
+	case 1:
+		for h := range found {
+			return h, nil
+		}
+	}
+	return Hash{}, fmt.Errorf("short object name %s is ambiguous", prefix)
+}
+
+// nthParent returns n-th parent (1-based) of the commit
+func (r *Repo) nthParent(h Hash, n int) (Hash, error) {
+	c, err := r.ReadCommit(h)
+	if err != nil {
+		return Hash{}, err
+	}
+	if n > len(c.Parents) {
+		return Hash{}, fmt.Errorf("commit %s has no parent %d: %w", h, n, ErrNotFound)
+	}
+	return c.Parents[n-1], nil
+}
+
+// peelToCommit follows annotated tags until a commit is reached
+func (r *Repo) peelToCommit(h Hash) (Hash, error) {
+	for i := 0; i < maxSymrefDepth; i++ {
+		typ, data, err := r.ReadObject(h)
+		if err != nil {
+			return Hash{}, err
+		}
+		switch typ {
+		case ObjCommit:
+			return h, nil
+		case ObjTag:
+			target, err := parseTagTarget(data)
+			if err != nil {
+				return Hash{}, fmt.Errorf("tag %s: %w", h, err)
+			}
+			h = target
+		default:
+			return Hash{}, fmt.Errorf("object %s is a %s, not a commit", h, typ)
+		}
+	}
+	return Hash{}, fmt.Errorf("tag chain at %s is too deep", h)
+}
+
+// parseTagTarget extracts "object" header of an annotated tag
+func parseTagTarget(data []byte) (Hash, error) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			break // end of headers
+		}
+		if target, ok := bytes.CutPrefix(line, []byte("object ")); ok {
+			return ParseHash(string(target))
+		}
+	}
+	return Hash{}, errors.New("missing object header")
+}
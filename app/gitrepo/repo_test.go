@@ -0,0 +1,292 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runGit runs git command in dir with deterministic identity and dates
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	return runGitEnv(t, dir, nil, args...)
+}
+
+// runGitEnv runs git command with extra environment variables, overriding the defaults
+func runGitEnv(t *testing.T, dir string, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test Author", "GIT_AUTHOR_EMAIL=author@example.com",
+		"GIT_COMMITTER_NAME=Test Committer", "GIT_COMMITTER_EMAIL=committer@example.com",
+		"GIT_AUTHOR_DATE=2024-01-02T03:04:05+02:00", "GIT_COMMITTER_DATE=2024-01-02T03:04:05+02:00",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+	return strings.TrimSpace(string(out))
+}
+
+// makeTestRepo creates repository with two commits on main, a release branch and tags
+func makeTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs", "guides"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "intro.md"), []byte("# Intro\nversion one\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "guides", "setup.md"),
+		[]byte("# Setup\n"+strings.Repeat("step line\n", 50)), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Readme\n"), 0o600))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial docs")
+	runGit(t, dir, "tag", "-a", "v1.0", "-m", "release 1.0")
+	runGit(t, dir, "branch", "release")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "intro.md"), []byte("# Intro\nversion two\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "guides", "setup.md"),
+		[]byte("# Setup\n"+strings.Repeat("step line\n", 50)+"extra step\n"), 0o600))
+	runGit(t, dir, "commit", "-q", "-am", "update docs\n\nlonger description")
+	runGit(t, dir, "tag", "light")
+	return dir
+}
+
+func TestOpen(t *testing.T) {
+	dir := makeTestRepo(t)
+
+	t.Run("working tree", func(t *testing.T) {
+		repo, err := Open(dir)
+		require.NoError(t, err)
+		defer repo.Close()
+		assert.Equal(t, filepath.Join(dir, ".git"), repo.GitDir())
+	})
+
+	t.Run("bare clone", func(t *testing.T) {
+		bare := filepath.Join(t.TempDir(), "bare.git")
+		runGit(t, dir, "clone", "-q", "--bare", dir, bare)
+		repo, err := Open(bare)
+		require.NoError(t, err)
+		defer repo.Close()
+		assert.Equal(t, bare, repo.GitDir())
+
+		h, err := repo.ResolveRevision("release")
+		require.NoError(t, err)
+		assert.Equal(t, runGit(t, dir, "rev-parse", "release"), h.String())
+	})
+
+	t.Run("linked worktree", func(t *testing.T) {
+		wt := filepath.Join(t.TempDir(), "wt")
+		runGit(t, dir, "worktree", "add", "-q", wt, "release")
+		repo, err := Open(wt)
+		require.NoError(t, err)
+		defer repo.Close()
+
+		h, err := repo.ResolveRevision("HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, runGit(t, dir, "rev-parse", "release"), h.String())
+		h, err = repo.ResolveRevision("main")
+		require.NoError(t, err)
+		assert.Equal(t, runGit(t, dir, "rev-parse", "main"), h.String())
+	})
+
+	t.Run("not a repository", func(t *testing.T) {
+		_, err := Open(t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not a git repository")
+	})
+}
+
+func TestRepo_ResolveRevision(t *testing.T) {
+	dir := makeTestRepo(t)
+
+	// test both loose and packed refs/objects
+	for _, packed := range []bool{false, true} {
+		name := "loose"
+		if packed {
+			name = "packed"
+		}
+		t.Run(name, func(t *testing.T) {
+			repoDir := dir
+			if packed {
+				repoDir = filepath.Join(t.TempDir(), "packed")
+				runGit(t, dir, "clone", "-q", "--no-local", dir, repoDir)
+				runGit(t, repoDir, "branch", "release", "origin/release")
+				runGit(t, repoDir, "repack", "-adfq", "--depth=10")
+				runGit(t, repoDir, "pack-refs", "--all")
+				entries, err := os.ReadDir(filepath.Join(repoDir, ".git", "objects", "pack"))
+				require.NoError(t, err)
+				require.NotEmpty(t, entries, "objects should be packed")
+			}
+
+			repo, err := Open(repoDir)
+			require.NoError(t, err)
+			defer repo.Close()
+
+			for _, rev := range []string{"HEAD", "main", "release", "v1.0", "light", "refs/heads/main", "HEAD~1", "HEAD^", "main^0", "v1.0~0"} {
+				h, err := repo.ResolveRevision(rev)
+				require.NoError(t, err, rev)
+				assert.Equal(t, runGit(t, repoDir, "rev-parse", rev+"^{commit}"), h.String(), rev)
+			}
+
+			head := runGit(t, repoDir, "rev-parse", "HEAD")
+			h, err := repo.ResolveRevision(head)
+			require.NoError(t, err)
+			assert.Equal(t, head, h.String())
+
+			short, err := repo.ResolveRevision(head[:7])
+			require.NoError(t, err)
+			assert.Equal(t, head, short.String())
+			short, err = repo.ResolveRevision(strings.ToUpper(head[:9]) + "~1")
+			require.NoError(t, err)
+			assert.Equal(t, runGit(t, repoDir, "rev-parse", "HEAD~1"), short.String())
+			_, err = repo.ResolveRevision(head[:3])
+			require.Error(t, err, "too short to be an object name")
+
+			_, err = repo.ResolveRevision("nonexistent")
+			require.ErrorIs(t, err, ErrNotFound)
+			_, err = repo.ResolveRevision("HEAD~5")
+			require.ErrorIs(t, err, ErrNotFound)
+			_, err = repo.ResolveRevision("")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRepo_ReadFileAt(t *testing.T) {
+	dir := makeTestRepo(t)
+
+	packedDir := filepath.Join(t.TempDir(), "packed")
+	runGit(t, dir, "clone", "-q", "--no-local", dir, packedDir)
+	runGit(t, packedDir, "repack", "-adfq", "--depth=10")
+
+	for _, repoDir := range []string{dir, packedDir} {
+		repo, err := Open(repoDir)
+		require.NoError(t, err)
+
+		head, err := repo.ResolveRevision("HEAD")
+		require.NoError(t, err)
+		prev, err := repo.ResolveRevision("HEAD~1")
+		require.NoError(t, err)
+
+		data, err := repo.ReadFileAt(head, "docs/intro.md")
+		require.NoError(t, err)
+		assert.Equal(t, "# Intro\nversion two\n", string(data))
+
+		data, err = repo.ReadFileAt(prev, "docs/intro.md")
+		require.NoError(t, err)
+		assert.Equal(t, "# Intro\nversion one\n", string(data))
+
+		// setup.md is stored as delta in the packed repo
+		data, err = repo.ReadFileAt(head, "docs/guides/setup.md")
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(data), "extra step\n"))
+		data, err = repo.ReadFileAt(prev, "/docs/guides/setup.md")
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "extra step")
+
+		_, err = repo.ReadFileAt(head, "docs/missing.md")
+		require.ErrorIs(t, err, ErrNotFound)
+		_, err = repo.ReadFileAt(head, "docs")
+		require.ErrorIs(t, err, ErrNotFound)
+		require.NoError(t, repo.Close())
+	}
+}
+
+func TestRepo_ReadCommitAndWalk(t *testing.T) {
+	dir := makeTestRepo(t)
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	head, err := repo.ResolveRevision("HEAD")
+	require.NoError(t, err)
+	c, err := repo.ReadCommit(head)
+	require.NoError(t, err)
+	assert.Equal(t, "update docs", c.Subject())
+	assert.Equal(t, "Test Author", c.Author.Name)
+	assert.Equal(t, "author@example.com", c.Author.Email)
+	assert.Equal(t, "2024-01-02T03:04:05+02:00", c.Author.When.Format("2006-01-02T15:04:05-07:00"))
+	assert.Equal(t, "Test Committer", c.Committer.Name)
+	require.Len(t, c.Parents, 1)
+
+	var paths []string
+	err = repo.Walk(c.Tree, "docs", func(p string, e TreeEntry) error {
+		if e.IsFile() {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guides/setup.md", "intro.md"}, paths)
+
+	paths = nil
+	err = repo.Walk(c.Tree, "", func(p string, e TreeEntry) error {
+		if e.IsDir() && e.Name == "guides" {
+			return SkipDir
+		}
+		if e.IsFile() {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "docs/intro.md"}, paths)
+
+	err = repo.Walk(c.Tree, "README.md", func(string, TreeEntry) error { return nil })
+	require.Error(t, err)
+
+	_, err = repo.ReadCommit(c.Tree)
+	require.Error(t, err, "tree is not a commit")
+	_, err = repo.ReadTree(head)
+	require.Error(t, err, "commit is not a tree")
+}
+
+func TestFindRepoRoot(t *testing.T) {
+	dir := makeTestRepo(t)
+
+	root, err := FindRepoRoot(filepath.Join(dir, "docs", "guides"))
+	require.NoError(t, err)
+	assert.Equal(t, dir, root)
+
+	_, err = FindRepoRoot(t.TempDir())
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello world")
+	// src size 11, dst size 17: copy "hello " (offset 0, size 6), insert "there", copy " world" (offset 5, size 6)
+	delta := []byte{11, 17, 0x90, 6, 5, 't', 'h', 'e', 'r', 'e', 0x91, 5, 6}
+	out, err := applyDelta(base, delta)
+	require.NoError(t, err)
+	assert.Equal(t, "hello there world", string(out))
+
+	_, err = applyDelta(base, []byte{11, 16, 0x90, 6, 5, 't', 'h', 'e', 'r', 'e', 0x91, 5, 6})
+	require.Error(t, err, "result size mismatch should be detected")
+
+	_, err = applyDelta([]byte("short"), delta)
+	require.Error(t, err)
+	_, err = applyDelta(base, []byte{11, 1, 0})
+	require.Error(t, err, "zero instruction is invalid")
+}
+
+func TestParseHash(t *testing.T) {
+	h, err := ParseHash("0123456789abcdef0123456789abcdef01234567")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", h.String())
+	assert.False(t, h.IsZero())
+	assert.True(t, Hash{}.IsZero())
+
+	_, err = ParseHash("0123")
+	require.Error(t, err)
+	_, err = ParseHash("zz23456789abcdef0123456789abcdef01234567")
+	require.Error(t, err)
+}
@@ -0,0 +1,290 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// Severity defines how serious an issue is
+type Severity string
+
+const (
+	// SeverityError marks issues that make metadata unusable
+	SeverityError Severity = "error"
+	// SeverityWarning marks issues worth fixing but not breaking anything
+	SeverityWarning Severity = "warning"
+)
+
+// issue rules reported by the linter
+const (
+	RuleMalformed      = "malformed-frontmatter"
+	RuleUnclosed       = "unclosed-frontmatter"
+	RuleOutsideWindow  = "frontmatter-outside-window"
+	RuleUnknownKey     = "unknown-key"
+	RuleMissingField   = "missing-field"
+	RuleDuplicatePath  = "duplicate-path"
+	RuleOversize       = "oversize"
+	RuleUnreadableFile = "unreadable-file"
+)
+
+// knownKeys are frontmatter keys used by the scanner, always allowed
+var knownKeys = []string{"name", "description", "tags", "priority", "audience"}
+
+// skillKeys are frontmatter keys of the skill format, allowed in skills only
+var skillKeys = []string{"license", "allowed-tools", "metadata", "version"}
+
+// commandKeys are frontmatter keys of the slash command format, allowed in commands only
+var commandKeys = []string{"allowed-tools", "argument-hint", "model", "disable-model-invocation"}
+
+// Schema defines expectations for frontmatter, loaded from a user-supplied YAML file
+type Schema struct {
+	Required     []string `yaml:"required" json:"required,omitempty"`           // keys every doc must define
+	Known        []string `yaml:"known" json:"known,omitempty"`                 // additional allowed keys
+	AllowUnknown bool     `yaml:"allow_unknown" json:"allow_unknown,omitempty"` // don't report unknown keys
+}
+
+// LoadSchema reads schema from YAML file
+func LoadSchema(path string) (Schema, error) {
+	var schema Schema
+	// #nosec G304 - path is provided by the user via cli option
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return schema, fmt.Errorf("failed to read lint schema: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return schema, fmt.Errorf("failed to parse lint schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// Issue is a single problem found in a documentation file
+type Issue struct {
+	Path     string   `json:"path"` // file name with source prefix
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Report contains all issues found by the linter
+type Report struct {
+	Files    int     `json:"files"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Issues   []Issue `json:"issues"`
+}
+
+// Failed returns true if the report has errors, or any issues in strict mode
+func (r *Report) Failed(strict bool) bool {
+	if strict {
+		return len(r.Issues) > 0
+	}
+	return r.Errors > 0
+}
+
+// WriteText writes human-readable report
+func (r *Report) WriteText(w io.Writer) error {
+	for _, issue := range r.Issues {
+		if _, err := fmt.Fprintf(w, "%s: %s [%s] %s\n", issue.Path, issue.Severity, issue.Rule, issue.Message); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintf(w, "%d files checked, %d errors, %d warnings\n", r.Files, r.Errors, r.Warnings); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// Linter checks documentation files against the schema
+type Linter struct {
+	schema      Schema
+	maxFileSize int64
+}
+
+// New creates a linter with the given schema and max file size
+func New(schema Schema, maxFileSize int64) *Linter {
+	return &Linter{schema: schema, maxFileSize: maxFileSize}
+}
+
+// Lint checks all files and returns a report. Issues are sorted by path and rule.
+// files from git sources are skipped, they are snapshots of other refs and not editable in place.
+func (l *Linter) Lint(ctx context.Context, files []scanner.FileInfo) (*Report, error) {
+	local := make([]scanner.FileInfo, 0, len(files))
+	for _, f := range files {
+		if f.Source != scanner.SourceGit {
+			local = append(local, f)
+		}
+	}
+	files = local
+	report := &Report{Files: len(files), Issues: []Issue{}}
+
+	for _, f := range files {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+		default:
+		}
+		report.Issues = append(report.Issues, l.lintFile(f)...)
+	}
+	report.Issues = append(report.Issues, duplicates(files)...)
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Path != report.Issues[j].Path {
+			return report.Issues[i].Path < report.Issues[j].Path
+		}
+		return report.Issues[i].Rule < report.Issues[j].Rule
+	})
+
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report, nil
+}
+
+// lintFile checks a single file
+func (l *Linter) lintFile(f scanner.FileInfo) []Issue {
+	newIssue := func(rule string, sev Severity, format string, args ...any) Issue {
+		return Issue{Path: f.Filename, Rule: rule, Severity: sev, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if f.Size > l.maxFileSize {
+		// oversize files are not served, no reason to check the content
+		return []Issue{newIssue(RuleOversize, SeverityWarning, "file size %d bytes exceeds max %d bytes", f.Size, l.maxFileSize)}
+	}
+
+	// #nosec G304 - path is from scanner, not user input
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return []Issue{newIssue(RuleUnreadableFile, SeverityError, "%v", err)}
+	}
+
+	fm, stripped, err := scanner.DecodeFrontmatter(content)
+	if err != nil {
+		if errors.Is(err, scanner.ErrUnclosedFrontmatter) {
+			return []Issue{newIssue(RuleUnclosed, SeverityError, "%v", err)}
+		}
+		return []Issue{newIssue(RuleMalformed, SeverityError, "%v", err)}
+	}
+
+	var issues []Issue
+
+	// scanner reads only the head of the file, frontmatter beyond it is silently lost
+	if fm.Format != scanner.FormatNone && len(content) > scanner.FrontmatterWindow {
+		if _, _, winErr := scanner.DecodeFrontmatter(content[:scanner.FrontmatterWindow]); winErr != nil {
+			issues = append(issues, newIssue(RuleOutsideWindow, SeverityError,
+				"frontmatter is %d bytes, only first %d bytes are indexed", len(content)-len(stripped), scanner.FrontmatterWindow))
+		}
+	}
+
+	for _, key := range l.unknownKeys(f.Source, fm.Fields) {
+		issues = append(issues, newIssue(RuleUnknownKey, SeverityWarning, "unknown frontmatter key %q", key))
+	}
+
+	for _, key := range l.schema.Required {
+		if v, ok := fm.Fields[key]; !ok || isEmptyValue(v) {
+			issues = append(issues, newIssue(RuleMissingField, SeverityError, "required frontmatter field %q is missing", key))
+		}
+	}
+
+	return issues
+}
+
+// unknownKeys returns sorted keys not defined by the scanner, the skill or command format or the schema
+func (l *Linter) unknownKeys(source scanner.Source, fields map[string]any) []string {
+	if l.schema.AllowUnknown {
+		return nil
+	}
+
+	lists := [][]string{knownKeys, l.schema.Known, l.schema.Required}
+	switch source {
+	case scanner.SourceSkills:
+		lists = append(lists, skillKeys)
+	case scanner.SourceCommands:
+		lists = append(lists, commandKeys)
+	}
+	allowed := make(map[string]bool, len(knownKeys)+len(skillKeys)+len(commandKeys)+len(l.schema.Known)+len(l.schema.Required))
+	for _, list := range lists {
+		for _, k := range list {
+			allowed[k] = true
+		}
+	}
+
+	var res []string
+	for k := range fields {
+		if !allowed[k] {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// duplicates reports the same relative path available from more than one source.
+// read_doc without source prefix resolves such paths to the first source only,
+// skills and memory are not resolved without prefix and can't clash.
+func duplicates(files []scanner.FileInfo) []Issue {
+	byRelPath := map[string][]string{}
+	var order []string
+	for _, f := range files {
+		if f.Source == scanner.SourceSkills || f.Source == scanner.SourceMemory {
+			continue
+		}
+		rel := relPath(f)
+		if _, seen := byRelPath[rel]; !seen {
+			order = append(order, rel)
+		}
+		byRelPath[rel] = append(byRelPath[rel], f.Filename)
+	}
+
+	var issues []Issue
+	for _, rel := range order {
+		names := byRelPath[rel]
+		if len(names) < 2 {
+			continue
+		}
+		for _, name := range names {
+			others := make([]string, 0, len(names)-1)
+			for _, other := range names {
+				if other != name {
+					others = append(others, other)
+				}
+			}
+			issues = append(issues, Issue{Path: name, Rule: RuleDuplicatePath, Severity: SeverityWarning,
+				Message: fmt.Sprintf("path %q is also provided by %s", rel, strings.Join(others, ", "))})
+		}
+	}
+	return issues
+}
+
+// relPath returns file name without source prefix
+func relPath(f scanner.FileInfo) string {
+	rel := strings.TrimPrefix(f.Filename, string(f.Source)+":")
+	return filepath.ToSlash(rel)
+}
+
+// isEmptyValue checks for nil, empty string and empty list values
+func isEmptyValue(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(val) == ""
+	case []any:
+		return len(val) == 0
+	default:
+		return false
+	}
+}
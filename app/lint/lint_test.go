@@ -0,0 +1,175 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+func TestLinter_Lint(t *testing.T) {
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(commandsDir, 0755))
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+
+	files := map[string]string{
+		filepath.Join(commandsDir, "good.md"):      "---\ndescription: fine\ntags: [a]\nowner: me\nargument-hint: <msg>\nmodel: haiku\n---\ncontent",
+		filepath.Join(commandsDir, "unclosed.md"):  "---\ndescription: oops\ncontent",
+		filepath.Join(commandsDir, "malformed.md"): "---\ndescription: [broken\n---\ncontent",
+		filepath.Join(commandsDir, "unknown.md"):   "+++\ndescription = \"x\"\nauthor = \"me\"\n+++\ncontent",
+		filepath.Join(commandsDir, "missing.md"):   "---\ntags: [a]\n---\ncontent",
+		filepath.Join(commandsDir, "window.md"):    "---\ndescription: " + strings.Repeat("a", 3000) + "\n---\ncontent",
+		filepath.Join(commandsDir, "shared.md"):    "---\ndescription: shared\n---\n",
+		filepath.Join(docsDir, "shared.md"):        "---\ndescription: project\n---\n",
+		filepath.Join(docsDir, "big.md"):           strings.Repeat("x", 5000),
+		filepath.Join(docsDir, "hint.md"):          "---\ndescription: x\nargument-hint: <msg>\n---\n",
+	}
+	for path, content := range files {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	sc := scanner.NewScanner(scanner.Params{CommandsDir: commandsDir, ProjectDocsDir: docsDir, MaxFileSize: 1 << 20})
+	scanned, err := sc.Scan(context.Background())
+	require.NoError(t, err)
+
+	linter := New(Schema{Required: []string{"description"}, Known: []string{"owner"}}, 4096)
+	report, err := linter.Lint(context.Background(), scanned)
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	for _, issue := range report.Issues {
+		got[issue.Path+" "+issue.Rule] = issue.Message
+	}
+
+	assert.Contains(t, got, "commands:unclosed.md "+RuleUnclosed)
+	assert.Contains(t, got, "commands:malformed.md "+RuleMalformed)
+	assert.Equal(t, `unknown frontmatter key "author"`, got["commands:unknown.md "+RuleUnknownKey])
+	assert.Equal(t, `required frontmatter field "description" is missing`, got["commands:missing.md "+RuleMissingField])
+	assert.Contains(t, got["commands:window.md "+RuleOutsideWindow], "only first 2048 bytes are indexed")
+	assert.Equal(t, `path "shared.md" is also provided by project-docs:shared.md`, got["commands:shared.md "+RuleDuplicatePath])
+	assert.Equal(t, `path "shared.md" is also provided by commands:shared.md`, got["project-docs:shared.md "+RuleDuplicatePath])
+	assert.Equal(t, "file size 5000 bytes exceeds max 4096 bytes", got["project-docs:big.md "+RuleOversize])
+	assert.Equal(t, `unknown frontmatter key "argument-hint"`, got["project-docs:hint.md "+RuleUnknownKey], "command keys are for commands only")
+	for k := range got {
+		assert.NotContains(t, k, "good.md", "valid file should have no issues")
+	}
+
+	assert.Equal(t, len(scanned), report.Files)
+	assert.Equal(t, 4, report.Errors)
+	assert.Equal(t, 5, report.Warnings)
+	assert.True(t, report.Failed(false))
+
+	// issues are sorted by path
+	for i := 1; i < len(report.Issues); i++ {
+		assert.LessOrEqual(t, report.Issues[i-1].Path, report.Issues[i].Path)
+	}
+}
+
+func TestLinter_Lint_AllowUnknown(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "doc.md")
+	require.NoError(t, os.WriteFile(path, []byte("---\nauthor: me\n---\ncontent"), 0600))
+
+	files := []scanner.FileInfo{{Filename: "project-docs:doc.md", Source: scanner.SourceProjectDocs, Path: path, Size: 10}}
+
+	report, err := New(Schema{}, 1024).Lint(context.Background(), files)
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, RuleUnknownKey, report.Issues[0].Rule)
+	assert.False(t, report.Failed(false), "warnings should not fail in non-strict mode")
+	assert.True(t, report.Failed(true), "warnings should fail in strict mode")
+
+	report, err = New(Schema{AllowUnknown: true}, 1024).Lint(context.Background(), files)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestLinter_Lint_UnreadableAndCanceled(t *testing.T) {
+	files := []scanner.FileInfo{{Filename: "commands:gone.md", Source: scanner.SourceCommands, Path: "/nonexistent/gone.md"}}
+
+	report, err := New(Schema{}, 1024).Lint(context.Background(), files)
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, RuleUnreadableFile, report.Issues[0].Rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New(Schema{}, 1024).Lint(ctx, files)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLinter_Lint_SkipsGitFiles(t *testing.T) {
+	files := []scanner.FileInfo{
+		{Filename: "app@main:guide.md", Source: scanner.SourceGit, Size: 10},
+		{Filename: "app@release:guide.md", Source: scanner.SourceGit, Size: 10},
+	}
+
+	report, err := New(Schema{Required: []string{"description"}}, 1024).Lint(context.Background(), files)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Files)
+	assert.Empty(t, report.Issues)
+}
+
+func TestLinter_Lint_SkillsAndMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	skill := filepath.Join(tmpDir, "SKILL.md")
+	require.NoError(t, os.WriteFile(skill, []byte("---\nname: pdf\ndescription: x\nlicense: MIT\nallowed-tools: Read\n---\n"), 0600))
+	memory := filepath.Join(tmpDir, "CLAUDE.md")
+	require.NoError(t, os.WriteFile(memory, []byte("---\nname: memory\nlicense: MIT\n---\n"), 0600))
+
+	files := []scanner.FileInfo{
+		{Filename: "skills:pdf/SKILL.md", Source: scanner.SourceSkills, Path: skill, Size: 10},
+		{Filename: "memory:CLAUDE.md", Source: scanner.SourceMemory, Path: memory, Size: 10},
+		{Filename: "memory:up/1/CLAUDE.md", Source: scanner.SourceMemory, Path: memory, Size: 10},
+		{Filename: "project-root:CLAUDE.md", Source: scanner.SourceProjectRoot, Path: memory, Size: 10},
+	}
+
+	report, err := New(Schema{}, 1024).Lint(context.Background(), files)
+	require.NoError(t, err)
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, issue.Path+" "+issue.Rule+" "+issue.Message)
+	}
+	// skill keys are allowed in skills only, memory files don't clash with the same path in other sources
+	assert.Equal(t, []string{
+		`memory:CLAUDE.md unknown-key unknown frontmatter key "license"`,
+		`memory:up/1/CLAUDE.md unknown-key unknown frontmatter key "license"`,
+		`project-root:CLAUDE.md unknown-key unknown frontmatter key "license"`,
+	}, got)
+}
+
+func TestLoadSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "schema.yml")
+	require.NoError(t, os.WriteFile(path, []byte("required: [description]\nknown: [owner, title]\nallow_unknown: true\n"), 0600))
+
+	schema, err := LoadSchema(path)
+	require.NoError(t, err)
+	assert.Equal(t, Schema{Required: []string{"description"}, Known: []string{"owner", "title"}, AllowUnknown: true}, schema)
+
+	_, err = LoadSchema(filepath.Join(tmpDir, "missing.yml"))
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("required: [broken"), 0600))
+	_, err = LoadSchema(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse lint schema")
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := &Report{Files: 2, Errors: 1, Issues: []Issue{
+		{Path: "commands:a.md", Rule: RuleUnclosed, Severity: SeverityError, Message: "not closed"},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	assert.Equal(t, "commands:a.md: error [unclosed-frontmatter] not closed\n2 files checked, 1 errors, 0 warnings\n", buf.String())
+}
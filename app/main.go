@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -14,6 +16,12 @@ import (
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/umputun/local-docs-mcp/app/audit"
+	"github.com/umputun/local-docs-mcp/app/gaps"
+	"github.com/umputun/local-docs-mcp/app/lint"
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/redact"
+	"github.com/umputun/local-docs-mcp/app/scanner"
 	"github.com/umputun/local-docs-mcp/app/server"
 )
 
@@ -27,12 +35,84 @@ type Options struct {
 	ExcludeDirs    []string      `long:"exclude-dir" env:"EXCLUDE_DIRS" env-delim:"," default:"plans" description:"directories to exclude from docs scan"`
 	CacheTTL       time.Duration `long:"cache-ttl" env:"CACHE_TTL" default:"1h" description:"cache TTL (time-to-live) for file list"`
 	MaxFileSize    int64         `long:"max-file-size" env:"MAX_FILE_SIZE" default:"5242880" description:"maximum file size in bytes to index"`
+	ContentCache   int64         `long:"content-cache-size" env:"CONTENT_CACHE_SIZE" default:"33554432" description:"bytes of doc contents cached in memory, 0 to disable"`
+	LintSchema     string        `long:"lint-schema" env:"LINT_SCHEMA" description:"YAML file with frontmatter lint schema"`
+	GitSources     []string      `long:"git-source" env:"GIT_SOURCES" env-delim:"," description:"git ref to read docs from, [name=]repo@ref[:subdir]"`
+	SkillsDir      string        `long:"skills-dir" env:"SKILLS_DIR" default:"~/.claude/skills" description:"skills directory, empty to disable"`
+	EnableMemory   bool          `long:"enable-memory" env:"ENABLE_MEMORY" description:"enable discovery of CLAUDE.md and AGENTS.md files"`
+	GlobalMemory   string        `long:"global-memory-dir" env:"GLOBAL_MEMORY_DIR" default:"~/.claude" description:"directory with global CLAUDE.md"`
+	PlansDir       string        `long:"plans-dir" env:"PLANS_DIR" default:"docs/plans" description:"plans directory relative to cwd, empty to disable plan tools"`
+	EnablePlanEdit bool          `long:"enable-plan-edit" env:"ENABLE_PLAN_EDIT" description:"allow complete_task to check off plan tasks"`
+	RedactRules    string        `long:"redact-rules" env:"REDACT_RULES" description:"YAML file with secret redaction rules"`
+	DisableRedact  bool          `long:"disable-redact" env:"DISABLE_REDACT" description:"return doc content without secret redaction"`
+	AuditLog       string        `long:"audit-log" env:"AUDIT_LOG" description:"JSONL file to record tool calls to"`
+	AuditMaxSize   int64         `long:"audit-max-size" env:"AUDIT_MAX_SIZE" default:"10485760" description:"audit log size in bytes to rotate at"`
+	AuditBackups   int           `long:"audit-backups" env:"AUDIT_BACKUPS" default:"3" description:"rotated audit logs to keep"`
+	MetricsListen  string        `long:"metrics-listen" env:"METRICS_LISTEN" description:"address to serve Prometheus metrics on, e.g. 127.0.0.1:9090"`
+	EnablePprof    bool          `long:"enable-pprof" env:"ENABLE_PPROF" description:"serve pprof handlers on the metrics address"`
+	ProjectsPath   []string      `long:"projects-path" env:"PROJECTS_PATH" env-delim:"," description:"directory with project roots, projects are selected by name"`
+	GapsFile       string        `long:"gaps-file" env:"GAPS_FILE" description:"JSON file to record unmet search queries to"`
+	GapsThreshold  float64       `long:"gaps-threshold" env:"GAPS_THRESHOLD" default:"0.3" description:"top search score below which a query is recorded as a gap"`
+	RankingFile    string        `long:"ranking-profiles" env:"RANKING_PROFILES" description:"YAML file with named ranking profiles"`
+	Precedence     string        `long:"source-precedence" env:"SOURCE_PRECEDENCE" default:"shared-first" description:"sources order for paths without prefix: shared-first, project-first or list of sources"`
+	StrictPaths    bool          `long:"strict-paths" env:"STRICT_PATHS" description:"reject paths without prefix found in several sources"`
 	Debug          bool          `long:"dbg" env:"DEBUG" description:"enable debug logging"`
+
+	Lint        LintCommand        `command:"lint" description:"validate documentation frontmatter and exit"`
+	ScanSecrets ScanSecretsCommand `command:"scan-secrets" description:"report secrets found in documentation files and exit"`
+	Audit       AuditCommand       `command:"audit" description:"summarize the audit log and exit"`
+	Gaps        GapsCommand        `command:"gaps" description:"report the most frequent unmet search queries and exit"`
+	Search      SearchCommand      `command:"search" description:"search docs as search_docs tool does and exit"`
+	RankEval    RankEvalCommand    `command:"rank-eval" description:"evaluate ranking profiles against judgments and exit"`
+}
+
+// LintCommand defines options of the lint subcommand
+type LintCommand struct {
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"report format"`
+	Strict bool   `long:"strict" description:"fail on warnings too"`
+}
+
+// AuditCommand defines options of the audit subcommand
+type AuditCommand struct {
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"summary format"`
+	Top    int    `long:"top" default:"10" description:"number of most read and never found docs to show"`
+}
+
+// GapsCommand defines options of the gaps subcommand
+type GapsCommand struct {
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"report format"`
+	Top    int    `long:"top" default:"20" description:"number of gaps to show"`
+}
+
+// SearchCommand defines options of the search subcommand
+type SearchCommand struct {
+	Format  string `long:"format" choice:"text" choice:"json" default:"text" description:"results format"`
+	Explain bool   `long:"explain" description:"show score breakdown of each result"`
+	Project string `long:"project" description:"project to search, the current one if empty"`
+	Profile string `long:"profile" description:"ranking profile, the default one if empty"`
+	Args    struct {
+		Query []string `positional-arg-name:"query" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// RankEvalCommand defines options of the rank-eval subcommand
+type RankEvalCommand struct {
+	Judgments string   `long:"judgments" required:"true" description:"YAML file with queries and docs expected in their results"`
+	Profiles  []string `long:"profile" description:"ranking profile to evaluate, all profiles if not set"`
+	K         int      `long:"k" default:"5" description:"number of top results to measure recall at, search returns up to 10"`
+	Format    string   `long:"format" choice:"text" choice:"json" default:"text" description:"report format"`
+}
+
+// ScanSecretsCommand defines options of the scan-secrets subcommand
+type ScanSecretsCommand struct {
+	Format string `long:"format" choice:"text" choice:"json" default:"text" description:"report format"`
 }
 
 func main() {
 	var opts Options
-	if _, err := flags.Parse(&opts); err != nil {
+	parser := flags.NewParser(&opts, flags.Default)
+	parser.SubcommandsOptional = true
+	if _, err := parser.Parse(); err != nil {
 		var flagsErr *flags.Error
 		if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
 			os.Exit(0)
@@ -49,6 +129,11 @@ func main() {
 	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
 	slog.SetDefault(slog.New(handler))
 
+	// run subcommand instead of the server if one was requested
+	if parser.Active != nil {
+		os.Exit(runCommand(parser.Active.Name, opts))
+	}
+
 	if os.Getenv("GO_FLAGS_COMPLETION") == "" {
 		slog.Info("starting local-docs MCP server", "version", revision)
 	}
@@ -67,16 +152,322 @@ func main() {
 }
 
 func run(ctx context.Context, opts Options) error {
+	config, err := makeConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	// create server
+	srv, err := server.New(config)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+
+	// run server
+	if err := srv.Run(ctx); err != nil {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
+
+// runCommand runs cli subcommand and returns process exit code
+func runCommand(name string, opts Options) int {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	var err error
+	code := 0
+	switch name {
+	case "lint":
+		code, err = runLint(ctx, opts, os.Stdout)
+	case "scan-secrets":
+		code, err = runScanSecrets(ctx, opts, os.Stdout)
+	case "audit":
+		err = runAudit(opts, os.Stdout)
+	case "gaps":
+		err = runGaps(opts, os.Stdout)
+	case "search":
+		err = runSearch(ctx, opts, os.Stdout)
+	case "rank-eval":
+		err = runRankEval(ctx, opts, os.Stdout)
+	default:
+		err = fmt.Errorf("unknown command: %s", name)
+	}
+	if err != nil {
+		slog.Error("command failed", "command", name, "error", err)
+		return 2
+	}
+	return code
+}
+
+// runLint validates documentation files and writes the report to w.
+// returns exit code 1 if the report has errors (or any issues in strict mode).
+func runLint(ctx context.Context, opts Options, w io.Writer) (int, error) {
+	config, err := makeConfig(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	files, err := scanner.NewScanner(config.ScannerParams()).Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan docs: %w", err)
+	}
+
+	report, err := lint.New(config.LintSchema, config.MaxFileSize).Lint(ctx, files)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lint docs: %w", err)
+	}
+
+	if opts.Lint.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return 0, fmt.Errorf("failed to write report: %w", err)
+		}
+	} else if err := report.WriteText(w); err != nil {
+		return 0, err // nolint:wrapcheck // report error is descriptive
+	}
+
+	if report.Failed(opts.Lint.Strict) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// runScanSecrets reports secrets found in documentation files to w, frontmatter included.
+// redaction rules apply even if redaction is disabled, files of git sources are skipped.
+// returns exit code 1 if any secrets were found.
+func runScanSecrets(ctx context.Context, opts Options, w io.Writer) (int, error) {
+	config, err := makeConfig(opts)
+	if err != nil {
+		return 0, err
+	}
+	redactor, err := makeRedactor(opts)
+	if err != nil {
+		return 0, err
+	}
+
+	files, err := scanner.NewScanner(config.ScannerParams()).Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan docs: %w", err)
+	}
+
+	report := &redact.Report{Findings: []redact.Finding{}}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return 0, err // nolint:wrapcheck // context errors should be returned as-is
+		}
+		if f.Source == scanner.SourceGit || f.Size > config.MaxFileSize {
+			continue
+		}
+		// #nosec G304 - path is from scanner, not user input
+		content, err := os.ReadFile(f.Path)
+		if err != nil {
+			slog.Warn("failed to read file", "path", f.Path, "error", err)
+			continue
+		}
+		report.Files++
+		for _, finding := range redactor.Scan(string(content)) {
+			finding.Path = f.Filename
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	report.Secrets = len(report.Findings)
+
+	if opts.ScanSecrets.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return 0, fmt.Errorf("failed to write report: %w", err)
+		}
+	} else if err := report.WriteText(w); err != nil {
+		return 0, err // nolint:wrapcheck // report error is descriptive
+	}
+
+	if report.Secrets > 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// runAudit writes summary of the audit log and its rotated files to w
+func runAudit(opts Options, w io.Writer) error {
+	if opts.AuditLog == "" {
+		return errors.New("audit log is not set, use --audit-log")
+	}
+	path, err := expandTilde(opts.AuditLog)
+	if err != nil {
+		return err
+	}
+	records, err := audit.ReadRecords(path)
+	if err != nil {
+		return err // nolint:wrapcheck // audit error is descriptive
+	}
+
+	summary := audit.Summarize(records, opts.Audit.Top)
+	if opts.Audit.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+		return nil
+	}
+	return summary.WriteText(w) // nolint:wrapcheck // summary error is descriptive
+}
+
+// runGaps writes the most frequent unmet search queries to w
+func runGaps(opts Options, w io.Writer) error {
+	if opts.GapsFile == "" {
+		return errors.New("gaps file is not set, use --gaps-file")
+	}
+	path, err := expandTilde(opts.GapsFile)
+	if err != nil {
+		return err
+	}
+	store, err := gaps.Open(path, 0)
+	if err != nil {
+		return err // nolint:wrapcheck // gaps error is descriptive
+	}
+
+	top := store.Top(opts.Gaps.Top)
+	if opts.Gaps.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(top); err != nil {
+			return fmt.Errorf("failed to write gaps: %w", err)
+		}
+		return nil
+	}
+	return gaps.WriteText(w, top) // nolint:wrapcheck // gaps error is descriptive
+}
+
+// runSearch searches docs with the query of the search subcommand and writes results to w
+func runSearch(ctx context.Context, opts Options, w io.Writer) error {
+	config, err := makeConfig(opts)
+	if err != nil {
+		return err
+	}
+	srv, err := server.New(config)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+	defer srv.Close()
+
+	res, err := srv.Search(ctx, server.SearchInput{Query: strings.Join(opts.Search.Args.Query, " "),
+		Project: opts.Search.Project, Explain: opts.Search.Explain, Profile: opts.Search.Profile})
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if opts.Search.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+		return nil
+	}
+	return res.WriteText(w) // nolint:wrapcheck // results error is descriptive
+}
+
+// runRankEval evaluates ranking profiles against judgments and writes reports to w
+func runRankEval(ctx context.Context, opts Options, w io.Writer) error {
+	path, err := expandTilde(opts.RankEval.Judgments)
+	if err != nil {
+		return err
+	}
+	judgments, err := ranking.LoadJudgments(path)
+	if err != nil {
+		return err // nolint:wrapcheck // ranking error is descriptive
+	}
+
+	config, err := makeConfig(opts)
+	if err != nil {
+		return err
+	}
+	profiles := opts.RankEval.Profiles
+	if len(profiles) == 0 {
+		profiles = ranking.Names(config.RankingProfiles)
+	}
+	srv, err := server.New(config)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+	defer srv.Close()
+
+	reports := make([]*ranking.Report, 0, len(profiles))
+	for _, name := range profiles {
+		report, err := ranking.Evaluate(ctx, name, judgments, opts.RankEval.K, searchPaths(srv, name))
+		if err != nil {
+			return fmt.Errorf("failed to evaluate profile %s: %w", name, err)
+		}
+		reports = append(reports, report)
+	}
+
+	if opts.RankEval.Format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return fmt.Errorf("failed to write reports: %w", err)
+		}
+		return nil
+	}
+	for i, r := range reports {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if err := r.WriteText(w); err != nil {
+			return err // nolint:wrapcheck // report error is descriptive
+		}
+	}
+	return nil
+}
+
+// searchPaths returns search function of rank evaluation, searching with the named ranking profile
+func searchPaths(srv *server.Server, profile string) ranking.SearchFunc {
+	return func(ctx context.Context, query string) ([]string, error) {
+		res, err := srv.Search(ctx, server.SearchInput{Query: query, Profile: profile})
+		if err != nil {
+			return nil, err // nolint:wrapcheck // wrapped by the caller
+		}
+		paths := make([]string, 0, len(res.Results))
+		for _, r := range res.Results {
+			paths = append(paths, r.Path)
+		}
+		return paths, nil
+	}
+}
+
+// makeRedactor creates secret redactor with built-in detectors and rules from the redact rules file
+func makeRedactor(opts Options) (*redact.Redactor, error) {
+	var cfg redact.Config
+	if opts.RedactRules != "" {
+		path, err := expandTilde(opts.RedactRules)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err = redact.LoadConfig(path); err != nil {
+			return nil, err // nolint:wrapcheck // redact error is descriptive
+		}
+	}
+	return redact.New(cfg) // nolint:wrapcheck // redact error is descriptive
+}
+
+// makeConfig creates server configuration from command line options
+func makeConfig(opts Options) (server.Config, error) {
 	// expand ~ in shared docs dir
 	sharedDocsDir, err := expandTilde(opts.SharedDocsDir)
 	if err != nil {
-		return err
+		return server.Config{}, err
 	}
 
 	// get current directory (project root)
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return server.Config{}, fmt.Errorf("failed to get current directory: %w", err)
 	}
 
 	// project docs dir is relative to cwd
@@ -88,31 +479,122 @@ func run(ctx context.Context, opts Options) error {
 		projectRootDir = cwd
 	}
 
+	skillsDir, err := expandTilde(opts.SkillsDir)
+	if err != nil {
+		return server.Config{}, err
+	}
+
+	// memory files are discovered around the project root, only if enabled
+	memoryRootDir, globalMemoryDir := "", ""
+	if opts.EnableMemory {
+		memoryRootDir = cwd
+		if globalMemoryDir, err = expandTilde(opts.GlobalMemory); err != nil {
+			return server.Config{}, err
+		}
+	}
+
+	// plans dir is relative to cwd
+	plansDir := ""
+	if opts.PlansDir != "" {
+		plansDir = filepath.Join(cwd, opts.PlansDir)
+	}
+
 	// create server config
 	config := server.Config{
-		CommandsDir:    sharedDocsDir,
-		ProjectDocsDir: projectDocsDir,
-		ProjectRootDir: projectRootDir,
-		ExcludeDirs:    opts.ExcludeDirs,
-		MaxFileSize:    opts.MaxFileSize,
-		ServerName:     "local-docs",
-		Version:        revision,
-		CacheTTL:       opts.CacheTTL,
+		CommandsDir:     sharedDocsDir,
+		ProjectDocsDir:  projectDocsDir,
+		ProjectRootDir:  projectRootDir,
+		ExcludeDirs:     opts.ExcludeDirs,
+		MaxFileSize:     opts.MaxFileSize,
+		ServerName:      "local-docs",
+		Version:         revision,
+		CacheTTL:        opts.CacheTTL,
+		SkillsDir:       skillsDir,
+		MemoryRootDir:   memoryRootDir,
+		GlobalMemoryDir: globalMemoryDir,
+		PlansDir:        plansDir,
+		EnablePlanEdit:  opts.EnablePlanEdit,
+		ProjectRoot:     cwd,
 	}
 
-	// create server
-	srv, err := server.New(config)
-	if err != nil {
-		return fmt.Errorf("failed to create server: %w", err)
+	// projects path dirs support ~ and are relative to cwd
+	for _, dir := range opts.ProjectsPath {
+		if dir, err = expandTilde(dir); err != nil {
+			return server.Config{}, err
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(cwd, dir)
+		}
+		config.ProjectsPath = append(config.ProjectsPath, dir)
 	}
 
-	// run server
-	if err := srv.Run(ctx); err != nil {
-		return fmt.Errorf("server error: %w", err)
+	// parse git sources, repository paths support ~ and are relative to cwd
+	for _, spec := range opts.GitSources {
+		g, err := scanner.ParseGitSource(spec)
+		if err != nil {
+			return server.Config{}, err // nolint:wrapcheck // scanner error is descriptive
+		}
+		if g.Repo, err = expandTilde(g.Repo); err != nil {
+			return server.Config{}, err
+		}
+		if !filepath.IsAbs(g.Repo) {
+			g.Repo = filepath.Join(cwd, g.Repo)
+		}
+		config.GitSources = append(config.GitSources, g)
 	}
 
-	slog.Info("server stopped")
-	return nil
+	// load optional lint schema
+	if opts.LintSchema != "" {
+		schemaPath, err := expandTilde(opts.LintSchema)
+		if err != nil {
+			return server.Config{}, err
+		}
+		if config.LintSchema, err = lint.LoadSchema(schemaPath); err != nil {
+			return server.Config{}, err // nolint:wrapcheck // lint error is descriptive
+		}
+	}
+
+	if config.SourcePrecedence, err = server.ParseSourcePrecedence(opts.Precedence); err != nil {
+		return server.Config{}, err // nolint:wrapcheck // precedence error is descriptive
+	}
+	config.StrictPaths = opts.StrictPaths
+	config.ContentCacheSize = opts.ContentCache
+
+	if opts.RankingFile != "" {
+		rankingPath, err := expandTilde(opts.RankingFile)
+		if err != nil {
+			return server.Config{}, err
+		}
+		if config.RankingProfiles, err = ranking.Load(rankingPath); err != nil {
+			return server.Config{}, err // nolint:wrapcheck // ranking error is descriptive
+		}
+	}
+
+	if opts.AuditLog != "" {
+		if config.AuditLog, err = expandTilde(opts.AuditLog); err != nil {
+			return server.Config{}, err
+		}
+		config.AuditMaxSize, config.AuditBackups = opts.AuditMaxSize, opts.AuditBackups
+	}
+
+	if opts.GapsFile != "" {
+		if config.GapsFile, err = expandTilde(opts.GapsFile); err != nil {
+			return server.Config{}, err
+		}
+		config.GapsThreshold = opts.GapsThreshold
+	}
+
+	if opts.MetricsListen != "" {
+		config.MetricsListen, config.EnablePprof = opts.MetricsListen, opts.EnablePprof
+	}
+
+	if !opts.DisableRedact {
+		if config.Redactor, err = makeRedactor(opts); err != nil {
+			return server.Config{}, err
+		}
+	}
+
+	return config, nil
 }
 
 // expandTilde expands ~ prefix in path to user home directory
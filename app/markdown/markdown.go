@@ -0,0 +1,225 @@
+// Package markdown extracts structured elements from markdown docs: fenced code blocks, pipe tables,
+// checklists and links. Elements inside fenced code blocks are not reported, except the blocks themselves.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CodeBlock is a fenced code block
+type CodeBlock struct {
+	Language string `json:"language,omitempty"` // first word of the info string
+	Content  string `json:"content"`
+	Section  string `json:"section,omitempty"` // nearest heading above the element
+	Line     int    `json:"line"`              // 1-based line of the element start
+}
+
+// Table is a pipe table, rows have the same number of cells as the header
+type Table struct {
+	Header  []string   `json:"header"`
+	Rows    [][]string `json:"rows"`
+	Section string     `json:"section,omitempty"`
+	Line    int        `json:"line"`
+}
+
+// ChecklistItem is a task list item, "- [ ] text" or "- [x] text"
+type ChecklistItem struct {
+	Text    string `json:"text"`
+	Checked bool   `json:"checked"`
+	Section string `json:"section,omitempty"`
+	Line    int    `json:"line"`
+}
+
+// Link is an inline link or autolink, images are not included
+type Link struct {
+	Text    string `json:"text"`
+	URL     string `json:"url"`
+	Section string `json:"section,omitempty"`
+	Line    int    `json:"line"`
+}
+
+// Document holds elements of a parsed doc, in order of appearance
+type Document struct {
+	CodeBlocks []CodeBlock
+	Tables     []Table
+	Checklist  []ChecklistItem
+	Links      []Link
+}
+
+var (
+	headingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	delimiterRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	checkboxRe  = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s+(.*)$`)
+	linkRe      = regexp.MustCompile(`(!?)\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)|<(https?://[^>\s]+)>`)
+	codeSpanRe  = regexp.MustCompile("`+[^`]*`+")
+)
+
+// Parse extracts elements from markdown content, frontmatter should be stripped already
+func Parse(content string) *Document {
+	doc := &Document{}
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	section := ""
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if f, ok := openFence(line); ok {
+			end := i + 1
+			for end < len(lines) && !f.closedBy(lines[end]) {
+				end++
+			}
+			block := CodeBlock{Language: f.language, Section: section, Line: i + 1}
+			if end > i+1 {
+				block.Content = strings.Join(lines[i+1:min(end, len(lines))], "\n") + "\n"
+			}
+			doc.CodeBlocks = append(doc.CodeBlocks, block)
+			i = end // unclosed block runs to the end of the doc
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			section = strings.TrimSpace(m[2])
+			continue
+		}
+
+		if i+1 < len(lines) && strings.Contains(line, "|") && delimiterRe.MatchString(lines[i+1]) {
+			if header := splitRow(line); len(header) == len(splitRow(lines[i+1])) {
+				table := Table{Header: header, Rows: [][]string{}, Section: section, Line: i + 1}
+				doc.Links = append(doc.Links, links(line, section, i+1)...)
+				i += 2
+				for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+					table.Rows = append(table.Rows, fitRow(splitRow(lines[i]), len(header)))
+					doc.Links = append(doc.Links, links(lines[i], section, i+1)...)
+				}
+				doc.Tables = append(doc.Tables, table)
+				i-- // the loop increments i past the last row
+				continue
+			}
+		}
+
+		if m := checkboxRe.FindStringSubmatch(line); m != nil {
+			doc.Checklist = append(doc.Checklist, ChecklistItem{Text: strings.TrimSpace(m[2]), Checked: m[1] != " ",
+				Section: section, Line: i + 1})
+		}
+
+		doc.Links = append(doc.Links, links(line, section, i+1)...)
+	}
+	return doc
+}
+
+// fence is an opening code fence
+type fence struct {
+	char     byte
+	size     int
+	language string
+}
+
+// openFence checks if line opens a fenced code block, ``` or ~~~ indented by up to 3 spaces
+func openFence(line string) (fence, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return fence{}, false
+	}
+	f := fence{char: trimmed[0]}
+	for f.size < len(trimmed) && trimmed[f.size] == f.char {
+		f.size++
+	}
+	if f.size < 3 {
+		return fence{}, false
+	}
+	info := strings.TrimSpace(trimmed[f.size:])
+	if f.char == '`' && strings.Contains(info, "`") {
+		return fence{}, false // backticks in info string mean inline code, not a fence
+	}
+	if fields := strings.Fields(info); len(fields) > 0 {
+		f.language = fields[0]
+	}
+	return f, true
+}
+
+// closedBy checks if line closes the fence: same char, at least the same size and nothing else.
+// shorter fences and fences with info string are content, so nested blocks are kept inside.
+func (f fence) closedBy(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == f.char {
+		n++
+	}
+	return n >= f.size && strings.TrimSpace(trimmed[n:]) == ""
+}
+
+// FenceTracker follows fenced code blocks of content processed line by line, zero value is ready to use
+type FenceTracker struct {
+	open *fence
+}
+
+// Inside checks if line is a fence of a code block or its content, lines are passed in order
+func (t *FenceTracker) Inside(line string) bool {
+	if t.open != nil {
+		if t.open.closedBy(line) {
+			t.open = nil
+		}
+		return true
+	}
+	if f, ok := openFence(line); ok {
+		t.open = &f
+		return true
+	}
+	return false
+}
+
+// splitRow splits table row into trimmed cells, escaped pipes are kept in cell text
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cur strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+// fitRow pads or truncates row to n cells
+func fitRow(row []string, n int) []string {
+	for len(row) < n {
+		row = append(row, "")
+	}
+	return row[:n]
+}
+
+// links returns inline links and autolinks of the line, ignoring images and code spans
+func links(line, section string, lineNum int) []Link {
+	if !strings.Contains(line, "](") && !strings.Contains(line, "<http") {
+		return nil
+	}
+	line = codeSpanRe.ReplaceAllString(line, "")
+
+	var res []Link
+	for _, m := range linkRe.FindAllStringSubmatch(line, -1) {
+		switch {
+		case m[4] != "":
+			res = append(res, Link{Text: m[4], URL: m[4], Section: section, Line: lineNum})
+		case m[1] == "":
+			res = append(res, Link{Text: m[2], URL: m[3], Section: section, Line: lineNum})
+		}
+	}
+	return res
+}
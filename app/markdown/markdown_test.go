@@ -0,0 +1,129 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `# Service
+
+Intro with [docs](https://example.com/docs "title") and ` + "`[not](a-link)`" + `, see <https://example.com/auto>.
+![image](img.png) is not a link.
+
+## Settings
+
+| Name | Default | Notes |
+|------|:-------:|-------|
+| port | 8080 | see [ports](ports.md) |
+| mode | a\|b |
+| extra | 1 | 2 | 3 |
+
+## Setup
+
+- [ ] install deps
+- [x] configure
+  * [X] nested done
+1. [ ] numbered
+- [] not an item
+
+` + "````md" + `
+Example doc:
+` + "```go" + `
+package main
+` + "```" + `
+- [ ] inside code
+| a | b |
+|---|---|
+` + "````" + `
+
+~~~sh
+make build
+~~~
+
+` + "```" + `
+` + "```" + `
+`
+
+func TestParse(t *testing.T) {
+	doc := Parse(sample)
+
+	require.Len(t, doc.CodeBlocks, 3)
+	assert.Equal(t, CodeBlock{Language: "md", Section: "Setup", Line: 22,
+		Content: "Example doc:\n```go\npackage main\n```\n- [ ] inside code\n| a | b |\n|---|---|\n"}, doc.CodeBlocks[0])
+	assert.Equal(t, CodeBlock{Language: "sh", Section: "Setup", Line: 32, Content: "make build\n"}, doc.CodeBlocks[1])
+	assert.Equal(t, CodeBlock{Section: "Setup", Line: 36}, doc.CodeBlocks[2], "empty block")
+
+	require.Len(t, doc.Tables, 1)
+	assert.Equal(t, Table{Header: []string{"Name", "Default", "Notes"}, Section: "Settings", Line: 8,
+		Rows: [][]string{{"port", "8080", "see [ports](ports.md)"}, {"mode", "a|b", ""}, {"extra", "1", "2"}}}, doc.Tables[0])
+
+	assert.Equal(t, []ChecklistItem{
+		{Text: "install deps", Section: "Setup", Line: 16},
+		{Text: "configure", Checked: true, Section: "Setup", Line: 17},
+		{Text: "nested done", Checked: true, Section: "Setup", Line: 18},
+		{Text: "numbered", Section: "Setup", Line: 19},
+	}, doc.Checklist)
+
+	assert.Equal(t, []Link{
+		{Text: "docs", URL: "https://example.com/docs", Section: "Service", Line: 3},
+		{Text: "https://example.com/auto", URL: "https://example.com/auto", Section: "Service", Line: 3},
+		{Text: "ports", URL: "ports.md", Section: "Settings", Line: 10},
+	}, doc.Links)
+}
+
+func TestParse_Edges(t *testing.T) {
+	t.Run("unclosed fence runs to the end", func(t *testing.T) {
+		doc := Parse("```python\nprint(1)\n# not a heading\n")
+		require.Len(t, doc.CodeBlocks, 1)
+		assert.Equal(t, "python", doc.CodeBlocks[0].Language)
+		assert.Equal(t, "print(1)\n# not a heading\n\n", doc.CodeBlocks[0].Content)
+	})
+
+	t.Run("inline code is not a fence", func(t *testing.T) {
+		doc := Parse("```echo `date` ```\n- [ ] item\n")
+		assert.Empty(t, doc.CodeBlocks)
+		assert.Len(t, doc.Checklist, 1)
+	})
+
+	t.Run("closing fence must be as long and bare", func(t *testing.T) {
+		doc := Parse("~~~~\n~~~\n~~~~ not closing\n~~~~~\nafter\n")
+		require.Len(t, doc.CodeBlocks, 1)
+		assert.Equal(t, "~~~\n~~~~ not closing\n", doc.CodeBlocks[0].Content)
+	})
+
+	t.Run("heading forms and crlf", func(t *testing.T) {
+		doc := Parse("## Title ##\r\n- [x] a\r\n#hashtag\r\n- [ ] b\r\n#\r\n- [ ] c\r\n")
+		require.Len(t, doc.Checklist, 3)
+		assert.Equal(t, "Title", doc.Checklist[0].Section)
+		assert.Equal(t, "Title", doc.Checklist[1].Section, "#hashtag is not a heading")
+		assert.Equal(t, "", doc.Checklist[2].Section)
+	})
+
+	t.Run("delimiter row with different cell count", func(t *testing.T) {
+		doc := Parse("| a | b |\n|---|\n| 1 | 2 |\n")
+		assert.Empty(t, doc.Tables)
+	})
+
+	t.Run("table without rows", func(t *testing.T) {
+		doc := Parse("a | b\n--- | ---\n\ntext\n")
+		require.Len(t, doc.Tables, 1)
+		assert.Equal(t, []string{"a", "b"}, doc.Tables[0].Header)
+		assert.Empty(t, doc.Tables[0].Rows)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, &Document{}, Parse(""))
+	})
+}
+
+func TestFenceTracker(t *testing.T) {
+	lines := []string{"text", "````md", "```go", "# in block", "```", "````", "after", "```echo `date` ```", "~~~", "x\n"}
+	var fences FenceTracker
+	var inside []bool
+	for _, line := range lines {
+		inside = append(inside, fences.Inside(line))
+	}
+	assert.Equal(t, []bool{false, true, true, true, true, true, false, false, true, true}, inside)
+}
@@ -0,0 +1,40 @@
+package markdown
+
+import (
+	"strings"
+)
+
+// Section returns the part of content under the heading matching title, case-insensitive, from the heading
+// line up to the next heading of the same or higher level. Headings inside fenced code blocks are ignored.
+// Returns false if there is no such heading.
+func Section(content, title string) (string, bool) {
+	lines := strings.SplitAfter(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	title = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(title), "#"))
+
+	start, level := -1, 0
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\n")
+		if f, ok := openFence(line); ok {
+			end := i + 1
+			for end < len(lines) && !f.closedBy(strings.TrimSuffix(lines[end], "\n")) {
+				end++
+			}
+			i = end // unclosed block runs to the end of the doc
+			continue
+		}
+		m := headingRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if start >= 0 && len(m[1]) <= level {
+			return strings.Join(lines[start:i], ""), true
+		}
+		if start < 0 && strings.EqualFold(strings.TrimSpace(m[2]), title) {
+			start, level = i, len(m[1])
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	return strings.Join(lines[start:], ""), true
+}
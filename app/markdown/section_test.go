@@ -0,0 +1,36 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSection(t *testing.T) {
+	content := "intro\n\n# Guide\n\n## Install\n\nrun make\n\n```sh\n# not a heading\n```\n\n### From source\n\ngo build\n\n" +
+		"## Usage\n\nrun it\n"
+
+	tbl := []struct {
+		name, title, want string
+		found             bool
+	}{
+		{name: "up to next heading of the same level", title: "Install",
+			want: "## Install\n\nrun make\n\n```sh\n# not a heading\n```\n\n### From source\n\ngo build\n\n", found: true},
+		{name: "case-insensitive with hashes", title: "## usage", want: "## Usage\n\nrun it\n", found: true},
+		{name: "nested heading", title: "from source", want: "### From source\n\ngo build\n\n", found: true},
+		{name: "top heading to the end", title: "Guide", want: content[len("intro\n\n"):], found: true},
+		{name: "heading in code block", title: "not a heading"},
+		{name: "missing", title: "Configuration"},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := Section(content, tt.title)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, found := Section("# A\r\n\r\ntext\r\n# B\r\n", "a")
+	assert.True(t, found)
+	assert.Equal(t, "# A\n\ntext\n", got, "crlf line endings are normalized")
+}
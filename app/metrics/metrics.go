@@ -0,0 +1,313 @@
+// Package metrics implements a minimal registry of counters, histograms and scrape-time values and summaries
+// exposed in Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets in seconds, suitable for request latencies
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Sample is a value of a scrape-time metric, label values are in order of the metric labels
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// SummarySample is a value of a scrape-time summary, sum and count of observations without quantiles
+type SummarySample struct {
+	LabelValues []string
+	Sum         float64
+	Count       uint64
+}
+
+// metric is a registered metric family
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry holds metrics and writes them in Prometheus text format
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// NewRegistry makes an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]metric{}}
+}
+
+// NewCounterVec registers counter with the given labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, labels: labels}, values: map[string]float64{}}
+	r.register(name, c)
+	return c
+}
+
+// NewHistogramVec registers histogram with the given upper bounds of buckets, sorted, and labels
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name: name, help: help, labels: labels}, buckets: buckets, values: map[string]*histogram{}}
+	r.register(name, h)
+	return h
+}
+
+// NewCounterFunc registers counter with values collected on each scrape
+func (r *Registry) NewCounterFunc(name, help string, fn func() []Sample, labels ...string) {
+	r.register(name, &funcMetric{desc: desc{name: name, help: help, labels: labels}, kind: "counter", fn: fn})
+}
+
+// NewGaugeFunc registers gauge with values collected on each scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() []Sample, labels ...string) {
+	r.register(name, &funcMetric{desc: desc{name: name, help: help, labels: labels}, kind: "gauge", fn: fn})
+}
+
+// NewSummaryFunc registers summary without quantiles with sum and count collected on each scrape
+func (r *Registry) NewSummaryFunc(name, help string, fn func() []SummarySample, labels ...string) {
+	r.register(name, &summaryFunc{desc: desc{name: name, help: help, labels: labels}, fn: fn})
+}
+
+// register adds metric, panics on duplicate names as it is a programming error
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		panic("duplicate metric " + name)
+	}
+	r.metrics[name] = m
+}
+
+// WriteTo writes all metrics sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]metric, 0, len(names))
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, m := range metrics {
+		m.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return cw.n, nil
+}
+
+// Handler returns http handler serving metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64 // by joined label values
+}
+
+// Inc increments counter with the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to counter with the given label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(c.labelValues(key), ""), formatValue(c.values[key]))
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+// histogram keeps counts of a single label set, counts are per bucket and not cumulative
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe adds observation v to histogram with the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+	hist.count++
+	hist.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hist, values := h.values[key], h.labelValues(key)
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += hist.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(values, formatValue(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(values, "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(values, ""), formatValue(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(values, ""), hist.count)
+	}
+}
+
+// funcMetric is a counter or gauge collected on scrape
+type funcMetric struct {
+	desc
+	kind string
+	fn   func() []Sample
+}
+
+func (f *funcMetric) write(w io.Writer) {
+	samples := f.fn()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	f.header(w, f.kind)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelPairs(s.LabelValues, ""), formatValue(s.Value))
+	}
+}
+
+// summaryFunc is a summary collected on scrape
+type summaryFunc struct {
+	desc
+	fn func() []SummarySample
+}
+
+func (f *summaryFunc) write(w io.Writer) {
+	samples := f.fn()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	f.header(w, "summary")
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.labelPairs(s.LabelValues, ""), formatValue(s.Sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.labelPairs(s.LabelValues, ""), s.Count)
+	}
+}
+
+// desc is name, help and label names of a metric
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, kind)
+}
+
+// key joins label values, panics if their number doesn't match labels as it is a programming error
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formats labels as {a="x",b="y"}, with "le" label added if set
+func (d *desc) labelPairs(values []string, le string) string {
+	pairs := make([]string, 0, len(d.labels)+1)
+	for i, name := range d.labels {
+		if i < len(values) {
+			pairs = append(pairs, name+"="+strconv.Quote(values[i]))
+		}
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelValues splits key joined by key method back to label values
+func (d *desc) labelValues(key string) []string {
+	if len(d.labels) == 0 {
+		return nil
+	}
+	return strings.Split(key, "\xff")
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue formats float the way Prometheus expects, integers without exponent
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter counts written bytes
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err // nolint:wrapcheck // bufio error is descriptive
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := NewRegistry()
+	calls := reg.NewCounterVec("calls_total", "Number of calls.", "tool", "status")
+	latency := reg.NewHistogramVec("latency_seconds", "Call latency.", []float64{0.1, 1}, "tool")
+	reg.NewGaugeFunc("files", "Number of files.", func() []Sample {
+		return []Sample{{LabelValues: []string{"b"}, Value: 2}, {LabelValues: []string{"a"}, Value: 1.5}}
+	}, "source")
+	reg.NewCounterFunc("events_total", "Number of events.", func() []Sample { return []Sample{{Value: 7}} })
+	reg.NewSummaryFunc("scan_seconds", "Scan duration.", func() []SummarySample {
+		return []SummarySample{{LabelValues: []string{"docs"}, Sum: 0.25, Count: 2}}
+	}, "source")
+
+	calls.Inc("read_doc", "ok")
+	calls.Add(2, "read_doc", "ok")
+	calls.Inc("search_docs", "error")
+	latency.Observe(0.05, "read_doc")
+	latency.Observe(0.5, "read_doc")
+	latency.Observe(3, "read_doc")
+
+	var buf bytes.Buffer
+	n, err := reg.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	expected := `# HELP calls_total Number of calls.
+# TYPE calls_total counter
+calls_total{tool="read_doc",status="ok"} 3
+calls_total{tool="search_docs",status="error"} 1
+# HELP events_total Number of events.
+# TYPE events_total counter
+events_total 7
+# HELP files Number of files.
+# TYPE files gauge
+files{source="a"} 1.5
+files{source="b"} 2
+# HELP latency_seconds Call latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{tool="read_doc",le="0.1"} 1
+latency_seconds_bucket{tool="read_doc",le="1"} 2
+latency_seconds_bucket{tool="read_doc",le="+Inf"} 3
+latency_seconds_sum{tool="read_doc"} 3.55
+latency_seconds_count{tool="read_doc"} 3
+# HELP scan_seconds Scan duration.
+# TYPE scan_seconds summary
+scan_seconds_sum{source="docs"} 0.25
+scan_seconds_count{source="docs"} 2
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounterVec("hits_total", "Number of hits.").Inc()
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, rec.Body.String(), "hits_total 1\n")
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounterVec("calls_total", "Number of calls.", "tool")
+	assert.Panics(t, func() { reg.NewCounterVec("calls_total", "again") }, "duplicate name")
+	assert.Panics(t, func() { c.Inc() }, "missing label value")
+	assert.Panics(t, func() { c.Inc("a", "b") }, "extra label value")
+}
+
+func TestFormatValue(t *testing.T) {
+	tbl := []struct {
+		in  float64
+		out string
+	}{
+		{0, "0"}, {42, "42"}, {0.25, "0.25"}, {1e21, "1e+21"},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.out, formatValue(tt.in))
+	}
+}
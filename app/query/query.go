@@ -0,0 +1,345 @@
+// Package query parses search queries: words, quoted phrases, field qualifiers (tag:, source:, path:, desc:),
+// AND/OR operators, -term negation and parentheses. Words next to each other are joined with AND,
+// AND binds tighter than OR.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// supported field qualifiers
+const (
+	FieldTag    = "tag"
+	FieldSource = "source"
+	FieldPath   = "path"
+	FieldDesc   = "desc"
+)
+
+var fields = map[string]bool{FieldTag: true, FieldSource: true, FieldPath: true, FieldDesc: true}
+
+// Term is a leaf of the query, a word or a phrase, optionally qualified with a field
+type Term struct {
+	Field  string // empty for unqualified terms
+	Value  string
+	Phrase bool // value was quoted
+}
+
+// SyntaxError is an error in the query, Pos is 1-based position of the offending character
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Query is a parsed query
+type Query struct {
+	root  node
+	plain bool
+}
+
+// Parse parses query string, errors are *SyntaxError. blank query is plain and matches nothing.
+func Parse(s string) (*Query, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return &Query{plain: true}, nil
+	}
+	p := &parser{toks: toks, end: len([]rune(s)) + 1}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) { // only an unmatched ")" stops the top level parser early
+		return nil, &SyntaxError{Pos: p.toks[p.pos].pos, Msg: "unexpected )"}
+	}
+
+	plain := true
+	for _, t := range toks {
+		if t.kind != tokWord || t.term.Field != "" {
+			plain = false
+			break
+		}
+	}
+	return &Query{root: root, plain: plain}, nil
+}
+
+// Plain reports whether query is just words, without phrases, qualifiers, operators or negation
+func (q *Query) Plain() bool {
+	return q.plain
+}
+
+// Match evaluates query with fn telling whether a term matches
+func (q *Query) Match(fn func(Term) bool) bool {
+	if q.root == nil {
+		return false
+	}
+	return q.root.eval(fn)
+}
+
+// Terms returns terms which are not negated, in order of appearance
+func (q *Query) Terms() []Term {
+	var res []Term
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case termNode:
+			res = append(res, n.term)
+		case andNode:
+			for _, c := range n {
+				walk(c)
+			}
+		case orNode:
+			for _, c := range n {
+				walk(c)
+			}
+		}
+	}
+	if q.root != nil {
+		walk(q.root)
+	}
+	return res
+}
+
+// node of the query tree
+type node interface {
+	eval(fn func(Term) bool) bool
+}
+
+type termNode struct{ term Term }
+
+func (n termNode) eval(fn func(Term) bool) bool { return fn(n.term) }
+
+type notNode struct{ node node }
+
+func (n notNode) eval(fn func(Term) bool) bool { return !n.node.eval(fn) }
+
+type andNode []node
+
+func (n andNode) eval(fn func(Term) bool) bool {
+	for _, c := range n {
+		if !c.eval(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []node
+
+func (n orNode) eval(fn func(Term) bool) bool {
+	for _, c := range n {
+		if c.eval(fn) {
+			return true
+		}
+	}
+	return false
+}
+
+type tokKind int
+
+const (
+	tokWord tokKind = iota
+	tokPhrase
+	tokAnd
+	tokOr
+	tokNot
+	tokOpen
+	tokClose
+)
+
+type token struct {
+	kind tokKind
+	pos  int  // 1-based position of the first character
+	term Term // for words and phrases
+}
+
+// lex splits query to tokens. "-" negates the following term only at the start of a token,
+// a lone "-" and dashes inside words are kept as words.
+func lex(s string) ([]token, error) {
+	rs := []rune(s)
+	var res []token
+	isDelim := func(r rune) bool { return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' }
+
+	// readPhrase reads quoted value starting at the opening quote, returns value and index after the closing quote
+	readPhrase := func(i int) (string, int, error) {
+		end := i + 1
+		for end < len(rs) && rs[end] != '"' {
+			end++
+		}
+		if end == len(rs) {
+			return "", 0, &SyntaxError{Pos: i + 1, Msg: "unterminated phrase"}
+		}
+		value := strings.TrimSpace(string(rs[i+1 : end]))
+		if value == "" {
+			return "", 0, &SyntaxError{Pos: i + 1, Msg: "empty phrase"}
+		}
+		return value, end + 1, nil
+	}
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			res = append(res, token{kind: tokOpen, pos: i + 1})
+			i++
+		case r == ')':
+			res = append(res, token{kind: tokClose, pos: i + 1})
+			i++
+		case r == '"':
+			value, next, err := readPhrase(i)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, token{kind: tokPhrase, pos: i + 1, term: Term{Value: value, Phrase: true}})
+			i = next
+		case r == '-' && i+1 < len(rs) && !unicode.IsSpace(rs[i+1]) && rs[i+1] != ')':
+			res = append(res, token{kind: tokNot, pos: i + 1})
+			i++
+		default:
+			start := i
+			for i < len(rs) && !isDelim(rs[i]) {
+				i++
+			}
+			word := string(rs[start:i])
+			switch word {
+			case "AND":
+				res = append(res, token{kind: tokAnd, pos: start + 1})
+				continue
+			case "OR":
+				res = append(res, token{kind: tokOr, pos: start + 1})
+				continue
+			}
+
+			name, value, ok := strings.Cut(word, ":")
+			if !ok || !fields[strings.ToLower(name)] {
+				res = append(res, token{kind: tokWord, pos: start + 1, term: Term{Value: word}})
+				continue
+			}
+			t := token{kind: tokWord, pos: start + 1, term: Term{Field: strings.ToLower(name), Value: value}}
+			if value == "" && i < len(rs) && rs[i] == '"' {
+				phrase, next, err := readPhrase(i)
+				if err != nil {
+					return nil, err
+				}
+				t.kind, t.term.Value, t.term.Phrase = tokPhrase, phrase, true
+				i = next
+			}
+			if t.term.Value == "" {
+				return nil, &SyntaxError{Pos: start + 1, Msg: fmt.Sprintf("missing value of %s:", t.term.Field)}
+			}
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+// parser is a recursive descent parser of tokens
+type parser struct {
+	toks []token
+	pos  int
+	end  int // position reported for errors at the end of the query
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *parser) parseOr() (node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	res := orNode{first}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != tokOr {
+			break
+		}
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, next)
+	}
+	if len(res) == 1 {
+		return first, nil
+	}
+	return res, nil
+}
+
+// parseAnd parses: unary (["AND"] unary)*
+func (p *parser) parseAnd() (node, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	res := andNode{first}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokOr || t.kind == tokClose {
+			break
+		}
+		if t.kind == tokAnd {
+			p.pos++
+		}
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, next)
+	}
+	if len(res) == 1 {
+		return first, nil
+	}
+	return res, nil
+}
+
+// parseUnary parses: "-" unary | "(" or ")" | term
+func (p *parser) parseUnary() (node, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, &SyntaxError{Pos: p.end, Msg: "unexpected end of query, expected term"}
+	}
+	p.pos++
+	switch t.kind {
+	case tokNot:
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node: n}, nil
+	case tokOpen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokClose {
+			return nil, &SyntaxError{Pos: t.pos, Msg: "missing )"}
+		}
+		p.pos++
+		return n, nil
+	case tokWord, tokPhrase:
+		return termNode{term: t.term}, nil
+	case tokAnd:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected AND, expected term"}
+	case tokOr:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected OR, expected term"}
+	default:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected ), expected term"}
+	}
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Plain(t *testing.T) {
+	tbl := []struct {
+		query string
+		plain bool
+	}{
+		{"go testing", true},
+		{"  ", true},
+		{"go-testing", true},
+		{"error: timeout", true}, // unknown qualifier is a word
+		{"c++ - notes", true},    // lone dash is a word
+		{"and or not", true},     // lowercase operators are words
+		{`"go testing"`, false},
+		{"tag:testing", false},
+		{"go OR testing", false},
+		{"go AND testing", false},
+		{"-commands", false},
+		{"(go)", false},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.plain, q.Plain())
+		})
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	// doc has words "deploy" and "guide", tag "ops" and source "commands"
+	doc := func(term Term) bool {
+		switch term.Field {
+		case "":
+			return term.Value == "deploy" || term.Value == "guide" || term.Value == "deploy guide"
+		case FieldTag:
+			return term.Value == "ops"
+		case FieldSource:
+			return term.Value == "commands"
+		}
+		return false
+	}
+
+	tbl := []struct {
+		query string
+		match bool
+	}{
+		{"deploy", true},
+		{"deploy guide", true},
+		{"deploy AND guide", true},
+		{"deploy missing", false},
+		{"deploy OR missing", true},
+		{"missing OR other", false},
+		{`"deploy guide"`, true},
+		{`"guide deploy"`, false},
+		{"tag:ops", true},
+		{"TAG:ops", true},
+		{`tag:"ops"`, true},
+		{"tag:dev", false},
+		{"deploy -source:commands", false},
+		{"deploy -source:project-docs", true},
+		{"-(missing OR other)", true},
+		{"missing OR deploy tag:ops", true}, // AND binds tighter
+		{"(missing OR deploy) tag:dev", false},
+		{"--deploy", true},
+		{"", false},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, q.Match(doc))
+		})
+	}
+}
+
+func TestQuery_Terms(t *testing.T) {
+	q, err := Parse(`deploy -guide (tag:ops OR "release notes") -(a b)`)
+	require.NoError(t, err)
+	assert.Equal(t, []Term{{Value: "deploy"}, {Field: FieldTag, Value: "ops"}, {Value: "release notes", Phrase: true}}, q.Terms())
+}
+
+func TestParse_Errors(t *testing.T) {
+	tbl := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{`go "testing`, 4, "unterminated phrase"},
+		{`go ""`, 4, "empty phrase"},
+		{"tag: go", 1, "missing value of tag:"},
+		{`path:"ops`, 6, "unterminated phrase"},
+		{"(go testing", 1, "missing )"},
+		{"go testing)", 11, "unexpected )"},
+		{"OR go", 1, "unexpected OR, expected term"},
+		{"go AND", 7, "unexpected end of query, expected term"},
+		{"go OR OR x", 7, "unexpected OR, expected term"},
+		{"go AND AND x", 8, "unexpected AND, expected term"},
+		{"()", 2, "unexpected ), expected term"},
+		{"ünï (x", 5, "missing )"}, // position counts characters, not bytes
+	}
+	for _, tt := range tbl {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			require.Error(t, err)
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr))
+			assert.Equal(t, tt.pos, syntaxErr.Pos)
+			assert.Equal(t, tt.msg, syntaxErr.Msg)
+		})
+	}
+	_, err := Parse("(go")
+	assert.EqualError(t, err, "query syntax error at position 1: missing )")
+}
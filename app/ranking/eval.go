@@ -0,0 +1,153 @@
+package ranking
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Judgment is a query with docs expected in its search results
+type Judgment struct {
+	Query    string   `yaml:"query" json:"query"`
+	Expected []string `yaml:"expected" json:"expected"` // doc paths, with or without source prefix
+}
+
+// LoadJudgments reads judgments from YAML file with a list of queries under the "judgments" key
+func LoadJudgments(path string) ([]Judgment, error) {
+	// #nosec G304 - path is provided by the user via cli option
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read judgments: %w", err)
+	}
+	var file struct {
+		Judgments []Judgment `yaml:"judgments"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse judgments %s: %w", path, err)
+	}
+	if len(file.Judgments) == 0 {
+		return nil, fmt.Errorf("no judgments in %s", path)
+	}
+	for i, j := range file.Judgments {
+		if strings.TrimSpace(j.Query) == "" {
+			return nil, fmt.Errorf("judgment %d has no query", i+1)
+		}
+		if len(j.Expected) == 0 {
+			return nil, fmt.Errorf("judgment %q has no expected docs", j.Query)
+		}
+	}
+	return file.Judgments, nil
+}
+
+// SearchFunc returns paths of search results for the query, best first
+type SearchFunc func(ctx context.Context, query string) ([]string, error)
+
+// QueryResult is the evaluation of a single judgment
+type QueryResult struct {
+	Query    string   `json:"query"`
+	Rank     int      `json:"rank"`              // 1-based rank of the first expected doc, 0 if not found
+	Recall   float64  `json:"recall"`            // share of expected docs in top k results
+	Missing  []string `json:"missing,omitempty"` // expected docs not in top k results
+	Returned int      `json:"returned"`          // number of search results
+}
+
+// Report is the evaluation of a profile against judgments
+type Report struct {
+	Profile string        `json:"profile"`
+	K       int           `json:"k"`
+	MRR     float64       `json:"mrr"`    // mean reciprocal rank of the first expected doc
+	Recall  float64       `json:"recall"` // mean recall at k
+	Queries []QueryResult `json:"queries"`
+}
+
+// Evaluate runs queries of judgments with search and computes mean reciprocal rank and mean recall at k
+func Evaluate(ctx context.Context, profile string, judgments []Judgment, k int, search SearchFunc) (*Report, error) {
+	if k < 1 {
+		return nil, errors.New("k must be positive")
+	}
+	res := &Report{Profile: profile, K: k, Queries: make([]QueryResult, 0, len(judgments))}
+	for _, j := range judgments {
+		paths, err := search(ctx, j.Query)
+		if err != nil {
+			return nil, fmt.Errorf("search %q failed: %w", j.Query, err)
+		}
+
+		qr := QueryResult{Query: j.Query, Returned: len(paths)}
+		for i, p := range paths {
+			if qr.Rank == 0 && matchAny(p, j.Expected) {
+				qr.Rank = i + 1
+			}
+		}
+		top := paths[:min(k, len(paths))]
+		for _, e := range j.Expected {
+			if !matchAny(e, top) {
+				qr.Missing = append(qr.Missing, e)
+			}
+		}
+		qr.Recall = float64(len(j.Expected)-len(qr.Missing)) / float64(len(j.Expected))
+
+		if qr.Rank > 0 {
+			res.MRR += 1 / float64(qr.Rank)
+		}
+		res.Recall += qr.Recall
+		res.Queries = append(res.Queries, qr)
+	}
+	if n := len(judgments); n > 0 {
+		res.MRR /= float64(n)
+		res.Recall /= float64(n)
+	}
+	return res, nil
+}
+
+// matchAny checks if the path matches any of paths, paths without source prefix match paths of any source
+func matchAny(path string, paths []string) bool {
+	for _, p := range paths {
+		if samePath(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePath compares doc paths, a path without source prefix matches the same path of any source
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	_, aPath, aPrefixed := strings.Cut(a, ":")
+	_, bPath, bPrefixed := strings.Cut(b, ":")
+	switch {
+	case aPrefixed && !bPrefixed:
+		return aPath == b
+	case !aPrefixed && bPrefixed:
+		return a == bPath
+	}
+	return false
+}
+
+// WriteText writes report in human-readable form
+func (r *Report) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "profile %s: MRR %.3f, recall@%d %.3f, %d queries\n", r.Profile, r.MRR, r.K, r.Recall, len(r.Queries))
+	for _, q := range r.Queries {
+		rank := "-"
+		if q.Rank > 0 {
+			rank = fmt.Sprintf("%d", q.Rank)
+		}
+		fmt.Fprintf(bw, "  rank %2s  recall %.2f  %s", rank, q.Recall, q.Query)
+		if len(q.Missing) > 0 {
+			fmt.Fprintf(bw, "  missing: %s", strings.Join(q.Missing, ", "))
+		}
+		fmt.Fprintln(bw)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package ranking
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadJudgments(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "judgments.yml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	judgments, err := LoadJudgments(write(t, `judgments:
+  - query: commit message style
+    expected: [commands:git/commit-message.md]
+  - query: deploy
+    expected: [ops/deploy.md, ops/rollback.md]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []Judgment{
+		{Query: "commit message style", Expected: []string{"commands:git/commit-message.md"}},
+		{Query: "deploy", Expected: []string{"ops/deploy.md", "ops/rollback.md"}},
+	}, judgments)
+
+	tbl := []struct {
+		content, err string
+	}{
+		{"", "no judgments in"},
+		{"judgments: []\n", "no judgments in"},
+		{"judgments:\n  - query: ' '\n    expected: [a.md]\n", "judgment 1 has no query"},
+		{"judgments:\n  - query: deploy\n", `judgment "deploy" has no expected docs`},
+		{"judgments: {", "failed to parse judgments"},
+	}
+	for _, tt := range tbl {
+		_, err := LoadJudgments(write(t, tt.content))
+		require.ErrorContains(t, err, tt.err, tt.content)
+	}
+
+	_, err = LoadJudgments(filepath.Join(t.TempDir(), "missing.yml"))
+	require.ErrorContains(t, err, "failed to read judgments")
+}
+
+func TestEvaluate(t *testing.T) {
+	results := map[string][]string{
+		"commit":  {"commands:commit.md", "commands:git/commit-message.md"},
+		"deploy":  {"project-docs:ops/deploy.md", "project-docs:ops/release.md", "project-docs:ops/rollback.md"},
+		"missing": {"project-docs:other.md"},
+		"empty":   {},
+	}
+	search := func(_ context.Context, query string) ([]string, error) {
+		return results[query], nil
+	}
+	judgments := []Judgment{
+		{Query: "commit", Expected: []string{"commands:git/commit-message.md"}},
+		{Query: "deploy", Expected: []string{"ops/deploy.md", "ops/rollback.md"}},
+		{Query: "missing", Expected: []string{"project-docs:missing.md"}},
+		{Query: "empty", Expected: []string{"a.md"}},
+	}
+
+	t.Run("recall at 2", func(t *testing.T) {
+		report, err := Evaluate(context.Background(), "default", judgments, 2, search)
+		require.NoError(t, err)
+		assert.Equal(t, "default", report.Profile)
+		assert.Equal(t, 2, report.K)
+		require.Len(t, report.Queries, 4)
+		assert.Equal(t, QueryResult{Query: "commit", Rank: 2, Recall: 1, Returned: 2}, report.Queries[0])
+		assert.Equal(t, QueryResult{Query: "deploy", Rank: 1, Recall: 0.5, Missing: []string{"ops/rollback.md"}, Returned: 3},
+			report.Queries[1])
+		assert.Equal(t, QueryResult{Query: "missing", Recall: 0, Missing: []string{"project-docs:missing.md"}, Returned: 1},
+			report.Queries[2])
+		assert.Equal(t, QueryResult{Query: "empty", Missing: []string{"a.md"}}, report.Queries[3])
+		assert.InDelta(t, (0.5+1)/4, report.MRR, 0.0001)
+		assert.InDelta(t, (1+0.5)/4, report.Recall, 0.0001)
+	})
+
+	t.Run("recall at 3", func(t *testing.T) {
+		report, err := Evaluate(context.Background(), "tags", judgments[:2], 3, search)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, report.Recall, 0.0001)
+		assert.InDelta(t, 0.75, report.MRR, 0.0001)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Evaluate(context.Background(), "default", judgments, 0, search)
+		require.EqualError(t, err, "k must be positive")
+		_, err = Evaluate(context.Background(), "default", judgments, 5, func(context.Context, string) ([]string, error) {
+			return nil, errors.New("bad query")
+		})
+		require.EqualError(t, err, `search "commit" failed: bad query`)
+	})
+}
+
+func TestSamePath(t *testing.T) {
+	tbl := []struct {
+		a, b string
+		same bool
+	}{
+		{"commands:commit.md", "commands:commit.md", true},
+		{"commands:commit.md", "commit.md", true},
+		{"commit.md", "commands:commit.md", true},
+		{"release@v1:guide.md", "guide.md", true},
+		{"commands:commit.md", "project-docs:commit.md", false},
+		{"commit.md", "git/commit.md", false},
+		{"commands:git/commit.md", "commit.md", false},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.same, samePath(tt.a, tt.b), "%s %s", tt.a, tt.b)
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := &Report{Profile: "tags", K: 5, MRR: 0.75, Recall: 0.5, Queries: []QueryResult{
+		{Query: "commit", Rank: 1, Recall: 1, Returned: 3},
+		{Query: "deploy", Recall: 0, Missing: []string{"ops/deploy.md", "ops/rollback.md"}, Returned: 10},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	assert.Equal(t, `profile tags: MRR 0.750, recall@5 0.500, 2 queries
+  rank  1  recall 1.00  commit
+  rank  -  recall 0.00  deploy  missing: ops/deploy.md, ops/rollback.md
+`, buf.String())
+}
@@ -0,0 +1,122 @@
+// Package ranking defines weights of search scoring as named profiles loaded from a YAML file,
+// and evaluates profiles against judgments, queries with the docs expected to be found.
+package ranking
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultName is the name of the profile used when search doesn't select one
+const DefaultName = "default"
+
+// Profile defines weights of search scoring. file names are scored by the best of exact, substring and
+// fuzzy matches, description and tags matches add boosts limited by the boost cap.
+type Profile struct {
+	Exact          float64 `yaml:"exact" json:"exact"`                     // exact file name match
+	Substring      float64 `yaml:"substring" json:"substring"`             // substring match, scaled by the matched share of the name
+	Fuzzy          float64 `yaml:"fuzzy" json:"fuzzy"`                     // fuzzy match, scaled by the fuzzy matcher score
+	FuzzyThreshold float64 `yaml:"fuzzy_threshold" json:"fuzzy_threshold"` // min fuzzy matcher score, 0-1
+	Description    float64 `yaml:"description" json:"description"`         // boost of description containing the query
+	TagExact       float64 `yaml:"tag_exact" json:"tag_exact"`             // boost of each tag equal to the query
+	TagPartial     float64 `yaml:"tag_partial" json:"tag_partial"`         // boost of each tag containing the query
+	BoostCap       float64 `yaml:"boost_cap" json:"boost_cap"`             // max total boost of description and tags
+}
+
+// weightKeys are YAML keys of profile weights, other keys are reported as typos
+var weightKeys = map[string]bool{"exact": true, "substring": true, "fuzzy": true, "fuzzy_threshold": true,
+	"description": true, "tag_exact": true, "tag_partial": true, "boost_cap": true}
+
+// Default returns the built-in profile
+func Default() Profile {
+	return Profile{Exact: 1.0, Substring: 0.8, Fuzzy: 0.7, FuzzyThreshold: 0.3, Description: 0.5, TagExact: 0.3,
+		TagPartial: 0.15, BoostCap: 1.0}
+}
+
+// Validate checks that weights are not negative and the fuzzy threshold is within 0-1
+func (p Profile) Validate() error {
+	weights := []struct {
+		name  string
+		value float64
+	}{{"exact", p.Exact}, {"substring", p.Substring}, {"fuzzy", p.Fuzzy}, {"fuzzy_threshold", p.FuzzyThreshold},
+		{"description", p.Description}, {"tag_exact", p.TagExact}, {"tag_partial", p.TagPartial}, {"boost_cap", p.BoostCap}}
+	for _, w := range weights {
+		if w.value < 0 {
+			return fmt.Errorf("%s is negative: %v", w.name, w.value)
+		}
+	}
+	if p.FuzzyThreshold > 1 {
+		return fmt.Errorf("fuzzy_threshold is above 1: %v", p.FuzzyThreshold)
+	}
+	return nil
+}
+
+// Load reads named profiles from YAML file with profiles under the "profiles" key. weights not set
+// in a profile are taken from the built-in profile. the result always has the default profile,
+// which can be redefined by the file too.
+func Load(path string) (map[string]Profile, error) {
+	// #nosec G304 - path is provided by the user via cli option
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranking profiles: %w", err)
+	}
+	var file struct {
+		Profiles map[string]yaml.Node `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ranking profiles %s: %w", path, err)
+	}
+
+	res := map[string]Profile{DefaultName: Default()}
+	for name, node := range file.Profiles {
+		var keys map[string]any
+		if err := node.Decode(&keys); err != nil {
+			return nil, fmt.Errorf("failed to parse ranking profile %s: %w", name, err)
+		}
+		for k := range keys {
+			if !weightKeys[k] {
+				return nil, fmt.Errorf("unknown weight %q in ranking profile %s", k, name)
+			}
+		}
+		p := Default()
+		if err := node.Decode(&p); err != nil {
+			return nil, fmt.Errorf("failed to parse ranking profile %s: %w", name, err)
+		}
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid ranking profile %s: %w", name, err)
+		}
+		res[name] = p
+	}
+	return res, nil
+}
+
+// Select returns the named profile, the default one if name is empty. the built-in profile is used
+// if profiles don't define the default one.
+func Select(profiles map[string]Profile, name string) (Profile, error) {
+	if name == "" {
+		name = DefaultName
+	}
+	if p, ok := profiles[name]; ok {
+		return p, nil
+	}
+	if name == DefaultName {
+		return Default(), nil
+	}
+	return Profile{}, fmt.Errorf("unknown ranking profile %q, available: %s", name, strings.Join(Names(profiles), ", "))
+}
+
+// Names returns names of profiles, the default one first and others sorted
+func Names(profiles map[string]Profile) []string {
+	res := []string{DefaultName}
+	for name := range profiles {
+		if name != DefaultName {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res[1:])
+	return res
+}
@@ -0,0 +1,108 @@
+package ranking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "ranking.yml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("profiles inherit built-in weights", func(t *testing.T) {
+		profiles, err := Load(write(t, "profiles:\n  tags:\n    tag_exact: 1.5\n    boost_cap: 2\n  strict:\n    fuzzy: 0\n"))
+		require.NoError(t, err)
+		require.Len(t, profiles, 3)
+		assert.Equal(t, Default(), profiles[DefaultName])
+
+		tags := Default()
+		tags.TagExact, tags.BoostCap = 1.5, 2
+		assert.Equal(t, tags, profiles["tags"])
+
+		strict := Default()
+		strict.Fuzzy = 0
+		assert.Equal(t, strict, profiles["strict"])
+	})
+
+	t.Run("default profile redefined", func(t *testing.T) {
+		profiles, err := Load(write(t, "profiles:\n  default:\n    description: 1\n"))
+		require.NoError(t, err)
+		require.Len(t, profiles, 1)
+		assert.InDelta(t, 1.0, profiles[DefaultName].Description, 0.0001)
+		assert.InDelta(t, 0.8, profiles[DefaultName].Substring, 0.0001)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		profiles, err := Load(write(t, ""))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Profile{DefaultName: Default()}, profiles)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tbl := []struct {
+			content, err string
+		}{
+			{"profiles:\n  tags:\n    tag_exct: 1\n", `unknown weight "tag_exct" in ranking profile tags`},
+			{"profiles:\n  tags:\n    tag_exact: -1\n", "invalid ranking profile tags: tag_exact is negative: -1"},
+			{"profiles:\n  loose:\n    fuzzy_threshold: 1.5\n", "invalid ranking profile loose: fuzzy_threshold is above 1: 1.5"},
+			{"profiles:\n  tags:\n    tag_exact: high\n", "failed to parse ranking profile tags"},
+			{"profiles:\n  tags: [1, 2]\n", "failed to parse ranking profile tags"},
+			{"profiles: [", "failed to parse ranking profiles"},
+		}
+		for _, tt := range tbl {
+			_, err := Load(write(t, tt.content))
+			require.ErrorContains(t, err, tt.err, tt.content)
+		}
+
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yml"))
+		require.ErrorContains(t, err, "failed to read ranking profiles")
+	})
+}
+
+func TestSelect(t *testing.T) {
+	tags := Default()
+	tags.TagExact = 2
+	profiles := map[string]Profile{DefaultName: Default(), "tags": tags, "alpha": Default()}
+
+	p, err := Select(profiles, "tags")
+	require.NoError(t, err)
+	assert.Equal(t, tags, p)
+
+	p, err = Select(profiles, "")
+	require.NoError(t, err)
+	assert.Equal(t, Default(), p)
+
+	p, err = Select(nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, Default(), p, "built-in profile without configured profiles")
+
+	p, err = Select(nil, DefaultName)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), p)
+
+	_, err = Select(profiles, "other")
+	require.EqualError(t, err, `unknown ranking profile "other", available: default, alpha, tags`)
+	_, err = Select(nil, "other")
+	require.EqualError(t, err, `unknown ranking profile "other", available: default`)
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"default"}, Names(nil))
+	assert.Equal(t, []string{"default", "a", "b"}, Names(map[string]Profile{"b": {}, "default": {}, "a": {}}))
+	assert.Equal(t, []string{"default", "tags"}, Names(map[string]Profile{"tags": {}}))
+}
+
+func TestProfile_Validate(t *testing.T) {
+	require.NoError(t, Default().Validate())
+	require.NoError(t, Profile{}.Validate(), "zero weights disable signals")
+	require.EqualError(t, Profile{Substring: -0.1}.Validate(), "substring is negative: -0.1")
+	require.EqualError(t, Profile{FuzzyThreshold: 2}.Validate(), "fuzzy_threshold is above 1: 2")
+}
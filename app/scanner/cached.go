@@ -2,12 +2,18 @@ package scanner
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -19,18 +25,58 @@ const (
 	defaultCacheTTL = 1 * time.Hour
 	defaultDebounce = 500 * time.Millisecond
 	watchBufferSize = 100
+	maxExpansions   = 1000 // max number of cached include expansions
+	maxVersions     = 5000 // max number of cached content hashes
 )
 
 // CachedScanner wraps Scanner with caching and file watching capabilities
 type CachedScanner struct {
 	scanner       *Scanner
 	cache         cache.Cache[string, []FileInfo]
+	expansions    cache.Cache[string, *Expansion] // expanded docs by absolute path
+	versions      cache.Cache[string, DocVersion] // content hashes of docs by absolute path
+	contents      *contentCache                   // docs by absolute path, disabled if nil
+	shared        *CachedScanner                  // scanner of sources shared with other projects, nil if not used
+	followers     []*CachedScanner                // scanners using this one as shared, notified of changed files
 	watcher       *fsnotify.Watcher
 	stopCh        chan struct{}
 	mu            sync.RWMutex
 	ttl           time.Duration
 	debounce      time.Duration
 	watcherActive bool
+	stats         cacheCounters
+}
+
+// cacheCounters are counters of CachedScanner, updated atomically
+type cacheCounters struct {
+	hits, misses, invalidations  atomic.Int64
+	scans, scanNanos             atomic.Int64
+	watcherEvents, watcherErrors atomic.Int64
+	contentHits, contentMisses   atomic.Int64
+}
+
+// CacheStats contains counters of the cached scanner since it was created
+type CacheStats struct {
+	Hits          int64 // scans served from cache
+	Misses        int64 // scans of the filesystem
+	Invalidations int64 // file list invalidations by watcher events or moved git refs
+	Scans         int64 // completed filesystem scans
+	ScanTime      time.Duration
+	WatcherEvents int64
+	WatcherErrors int64
+	WatchedDirs   int
+	Files         map[Source]int // files of the cached list by source, nil if the list is not cached
+	ContentHits   int64          // docs read from content cache
+	ContentMisses int64          // docs read from the filesystem
+	ContentDocs   int            // docs in content cache
+	ContentBytes  int64          // total size of docs in content cache
+}
+
+// DocVersion identifies content of a doc file
+type DocVersion struct {
+	Hash    string // hex-encoded sha256 of the file content
+	ModTime time.Time
+	Size    int64
 }
 
 // NewCachedScanner creates a new cached scanner with file watching
@@ -40,11 +86,13 @@ func NewCachedScanner(scanner *Scanner, ttl time.Duration) (*CachedScanner, erro
 	}
 
 	cs := &CachedScanner{
-		scanner:  scanner,
-		cache:    cache.NewCache[string, []FileInfo]().WithTTL(ttl),
-		stopCh:   make(chan struct{}),
-		ttl:      ttl,
-		debounce: defaultDebounce,
+		scanner:    scanner,
+		cache:      cache.NewCache[string, []FileInfo]().WithTTL(ttl),
+		expansions: cache.NewCache[string, *Expansion]().WithTTL(ttl).WithMaxKeys(maxExpansions),
+		versions:   cache.NewCache[string, DocVersion]().WithTTL(ttl).WithMaxKeys(maxVersions),
+		stopCh:     make(chan struct{}),
+		ttl:        ttl,
+		debounce:   defaultDebounce,
 	}
 
 	// attempt to start watcher, but don't fail if it doesn't work
@@ -56,8 +104,54 @@ func NewCachedScanner(scanner *Scanner, ttl time.Duration) (*CachedScanner, erro
 	return cs, nil
 }
 
-// Scan returns cached file list or scans filesystem if cache miss
+// WithContentCache enables cache of doc contents read with Document, up to maxBytes in total, docs expire
+// after the scanner ttl. the cache is disabled if maxBytes is not positive.
+func (cs *CachedScanner) WithContentCache(maxBytes int64) *CachedScanner {
+	cs.contents = newContentCache(maxBytes, cs.ttl)
+	return cs
+}
+
+// WithShared makes cs a project scanner using sources of the shared scanner, commands, skills and global memory,
+// which cs doesn't scan and watch itself. files of both are listed by Scan, and the content cache of the shared
+// scanner is used instead of own one, so all projects share its size. changes of shared files seen by the shared
+// watcher drop cached expansions, versions and contents of cs.
+func (cs *CachedScanner) WithShared(shared *CachedScanner) *CachedScanner {
+	cs.shared = shared
+	cs.contents = shared.contents
+	shared.mu.Lock()
+	shared.followers = append(shared.followers, cs)
+	shared.mu.Unlock()
+	return cs
+}
+
+// Scan returns cached file list or scans filesystem if cache miss. files of the shared scanner, if set,
+// are merged in, in the same order of sources as Scanner.Scan lists them.
 func (cs *CachedScanner) Scan(ctx context.Context) ([]FileInfo, error) {
+	files, err := cs.scan(ctx)
+	if err != nil || cs.shared == nil {
+		return files, err
+	}
+	sharedFiles, err := cs.shared.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]FileInfo, 0, len(sharedFiles)+len(files))
+	res = append(append(res, sharedFiles...), files...)
+	slices.SortStableFunc(res, func(a, b FileInfo) int { return sourceOrder(a.Source) - sourceOrder(b.Source) })
+	return res, nil
+}
+
+// sourceOrder returns position of the source in the list of Scanner.Scan
+func sourceOrder(src Source) int {
+	order := []Source{SourceCommands, SourceProjectDocs, SourceProjectRoot, SourceSkills, SourceMemory, SourceGit}
+	if i := slices.Index(order, src); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
+// scan returns cached file list of own sources or scans filesystem if cache miss
+func (cs *CachedScanner) scan(ctx context.Context) ([]FileInfo, error) {
 	// check context before starting
 	select {
 	case <-ctx.Done():
@@ -65,24 +159,68 @@ func (cs *CachedScanner) Scan(ctx context.Context) ([]FileInfo, error) {
 	default:
 	}
 
+	// moved git refs are not visible to the file watcher, check them on each scan
+	if len(cs.scanner.GitSources()) > 0 && cs.scanner.GitRefsChanged() {
+		cs.invalidate()
+	}
+
 	// try cache first
 	if files, ok := cs.cache.Get(cacheKey); ok {
+		cs.stats.hits.Add(1)
 		return files, nil
 	}
 
 	// cache miss - scan filesystem
+	cs.stats.misses.Add(1)
+	start := time.Now()
 	files, err := cs.scanner.Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
+	cs.stats.scans.Add(1)
+	cs.stats.scanNanos.Add(int64(time.Since(start)))
+	cs.watchMemoryDirs(files)
 
 	// populate cache
 	cs.cache.Set(cacheKey, files, cs.ttl)
 	return files, nil
 }
 
-// CommandsDir returns the commands directory path
+// Stats returns cache and watcher counters, the number of watched directories and files of the cached list
+func (cs *CachedScanner) Stats() CacheStats {
+	res := CacheStats{
+		Hits:          cs.stats.hits.Load(),
+		Misses:        cs.stats.misses.Load(),
+		Invalidations: cs.stats.invalidations.Load(),
+		Scans:         cs.stats.scans.Load(),
+		ScanTime:      time.Duration(cs.stats.scanNanos.Load()),
+		WatcherEvents: cs.stats.watcherEvents.Load(),
+		WatcherErrors: cs.stats.watcherErrors.Load(),
+		ContentHits:   cs.stats.contentHits.Load(),
+		ContentMisses: cs.stats.contentMisses.Load(),
+	}
+	res.ContentDocs, res.ContentBytes = cs.contents.size()
+
+	cs.mu.RLock()
+	if cs.watcherActive && cs.watcher != nil {
+		res.WatchedDirs = len(cs.watcher.WatchList())
+	}
+	cs.mu.RUnlock()
+
+	if files, ok := cs.cache.Peek(cacheKey); ok {
+		res.Files = map[Source]int{}
+		for _, f := range files {
+			res.Files[f.Source]++
+		}
+	}
+	return res
+}
+
+// CommandsDir returns the commands directory path, of the shared scanner if set
 func (cs *CachedScanner) CommandsDir() string {
+	if cs.shared != nil {
+		return cs.shared.CommandsDir()
+	}
 	return cs.scanner.CommandsDir()
 }
 
@@ -96,23 +234,148 @@ func (cs *CachedScanner) ProjectRootDir() string {
 	return cs.scanner.ProjectRootDir()
 }
 
-// Close stops the file watcher and cleans up resources
+// SkillsDir returns the skills directory path, of the shared scanner if set
+func (cs *CachedScanner) SkillsDir() string {
+	if cs.shared != nil {
+		return cs.shared.SkillsDir()
+	}
+	return cs.scanner.SkillsDir()
+}
+
+// ResolveMemoryPath resolves memory file name to its absolute path, global memory files by the shared scanner if set
+func (cs *CachedScanner) ResolveMemoryPath(name string, maxSize int64) (string, error) {
+	if cs.shared != nil {
+		if cleanName, err := CleanUserPath(name); err == nil && strings.HasPrefix(filepath.ToSlash(cleanName), memoryGlobalDir+"/") {
+			return cs.shared.ResolveMemoryPath(cleanName, maxSize)
+		}
+	}
+	return cs.scanner.ResolveMemoryPath(name, maxSize)
+}
+
+// ReadGitFile reads a markdown file from the git source with the given "name@ref" prefix
+func (cs *CachedScanner) ReadGitFile(prefix, path string, maxSize int64) ([]byte, error) {
+	return cs.scanner.ReadGitFile(prefix, path, maxSize)
+}
+
+// Expansion returns cached include expansion of the doc with the given absolute path
+func (cs *CachedScanner) Expansion(path string) (*Expansion, bool) {
+	return cs.expansions.Get(path)
+}
+
+// SetExpansion caches include expansion of the doc with the given absolute path. the expansion is dropped
+// when the doc or any of its dependencies changes, so nothing is cached without active file watcher.
+func (cs *CachedScanner) SetExpansion(path string, exp *Expansion) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if cs.watcherActive {
+		cs.expansions.Set(path, exp, cs.ttl)
+	}
+}
+
+// invalidateExpansions drops cached expansions of the changed file and of docs including it
+func (cs *CachedScanner) invalidateExpansions(changed string) {
+	for _, key := range cs.expansions.Keys() {
+		exp, ok := cs.expansions.Peek(key)
+		if ok && (key == changed || slices.Contains(exp.Deps, changed)) {
+			cs.expansions.Invalidate(key)
+		}
+	}
+}
+
+// Version returns version of the doc file with the given absolute path. the content hash is computed on
+// the first call and cached until the watcher reports a change of the file, or its size or modification
+// time differ from the cached ones. nothing is cached without active file watcher.
+func (cs *CachedScanner) Version(path string) (DocVersion, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return DocVersion{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if v, ok := cs.versions.Get(path); ok && v.Size == info.Size() && v.ModTime.Equal(info.ModTime()) {
+		return v, nil
+	}
+	v, _, err := cs.load(path, info)
+	return v, err
+}
+
+// Document returns the doc file with the given absolute path, with frontmatter stripped, and its version.
+// docs are kept in content cache, if enabled, until the watcher reports a change of the file, they expire,
+// or they are evicted by more recently used docs. like with Version, cached doc is not used if size or
+// modification time of the file differ, as not every file is watched. nothing is cached without active
+// file watcher.
+func (cs *CachedScanner) Document(path string) (DocContent, error) {
+	gen := cs.contents.generation()
+	info, err := os.Stat(path)
+	if err != nil {
+		return DocContent{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if doc, ok := cs.contents.get(path); ok && doc.Version.Size == info.Size() && doc.Version.ModTime.Equal(info.ModTime()) {
+		cs.stats.contentHits.Add(1)
+		return doc, nil
+	}
+	cs.stats.contentMisses.Add(1)
+
+	v, content, err := cs.load(path, info)
+	if err != nil {
+		return DocContent{}, err
+	}
+	_, body := ParseFrontmatter(content)
+	doc := DocContent{Body: body, Version: v}
+
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if cs.watcherActive && v.Size == info.Size() {
+		cs.contents.set(path, doc, gen)
+	}
+	return doc, nil
+}
+
+// load reads the doc file with stat info taken before reading, and caches its version
+func (cs *CachedScanner) load(path string, info os.FileInfo) (DocVersion, []byte, error) {
+	// #nosec G304 - path is resolved and validated by the caller
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DocVersion{}, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	v := DocVersion{Hash: HashContent(content), ModTime: info.ModTime(), Size: int64(len(content))}
+
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if cs.watcherActive && v.Size == info.Size() { // size differs if the file changed after stat
+		cs.versions.Set(path, v, cs.ttl)
+	}
+	return v, content, nil
+}
+
+// HashContent returns hex-encoded sha256 of content, the hash of DocVersion
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Close stops the file watcher and cleans up resources, the shared scanner is not closed
 func (cs *CachedScanner) Close() error {
+	if cs.shared != nil {
+		cs.shared.mu.Lock()
+		cs.shared.followers = slices.DeleteFunc(cs.shared.followers, func(f *CachedScanner) bool { return f == cs })
+		cs.shared.mu.Unlock()
+	}
+
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
+	scannerErr := cs.scanner.Close()
 	if !cs.watcherActive {
-		return nil
+		return scannerErr
 	}
 
 	close(cs.stopCh)
 	cs.watcherActive = false
 
 	if cs.watcher != nil {
-		return cs.watcher.Close() // nolint:wrapcheck // watcher error is descriptive
+		return errors.Join(cs.watcher.Close(), scannerErr)
 	}
 
-	return nil
+	return scannerErr
 }
 
 // startWatcher initializes fsnotify watcher and monitoring goroutine
@@ -129,15 +392,29 @@ func (cs *CachedScanner) startWatcher(ctx context.Context) error {
 		cs.scanner.CommandsDir(),
 		cs.scanner.ProjectDocsDir(),
 		cs.scanner.ProjectRootDir(),
+		cs.scanner.SkillsDir(),
 	}
+	memoryRoot, globalMemory := cs.scanner.MemoryDirs()
 
 	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
 		if err := cs.addWatchRecursive(dir); err != nil {
 			// log but don't fail - some dirs might not exist
 			continue
 		}
 	}
 
+	// only files directly in the global memory dir are memory, no need to watch its subdirectories.
+	// the project tree is not watched as a whole for memory, only its root and directories with memory files
+	// found by scans, memory files added to other directories are found after the cache ttl.
+	for _, dir := range []string{globalMemory, memoryRoot} {
+		if dir != "" {
+			_ = watcher.Add(dir) // dir might not exist
+		}
+	}
+
 	cs.mu.Lock()
 	cs.watcherActive = true
 	cs.mu.Unlock()
@@ -148,6 +425,28 @@ func (cs *CachedScanner) startWatcher(ctx context.Context) error {
 	return nil
 }
 
+// watchMemoryDirs adds directories of memory files below the project root to watcher, if it is running
+func (cs *CachedScanner) watchMemoryDirs(files []FileInfo) {
+	memoryRoot, _ := cs.scanner.MemoryDirs()
+	cs.mu.RLock()
+	watcher := cs.watcher
+	active := cs.watcherActive
+	cs.mu.RUnlock()
+	if memoryRoot == "" || watcher == nil || !active {
+		return
+	}
+	for _, f := range files {
+		if f.Source != SourceMemory {
+			continue
+		}
+		dir := filepath.Dir(f.Path)
+		if rel, err := filepath.Rel(memoryRoot, dir); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue // the root is watched already, global and upper dirs are not watched
+		}
+		_ = watcher.Add(dir) // adding a watched dir again is a no-op
+	}
+}
+
 // addWatchRecursive adds directory and subdirectories to watcher
 func (cs *CachedScanner) addWatchRecursive(dir string) error {
 	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error { // nolint:wrapcheck // filepath.WalkDir error is descriptive
@@ -206,8 +505,16 @@ func (cs *CachedScanner) watchLoop(ctx context.Context) {
 			if !ok {
 				return
 			}
+			cs.stats.watcherEvents.Add(1)
 
 			if cs.isRelevantEvent(event) {
+				// expansions, hashes and contents are dropped right away, only the file list rescan is debounced
+				cs.invalidateFile(event.Name)
+				cs.mu.RLock()
+				for _, f := range cs.followers {
+					f.invalidateFile(event.Name)
+				}
+				cs.mu.RUnlock()
 				// reset debounce timer on each relevant event
 				debounceTimer.Reset(cs.debounce)
 			}
@@ -220,11 +527,19 @@ func (cs *CachedScanner) watchLoop(ctx context.Context) {
 			if !ok {
 				return
 			}
+			cs.stats.watcherErrors.Add(1)
 			slog.Warn("file watcher error", "error", err)
 		}
 	}
 }
 
+// invalidateFile drops cached expansions, version and content of the changed file
+func (cs *CachedScanner) invalidateFile(changed string) {
+	cs.invalidateExpansions(changed)
+	cs.versions.Invalidate(changed)
+	cs.contents.remove(changed)
+}
+
 // isRelevantEvent checks if event should trigger cache invalidation
 func (cs *CachedScanner) isRelevantEvent(event fsnotify.Event) bool {
 	// only care about write, create, remove, rename
@@ -233,8 +548,8 @@ func (cs *CachedScanner) isRelevantEvent(event fsnotify.Event) bool {
 		return false
 	}
 
-	// only care about .md files
-	if !strings.HasSuffix(event.Name, ".md") {
+	// only care about .md files and directory metadata files
+	if !strings.HasSuffix(event.Name, ".md") && filepath.Base(event.Name) != MetaFileName {
 		return false
 	}
 
@@ -254,6 +569,7 @@ func (cs *CachedScanner) isRelevantEvent(event fsnotify.Event) bool {
 
 // invalidate clears the cache
 func (cs *CachedScanner) invalidate() {
+	cs.stats.invalidations.Add(1)
 	cs.cache.Invalidate(cacheKey)
 }
 
@@ -64,6 +64,44 @@ func TestCachedScanner_Scan_CacheHitMiss(t *testing.T) {
 	assert.Equal(t, files1[0].Name, files2[0].Name)
 }
 
+func TestCachedScanner_Stats(t *testing.T) {
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	require.NoError(t, os.MkdirAll(filepath.Join(commandsDir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(commandsDir, "a.md"), []byte("a"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(commandsDir, "sub", "b.md"), []byte("b"), 0600))
+
+	scanner := NewScanner(Params{CommandsDir: commandsDir, MaxFileSize: 1024 * 1024})
+	cached, err := NewCachedScanner(scanner, 1*time.Hour)
+	require.NoError(t, err)
+	defer cached.Close()
+
+	stats := cached.Stats()
+	assert.Zero(t, stats.Hits)
+	assert.Zero(t, stats.Misses)
+	assert.Nil(t, stats.Files, "nothing cached before the first scan")
+	assert.Equal(t, 2, stats.WatchedDirs)
+
+	ctx := context.Background()
+	_, err = cached.Scan(ctx)
+	require.NoError(t, err)
+	_, err = cached.Scan(ctx)
+	require.NoError(t, err)
+	cached.invalidate()
+
+	stats = cached.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Scans)
+	assert.Positive(t, stats.ScanTime)
+	assert.Equal(t, int64(1), stats.Invalidations)
+	assert.Nil(t, stats.Files, "invalidated list is not counted")
+
+	_, err = cached.Scan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[Source]int{SourceCommands: 2}, cached.Stats().Files)
+}
+
 func TestCachedScanner_Invalidate(t *testing.T) {
 	tmpDir := t.TempDir()
 	commandsDir := filepath.Join(tmpDir, "commands")
@@ -177,6 +215,18 @@ func TestCachedScanner_IsRelevantEvent(t *testing.T) {
 			op:       "write",
 			expected: false,
 		},
+		{
+			name:     "directory metadata file",
+			path:     "/path/docs/runbooks/_meta.yml",
+			op:       "write",
+			expected: true,
+		},
+		{
+			name:     "other yaml file",
+			path:     "/path/docs/runbooks/config.yml",
+			op:       "write",
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -250,6 +300,304 @@ func TestCachedScanner_Integration(t *testing.T) {
 	require.Len(t, files, 1)
 }
 
+func TestCachedScanner_MetaFileChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	runbooksDir := filepath.Join(tmpDir, "docs", "runbooks")
+	require.NoError(t, os.MkdirAll(runbooksDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooksDir, "restart.md"), []byte("# Restart"), 0600))
+
+	scanner := NewScanner(Params{ProjectDocsDir: filepath.Join(tmpDir, "docs"), MaxFileSize: 1024 * 1024})
+	cached, err := NewCachedScanner(scanner, 1*time.Hour)
+	require.NoError(t, err)
+	defer cached.Close()
+
+	ctx := context.Background()
+	files, err := cached.Scan(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Empty(t, files[0].Tags)
+
+	// adding directory metadata should invalidate cache and re-apply inherited tags
+	require.NoError(t, os.WriteFile(filepath.Join(runbooksDir, MetaFileName), []byte("tags: [ops, oncall]\n"), 0600))
+
+	assert.Eventually(t, func() bool {
+		files, err = cached.Scan(ctx)
+		return err == nil && len(files) == 1 && len(files[0].Tags) == 2
+	}, 2*time.Second, 100*time.Millisecond, "inherited tags should appear after _meta.yml change")
+	assert.Equal(t, []string{"ops", "oncall"}, files[0].Tags)
+}
+
+func TestCachedScanner_Expansions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	guide, shared, other := filepath.Join(docsDir, "guide.md"), filepath.Join(docsDir, "shared.md"), filepath.Join(docsDir, "other.md")
+	for _, p := range []string{guide, shared, other} {
+		require.NoError(t, os.WriteFile(p, []byte("# doc"), 0600))
+	}
+
+	cached, err := NewCachedScanner(NewScanner(Params{ProjectDocsDir: docsDir, MaxFileSize: 1024}), time.Hour)
+	require.NoError(t, err)
+	defer cached.Close()
+
+	cached.SetExpansion(guide, &Expansion{Content: []byte("expanded"), Deps: []string{shared}})
+	cached.SetExpansion(other, &Expansion{Content: []byte("other")})
+	exp, ok := cached.Expansion(guide)
+	require.True(t, ok)
+	assert.Equal(t, "expanded", string(exp.Content))
+
+	// change of included doc drops expansions depending on it only
+	require.NoError(t, os.WriteFile(shared, []byte("# changed"), 0600))
+	assert.Eventually(t, func() bool {
+		_, ok := cached.Expansion(guide)
+		return !ok
+	}, 2*time.Second, 50*time.Millisecond)
+	_, ok = cached.Expansion(other)
+	assert.True(t, ok)
+
+	// change of the doc itself drops its expansion
+	require.NoError(t, os.WriteFile(other, []byte("# changed"), 0600))
+	assert.Eventually(t, func() bool {
+		_, ok := cached.Expansion(other)
+		return !ok
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// nothing is cached without watcher
+	require.NoError(t, cached.Close())
+	cached.SetExpansion(guide, &Expansion{})
+	_, ok = cached.Expansion(guide)
+	assert.False(t, ok)
+}
+
+func TestCachedScanner_Version(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	guide := filepath.Join(docsDir, "guide.md")
+	require.NoError(t, os.WriteFile(guide, []byte("# guide"), 0600))
+
+	cached, err := NewCachedScanner(NewScanner(Params{ProjectDocsDir: docsDir, MaxFileSize: 1024}), time.Hour)
+	require.NoError(t, err)
+	defer cached.Close()
+
+	v, err := cached.Version(guide)
+	require.NoError(t, err)
+	assert.Equal(t, HashContent([]byte("# guide")), v.Hash)
+	assert.Equal(t, int64(7), v.Size)
+	info, err := os.Stat(guide)
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime(), v.ModTime)
+	cachedV, ok := cached.versions.Peek(guide)
+	require.True(t, ok)
+	assert.Equal(t, v, cachedV)
+
+	// change of the doc drops its hash
+	require.NoError(t, os.WriteFile(guide, []byte("# changed guide"), 0600))
+	assert.Eventually(t, func() bool {
+		_, ok := cached.versions.Peek(guide)
+		return !ok
+	}, 2*time.Second, 50*time.Millisecond)
+	v, err = cached.Version(guide)
+	require.NoError(t, err)
+	assert.Equal(t, HashContent([]byte("# changed guide")), v.Hash)
+
+	// stale cached hash is not returned for a file of another size
+	cached.versions.Set(guide, DocVersion{Hash: "stale", ModTime: v.ModTime, Size: 1}, time.Hour)
+	v, err = cached.Version(guide)
+	require.NoError(t, err)
+	assert.Equal(t, HashContent([]byte("# changed guide")), v.Hash)
+
+	_, err = cached.Version(filepath.Join(docsDir, "missing.md"))
+	require.ErrorContains(t, err, "failed to stat file")
+
+	// nothing is cached without watcher
+	require.NoError(t, cached.Close())
+	cached.versions.Purge()
+	_, err = cached.Version(guide)
+	require.NoError(t, err)
+	_, ok = cached.versions.Peek(guide)
+	assert.False(t, ok)
+}
+
+func TestCachedScanner_Document(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	guide, other := filepath.Join(docsDir, "guide.md"), filepath.Join(docsDir, "other.md")
+	raw := "---\ntags: [a]\n---\n# guide\n"
+	require.NoError(t, os.WriteFile(guide, []byte(raw), 0600))
+	require.NoError(t, os.WriteFile(other, []byte("# other"), 0600))
+
+	cached, err := NewCachedScanner(NewScanner(Params{ProjectDocsDir: docsDir, MaxFileSize: 1024}), time.Hour)
+	require.NoError(t, err)
+	defer cached.Close()
+	cached.WithContentCache(1024)
+
+	doc, err := cached.Document(guide)
+	require.NoError(t, err)
+	assert.Equal(t, "# guide\n", string(doc.Body))
+	assert.Equal(t, HashContent([]byte(raw)), doc.Version.Hash)
+	_, err = cached.Document(guide)
+	require.NoError(t, err)
+	_, err = cached.Document(other)
+	require.NoError(t, err)
+
+	stats := cached.Stats()
+	assert.Equal(t, int64(1), stats.ContentHits)
+	assert.Equal(t, int64(2), stats.ContentMisses)
+	assert.Equal(t, 2, stats.ContentDocs)
+	assert.Equal(t, int64(len("# guide\n")+len("# other")), stats.ContentBytes)
+	v, err := cached.Version(guide)
+	require.NoError(t, err)
+	assert.Equal(t, doc.Version, v, "version cached by document read")
+
+	// change of the doc drops it from cache, other docs are kept
+	require.NoError(t, os.WriteFile(guide, []byte("# guide v2\n"), 0600))
+	assert.Eventually(t, func() bool { return cached.Stats().ContentDocs == 1 }, 2*time.Second, 50*time.Millisecond)
+	doc, err = cached.Document(guide)
+	require.NoError(t, err)
+	assert.Equal(t, "# guide v2\n", string(doc.Body))
+	_, err = cached.Document(other)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cached.Stats().ContentHits)
+
+	_, err = cached.Document(filepath.Join(docsDir, "missing.md"))
+	require.ErrorContains(t, err, "failed to stat file")
+
+	t.Run("unwatched file", func(t *testing.T) {
+		// files outside of the sources, like memory files of parent dirs, are not watched
+		outside := filepath.Join(tmpDir, "outside.md")
+		require.NoError(t, os.WriteFile(outside, []byte("# one"), 0600))
+		doc, err := cached.Document(outside)
+		require.NoError(t, err)
+		assert.Equal(t, "# one", string(doc.Body))
+		hits := cached.Stats().ContentHits
+		_, err = cached.Document(outside)
+		require.NoError(t, err)
+		assert.Equal(t, hits+1, cached.Stats().ContentHits)
+
+		// same size, only modification time tells the change
+		require.NoError(t, os.WriteFile(outside, []byte("# two"), 0600))
+		mtime := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(outside, mtime, mtime))
+		doc, err = cached.Document(outside)
+		require.NoError(t, err)
+		assert.Equal(t, "# two", string(doc.Body))
+		assert.Equal(t, HashContent([]byte("# two")), doc.Version.Hash)
+
+		require.NoError(t, os.WriteFile(outside, []byte("# three, longer"), 0600))
+		require.NoError(t, os.Chtimes(outside, mtime, mtime))
+		doc, err = cached.Document(outside)
+		require.NoError(t, err)
+		assert.Equal(t, "# three, longer", string(doc.Body), "size differs")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		plain, err := NewCachedScanner(NewScanner(Params{ProjectDocsDir: docsDir, MaxFileSize: 1024}), time.Hour)
+		require.NoError(t, err)
+		defer plain.Close()
+		for range 2 {
+			doc, err := plain.Document(other)
+			require.NoError(t, err)
+			assert.Equal(t, "# other", string(doc.Body))
+		}
+		stats := plain.Stats()
+		assert.Equal(t, int64(2), stats.ContentMisses)
+		assert.Zero(t, stats.ContentHits)
+		assert.Zero(t, stats.ContentDocs)
+	})
+}
+
+func TestCachedScanner_WithShared(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	commandsDir, globalDir := filepath.Join(tmpDir, "commands"), filepath.Join(tmpDir, "global")
+	projectDir := filepath.Join(tmpDir, "project")
+	docsDir := filepath.Join(projectDir, "docs")
+	for _, d := range []string{commandsDir, globalDir, docsDir} {
+		require.NoError(t, os.MkdirAll(d, 0755))
+	}
+	files := map[string]string{
+		filepath.Join(commandsDir, "commit.md"): "# commit",
+		filepath.Join(globalDir, "CLAUDE.md"):   "# global",
+		filepath.Join(projectDir, "CLAUDE.md"):  "# project",
+		filepath.Join(projectDir, "README.md"):  "# readme",
+		filepath.Join(docsDir, "guide.md"):      "# guide",
+	}
+	for p, content := range files {
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+
+	shared, err := NewCachedScanner(NewScanner(Params{CommandsDir: commandsDir, GlobalMemoryDir: globalDir, MaxFileSize: 1024}),
+		time.Hour)
+	require.NoError(t, err)
+	defer shared.Close()
+	shared.WithContentCache(1024)
+	project, err := NewCachedScanner(NewScanner(Params{ProjectDocsDir: docsDir, ProjectRootDir: projectDir,
+		MemoryRootDir: projectDir, MaxFileSize: 1024}), time.Hour)
+	require.NoError(t, err)
+	project.WithShared(shared)
+
+	res, err := project.Scan(context.Background())
+	require.NoError(t, err)
+	names := make([]string, 0, len(res))
+	for _, f := range res {
+		names = append(names, f.Filename)
+	}
+	assert.Equal(t, []string{"commands:commit.md", "project-docs:guide.md", "project-root:CLAUDE.md", "project-root:README.md",
+		"memory:global/CLAUDE.md", "memory:CLAUDE.md"}, names, "in order of sources, shared memory first")
+	assert.Equal(t, commandsDir, project.CommandsDir())
+
+	p, err := project.ResolveMemoryPath("global/CLAUDE.md", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(globalDir, "CLAUDE.md"), p)
+	p, err = project.ResolveMemoryPath("CLAUDE.md", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "CLAUDE.md"), p)
+
+	// content cache of the shared scanner is used, shared changes are dropped from caches of the project
+	commit := filepath.Join(commandsDir, "commit.md")
+	_, err = project.Document(commit)
+	require.NoError(t, err)
+	project.SetExpansion(filepath.Join(docsDir, "guide.md"), &Expansion{Deps: []string{commit}})
+	assert.Equal(t, 1, shared.Stats().ContentDocs)
+	require.NoError(t, os.WriteFile(commit, []byte("# commit v2"), 0600))
+	assert.Eventually(t, func() bool { return shared.Stats().ContentDocs == 0 }, 2*time.Second, 50*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		_, ok := project.Expansion(filepath.Join(docsDir, "guide.md"))
+		return !ok
+	}, 2*time.Second, 50*time.Millisecond, "expansion depending on shared doc dropped")
+
+	require.NoError(t, project.Close())
+	assert.Empty(t, shared.followers)
+	_, err = shared.Scan(context.Background())
+	require.NoError(t, err, "shared scanner is not closed with the project")
+}
+
+func TestHashContent(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", HashContent(nil))
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", HashContent([]byte("hello")))
+}
+
 func TestCachedScanner_TTLExpiration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping TTL test in short mode")
@@ -712,3 +1060,27 @@ func TestCachedScanner_AddWatchRecursive_ExcludedDirs(t *testing.T) {
 	assert.Contains(t, fileNames, "normal.md")
 	assert.NotContains(t, fileNames, "plan.md")
 }
+
+func TestCachedScanner_WatchMemoryDirs(t *testing.T) {
+	project := t.TempDir()
+	writeFiles(t, project, map[string]string{
+		"CLAUDE.md":            "# Project",
+		"app/server/CLAUDE.md": "# Server",
+		"app/client/main.md":   "# not memory",
+		"lib/pkg/code.md":      "# not memory",
+	})
+
+	cached, err := NewCachedScanner(NewScanner(Params{MemoryRootDir: project, MaxFileSize: 1024}), time.Hour)
+	require.NoError(t, err)
+	defer cached.Close()
+	assert.Equal(t, []string{project}, cached.watcher.WatchList(), "only the root is watched before scan")
+
+	_, err = cached.Scan(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{project, filepath.Join(project, "app", "server")}, cached.watcher.WatchList(),
+		"directories without memory files are not watched")
+
+	// change of a watched memory file invalidates the file list
+	require.NoError(t, os.WriteFile(filepath.Join(project, "app", "server", "CLAUDE.md"), []byte("# Server v2"), 0600))
+	require.Eventually(t, func() bool { return cached.Stats().Invalidations > 0 }, 2*time.Second, 20*time.Millisecond)
+}
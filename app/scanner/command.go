@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"path"
+	"strings"
+)
+
+// CommandMeta contains metadata of a Claude slash command, parsed from frontmatter of files in the commands source
+type CommandMeta struct {
+	Name         string   // slash command name derived from the path, e.g. "/action:commit"
+	ArgumentHint string   // argument-hint, shown to the user after the command name
+	AllowedTools []string // allowed-tools, e.g. "Bash(git add:*)"
+	Model        string   // model the command should run with
+}
+
+// CommandName derives slash command name from a path relative to the commands directory,
+// directories become namespaces separated by ":", e.g. "action/commit.md" is "/action:commit"
+func CommandName(relPath string) string {
+	name := strings.TrimSuffix(path.Clean(strings.ReplaceAll(relPath, "\\", "/")), ".md")
+	return "/" + strings.ReplaceAll(name, "/", ":")
+}
+
+// commandMetaFromFields makes command metadata for a command file with the given relative path
+func commandMetaFromFields(relPath string, fields map[string]any) *CommandMeta {
+	return &CommandMeta{
+		Name:         CommandName(relPath),
+		ArgumentHint: argumentHint(fields["argument-hint"]),
+		AllowedTools: parseTools(fields["allowed-tools"]),
+		Model:        stringField(fields["model"]),
+	}
+}
+
+// argumentHint converts argument-hint value to string. unquoted hints like "[message]"
+// are decoded by YAML as lists, such hints are restored to bracketed form.
+func argumentHint(v any) string {
+	list, ok := v.([]any)
+	if !ok {
+		return stringField(v)
+	}
+	parts := make([]string, 0, len(list))
+	for _, item := range list {
+		parts = append(parts, "["+stringField(item)+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseTools converts allowed-tools value to []string. lists are used as is, strings are split
+// on commas outside of parentheses, as tool patterns like "Bash(git add:*, git rm:*)" may contain commas.
+func parseTools(v any) []string {
+	s, ok := v.(string)
+	if !ok {
+		return parseTags(v)
+	}
+
+	var res []string
+	depth, start := 0, 0
+	for i, r := range s + "," {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth > 0 {
+				continue
+			}
+			if tool := strings.TrimSpace(s[start:min(i, len(s))]); tool != "" {
+				res = append(res, tool)
+			}
+			start = i + 1
+		}
+	}
+	return res
+}
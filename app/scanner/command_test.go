@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{path: "commit.md", want: "/commit"},
+		{path: "action/commit.md", want: "/action:commit"},
+		{path: "a/b/deploy.md", want: "/a:b:deploy"},
+		{path: "./action//review.md", want: "/action:review"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CommandName(tt.path), tt.path)
+	}
+}
+
+func TestParseTools(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want []string
+	}{
+		{name: "nil", in: nil, want: nil},
+		{name: "empty", in: "", want: nil},
+		{name: "single", in: "Read", want: []string{"Read"}},
+		{name: "comma separated", in: "Read, Grep,Glob", want: []string{"Read", "Grep", "Glob"}},
+		{name: "patterns with commas", in: "Bash(git add:*, git rm:*), Read",
+			want: []string{"Bash(git add:*, git rm:*)", "Read"}},
+		{name: "unbalanced parens", in: "Read), Grep", want: []string{"Read)", "Grep"}},
+		{name: "list", in: []any{"Bash(git status:*)", "Read"}, want: []string{"Bash(git status:*)", "Read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseTools(tt.in))
+		})
+	}
+}
+
+func TestArgumentHint(t *testing.T) {
+	assert.Equal(t, "", argumentHint(nil))
+	assert.Equal(t, "<file> [--force]", argumentHint("<file> [--force]"))
+	assert.Equal(t, "[message]", argumentHint([]any{"message"}))
+	assert.Equal(t, "[a, b]", argumentHint([]any{"a, b"}))
+}
+
+func TestScanner_CommandMeta(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"commands/action/commit.md": "---\ndescription: commit changes\nargument-hint: [message]\n" +
+			"allowed-tools: Bash(git add:*), Bash(git commit:*)\nmodel: haiku\n---\nCommit with $ARGUMENTS\n",
+		"commands/plain.md": "# no frontmatter\n",
+		"docs/guide.md":     "---\nargument-hint: ignored\n---\n",
+	})
+
+	s := NewScanner(Params{CommandsDir: tmpDir + "/commands", ProjectDocsDir: tmpDir + "/docs", MaxFileSize: 1024})
+	files, err := s.Scan(context.Background())
+	require.NoError(t, err)
+	byName := map[string]FileInfo{}
+	for _, f := range files {
+		byName[f.Filename] = f
+	}
+
+	commit := byName["commands:action/commit.md"]
+	require.NotNil(t, commit.Command)
+	assert.Equal(t, CommandMeta{Name: "/action:commit", ArgumentHint: "[message]",
+		AllowedTools: []string{"Bash(git add:*)", "Bash(git commit:*)"}, Model: "haiku"}, *commit.Command)
+	assert.Equal(t, "commit changes", commit.Description)
+
+	plain := byName["commands:plain.md"]
+	require.NotNil(t, plain.Command)
+	assert.Equal(t, CommandMeta{Name: "/plain"}, *plain.Command)
+
+	assert.Nil(t, byName["project-docs:guide.md"].Command, "only commands have command metadata")
+}
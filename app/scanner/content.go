@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DocContent is a doc file with frontmatter stripped, and the version of the whole file
+type DocContent struct {
+	Body    []byte
+	Version DocVersion
+}
+
+// contentCache is LRU cache of doc contents by absolute path, bounded by total size of bodies,
+// docs expire after ttl. nil cache is disabled, it doesn't store anything.
+type contentCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	bytes    int64
+	order    *list.List               // most recently used first, elements are *contentEntry
+	entries  map[string]*list.Element // by path
+	gen      int64                    // incremented on each removal, to skip docs read before it
+}
+
+// contentEntry is an element of contentCache
+type contentEntry struct {
+	path    string
+	doc     DocContent
+	expires time.Time
+}
+
+// newContentCache makes content cache of maxBytes size with docs expiring after ttl,
+// returns nil if size is not positive
+func newContentCache(maxBytes int64, ttl time.Duration) *contentCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &contentCache{maxBytes: maxBytes, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns cached doc and marks it as recently used, expired doc is dropped
+func (c *contentCache) get(path string) (DocContent, bool) {
+	if c == nil {
+		return DocContent{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[path]
+	if !ok {
+		return DocContent{}, false
+	}
+	entry := el.Value.(*contentEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.removeLocked(path)
+		return DocContent{}, false
+	}
+	c.order.MoveToFront(el)
+	return entry.doc, true
+}
+
+// generation returns the current generation, to be passed to set of a doc read after this call
+func (c *contentCache) generation() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// set caches doc read at the given generation, evicting least recently used docs to fit the size.
+// docs larger than the cache, or read before a removal, possibly of a changed file, are not stored.
+func (c *contentCache) set(path string, doc DocContent, gen int64) {
+	if c == nil || int64(len(doc.Body)) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	c.removeLocked(path)
+	c.entries[path] = c.order.PushFront(&contentEntry{path: path, doc: doc, expires: time.Now().Add(c.ttl)})
+	c.bytes += int64(len(doc.Body))
+	for c.bytes > c.maxBytes {
+		c.removeLocked(c.order.Back().Value.(*contentEntry).path)
+	}
+}
+
+// remove drops cached doc of the path, if any
+func (c *contentCache) remove(path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.removeLocked(path)
+}
+
+// removeLocked drops cached doc of the path, the lock is held by the caller
+func (c *contentCache) removeLocked(path string) {
+	el, ok := c.entries[path]
+	if !ok {
+		return
+	}
+	c.order.Remove(el)
+	delete(c.entries, path)
+	c.bytes -= int64(len(el.Value.(*contentEntry).doc.Body))
+}
+
+// size returns number of cached docs and their total size
+func (c *contentCache) size() (docs int, bytes int64) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.bytes
+}
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentCache(t *testing.T) {
+	doc := func(body string) DocContent { return DocContent{Body: []byte(body), Version: DocVersion{Hash: body}} }
+	c := newContentCache(10, time.Hour)
+
+	c.set("a", doc("aaaa"), c.generation())
+	c.set("b", doc("bbbb"), c.generation())
+	got, ok := c.get("a")
+	require.True(t, ok)
+	assert.Equal(t, doc("aaaa"), got)
+
+	c.set("c", doc("cccc"), c.generation()) // evicts b, least recently used
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	docs, bytes := c.size()
+	assert.Equal(t, 2, docs)
+	assert.Equal(t, int64(8), bytes)
+
+	c.set("a", doc("aa"), c.generation()) // replaces a
+	docs, bytes = c.size()
+	assert.Equal(t, 2, docs)
+	assert.Equal(t, int64(6), bytes)
+
+	c.set("big", doc("01234567890"), c.generation())
+	_, ok = c.get("big")
+	assert.False(t, ok, "doc larger than the cache is not stored")
+	_, ok = c.get("c")
+	assert.True(t, ok, "nothing evicted for a doc which is not stored")
+
+	gen := c.generation()
+	c.remove("c")
+	_, ok = c.get("c")
+	assert.False(t, ok)
+	c.set("d", doc("d"), gen)
+	_, ok = c.get("d")
+	assert.False(t, ok, "doc read before a removal is not stored")
+	docs, bytes = c.size()
+	assert.Equal(t, 1, docs)
+	assert.Equal(t, int64(2), bytes)
+}
+
+func TestContentCache_TTL(t *testing.T) {
+	c := newContentCache(10, 50*time.Millisecond)
+	c.set("a", DocContent{Body: []byte("a")}, c.generation())
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	time.Sleep(60 * time.Millisecond)
+	_, ok = c.get("a")
+	assert.False(t, ok, "expired doc is not returned")
+	docs, _ := c.size()
+	assert.Zero(t, docs, "expired doc is dropped")
+}
+
+func TestContentCache_Disabled(t *testing.T) {
+	assert.Nil(t, newContentCache(0, time.Hour))
+	var c *contentCache
+	c.set("a", DocContent{Body: []byte("a")}, c.generation())
+	_, ok := c.get("a")
+	assert.False(t, ok)
+	c.remove("a")
+	docs, bytes := c.size()
+	assert.Zero(t, docs)
+	assert.Zero(t, bytes)
+}
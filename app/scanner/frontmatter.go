@@ -2,90 +2,227 @@ package scanner
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
-// Frontmatter contains metadata extracted from YAML frontmatter
-type Frontmatter struct {
-	Description string   `yaml:"description"`
-	Tags        []string `yaml:"tags"`
-}
+// FrontmatterFormat identifies the syntax of a frontmatter block
+type FrontmatterFormat string
+
+const (
+	// FormatNone means no frontmatter block was found
+	FormatNone FrontmatterFormat = ""
+	// FormatYAML is a block delimited by "---" lines
+	FormatYAML FrontmatterFormat = "yaml"
+	// FormatTOML is a block delimited by "+++" lines (hugo style)
+	FormatTOML FrontmatterFormat = "toml"
+	// FormatJSON is a JSON object starting on the first line
+	FormatJSON FrontmatterFormat = "json"
+)
+
+// ErrUnclosedFrontmatter is returned when a frontmatter block has an opening delimiter but no closing one
+var ErrUnclosedFrontmatter = errors.New("frontmatter block is not closed")
 
-// rawFrontmatter is used for initial YAML parsing to handle flexible tag formats
-type rawFrontmatter struct {
-	Description string      `yaml:"description"`
-	Tags        interface{} `yaml:"tags"`
+// Frontmatter contains metadata extracted from YAML, TOML or JSON frontmatter
+type Frontmatter struct {
+	Name        string            `yaml:"name"` // used as skill name
+	Description string            `yaml:"description"`
+	Tags        []string          `yaml:"tags"`
+	Priority    *int              `yaml:"priority"` // nil if not set
+	Audience    []string          `yaml:"audience"`
+	Format      FrontmatterFormat `yaml:"-"`
+	Fields      map[string]any    `yaml:"-"` // all decoded top-level keys, as found in the block
 }
 
-// ParseFrontmatter extracts YAML frontmatter from markdown content.
+// ParseFrontmatter extracts frontmatter from markdown content.
 // Returns frontmatter metadata and content without the frontmatter block.
 // If no frontmatter exists or parsing fails, returns empty metadata and original content.
+// Use DecodeFrontmatter to get the parsing error.
 func ParseFrontmatter(content []byte) (frontmatter Frontmatter, strippedContent []byte) {
-	var fm Frontmatter
+	fm, stripped, _ := DecodeFrontmatter(content)
+	return fm, stripped
+}
 
-	// check if content starts with frontmatter delimiter
-	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
-		return fm, content
+// DecodeFrontmatter extracts YAML ("---"), TOML ("+++") or JSON (leading "{") frontmatter from markdown content.
+// Returns frontmatter metadata and content without the frontmatter block. If the block is malformed,
+// returns empty metadata, the original content and an error describing the problem.
+// Content without any frontmatter is not an error.
+func DecodeFrontmatter(content []byte) (frontmatter Frontmatter, strippedContent []byte, err error) {
+	switch {
+	case hasDelimiterLine(content, "---"):
+		return decodeDelimited(content, "---", FormatYAML)
+	case hasDelimiterLine(content, "+++"):
+		return decodeDelimited(content, "+++", FormatTOML)
+	case isJSONObjectStart(content):
+		return decodeJSON(content)
+	default:
+		return Frontmatter{}, content, nil
 	}
+}
+
+// hasDelimiterLine checks if content starts with the delimiter on its own line (LF or CRLF)
+func hasDelimiterLine(content []byte, delim string) bool {
+	return bytes.HasPrefix(content, []byte(delim+"\n")) || bytes.HasPrefix(content, []byte(delim+"\r\n"))
+}
 
-	// determine line ending type
-	lineEnding := "\n"
-	if bytes.HasPrefix(content, []byte("---\r\n")) {
-		lineEnding = "\r\n"
+// isJSONObjectStart checks if content starts with a JSON object, "{" followed by a key or the closing brace,
+// on the same line or the next ones. other text starting with "{", like template directives, is not frontmatter.
+func isJSONObjectStart(content []byte) bool {
+	if !bytes.HasPrefix(content, []byte("{")) {
+		return false
 	}
+	rest := bytes.TrimLeft(content[1:], " \t\r\n")
+	return bytes.HasPrefix(rest, []byte(`"`)) || bytes.HasPrefix(rest, []byte("}"))
+}
 
-	// find the closing delimiter
-	// skip the opening "---\n" or "---\r\n"
-	startIdx := bytes.Index(content, []byte(lineEnding))
-	if startIdx == -1 {
-		return fm, content
+// lineEndingOf returns the line ending used by the first line of content
+func lineEndingOf(content []byte) string {
+	idx := bytes.IndexByte(content, '\n')
+	if idx > 0 && content[idx-1] == '\r' {
+		return "\r\n"
 	}
-	startIdx += len(lineEnding)
+	return "\n"
+}
 
-	// look for closing "---" on its own line
+// decodeDelimited parses a frontmatter block enclosed by delim lines, e.g. "---" or "+++"
+func decodeDelimited(content []byte, delim string, format FrontmatterFormat) (Frontmatter, []byte, error) {
+	lineEnding := lineEndingOf(content)
+
+	// skip the opening delimiter line
+	startIdx := len(delim) + len(lineEnding)
 	remaining := content[startIdx:]
 
 	// look for closing delimiter: either at start of remaining (empty frontmatter)
-	// or preceded by a line ending
-	var endIdx int
-	closingPattern := "---" + lineEnding
-	if bytes.HasPrefix(remaining, []byte(closingPattern)) {
+	// or preceded by a line ending. closing delimiter may also be the last line without line ending
+	var endIdx, closingLen int
+	closingPattern := delim + lineEnding
+	switch {
+	case bytes.HasPrefix(remaining, []byte(closingPattern)):
 		// empty frontmatter case: ---\n---\n
-		endIdx = 0
-	} else {
+		endIdx, closingLen = 0, len(closingPattern)
+	case bytes.Equal(remaining, []byte(delim)):
+		endIdx, closingLen = 0, len(delim)
+	default:
 		// normal case: content\n---\n
-		closingDelim := lineEnding + closingPattern
-		endIdx = bytes.Index(remaining, []byte(closingDelim))
-		if endIdx == -1 {
-			// no closing delimiter found
-			return fm, content
+		idx := bytes.Index(remaining, []byte(lineEnding+closingPattern))
+		switch {
+		case idx >= 0:
+			endIdx, closingLen = idx+len(lineEnding), len(closingPattern)
+		case bytes.HasSuffix(remaining, []byte(lineEnding+delim)):
+			endIdx, closingLen = len(remaining)-len(delim), len(delim)
+		default:
+			return Frontmatter{}, content, fmt.Errorf("%s: %w", format, ErrUnclosedFrontmatter)
 		}
-		endIdx += len(lineEnding) // skip the newline before ---
 	}
 
-	// extract YAML block (between the two --- delimiters)
-	yamlBlock := remaining[:endIdx]
+	// extract metadata block (between the two delimiters)
+	block := remaining[:endIdx]
 
-	// parse YAML into raw format to handle flexible tag types (only if not empty)
-	var raw rawFrontmatter
-	if len(yamlBlock) > 0 {
-		if err := yaml.Unmarshal(yamlBlock, &raw); err != nil {
+	var fields map[string]any
+	if len(bytes.TrimSpace(block)) > 0 {
+		var err error
+		switch format {
+		case FormatTOML:
+			err = toml.Unmarshal(block, &fields)
+		default:
+			err = yaml.Unmarshal(block, &fields)
+		}
+		if err != nil {
 			// parsing failed, return empty metadata with original content
-			return Frontmatter{}, content
+			return Frontmatter{}, content, fmt.Errorf("invalid %s frontmatter: %w", format, err)
 		}
-		// copy description
-		fm.Description = raw.Description
-		// parse tags: handle string (comma-separated), array, or interface slice
-		fm.Tags = parseTags(raw.Tags)
 	}
 
-	// find where content starts (after closing ---)
-	contentStart := startIdx + endIdx + len(closingPattern)
-	contentWithoutFrontmatter := content[contentStart:]
+	fm := frontmatterFromFields(fields)
+	fm.Format = format
+
+	// content starts after the closing delimiter
+	return fm, content[startIdx+endIdx+closingLen:], nil
+}
+
+// decodeJSON parses a JSON object frontmatter starting at the first line of content.
+// the object must be followed by a line ending or the end of content.
+func decodeJSON(content []byte) (Frontmatter, []byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return Frontmatter{}, content, fmt.Errorf("invalid %s frontmatter: %w", FormatJSON, err)
+	}
 
-	return fm, contentWithoutFrontmatter
+	rest := content[dec.InputOffset():]
+	// drop trailing spaces and the line ending after the closing brace
+	rest = bytes.TrimLeft(rest, " \t")
+	switch {
+	case bytes.HasPrefix(rest, []byte("\r\n")):
+		rest = rest[2:]
+	case bytes.HasPrefix(rest, []byte("\n")):
+		rest = rest[1:]
+	case len(rest) > 0:
+		return Frontmatter{}, content, fmt.Errorf("invalid %s frontmatter: unexpected content after closing brace", FormatJSON)
+	}
+
+	fm := frontmatterFromFields(fields)
+	fm.Format = FormatJSON
+	return fm, rest, nil
+}
+
+// frontmatterFromFields maps decoded key/value pairs onto Frontmatter
+func frontmatterFromFields(fields map[string]any) Frontmatter {
+	fm := Frontmatter{Fields: fields}
+	fm.Name = stringField(fields["name"])
+	fm.Description = stringField(fields["description"])
+	// parse tags: handle string (comma-separated), array, or interface slice
+	fm.Tags = parseTags(fields["tags"])
+	fm.Priority = intField(fields["priority"])
+	fm.Audience = parseTags(fields["audience"]) // same formats as tags
+	return fm
+}
+
+// intField converts numeric frontmatter value to int, returns nil for missing or non-numeric values
+func intField(v any) *int {
+	var res int
+	switch val := v.(type) {
+	case int:
+		res = val
+	case int64:
+		res = int(val)
+	case uint64:
+		res = int(val) // nolint:gosec // priority values are small
+	case float64:
+		if val != float64(int(val)) {
+			return nil
+		}
+		res = int(val)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			return nil
+		}
+		res = n
+	default:
+		return nil
+	}
+	return &res
+}
+
+// stringField converts scalar frontmatter value to string, non-scalar values are ignored
+func stringField(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []any, map[string]any:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
 }
 
 // parseTags converts various tag formats to []string
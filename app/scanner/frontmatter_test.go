@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseFrontmatter(t *testing.T) {
@@ -153,3 +154,176 @@ Content here`
 	assert.Equal(t, []string{"test"}, fm.Tags)
 	assert.Equal(t, "Content here", string(content))
 }
+
+func TestParseFrontmatter_TOMLAndJSON(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		wantFormat      FrontmatterFormat
+		wantDescription string
+		wantTags        []string
+		wantContent     string
+	}{
+		{
+			name: "toml frontmatter",
+			input: `+++
+title = "Page"
+description = "TOML description"
+tags = ["hugo", "docs"]
++++
+# Content`,
+			wantFormat:      FormatTOML,
+			wantDescription: "TOML description",
+			wantTags:        []string{"hugo", "docs"},
+			wantContent:     "# Content",
+		},
+		{
+			name:            "toml frontmatter with windows line endings",
+			input:           "+++\r\ndescription = 'Windows TOML'\r\ntags = \"a, b\"\r\n+++\r\nContent",
+			wantFormat:      FormatTOML,
+			wantDescription: "Windows TOML",
+			wantTags:        []string{"a", "b"},
+			wantContent:     "Content",
+		},
+		{
+			name:        "empty toml frontmatter",
+			input:       "+++\n+++\nContent",
+			wantFormat:  FormatTOML,
+			wantContent: "Content",
+		},
+		{
+			name: "json frontmatter",
+			input: `{
+  "description": "JSON description",
+  "tags": ["generated"]
+}
+# Content`,
+			wantFormat:      FormatJSON,
+			wantDescription: "JSON description",
+			wantTags:        []string{"generated"},
+			wantContent:     "# Content",
+		},
+		{
+			name:            "json frontmatter with windows line endings",
+			input:           "{\r\n\"description\": \"win\"\r\n}\r\nContent",
+			wantFormat:      FormatJSON,
+			wantDescription: "win",
+			wantContent:     "Content",
+		},
+		{
+			name:            "json frontmatter only",
+			input:           "{\n\"description\": \"only\"\n}",
+			wantFormat:      FormatJSON,
+			wantDescription: "only",
+			wantContent:     "",
+		},
+		{
+			name:            "yaml closing delimiter at end of content",
+			input:           "---\ndescription: no trailing newline\n---",
+			wantFormat:      FormatYAML,
+			wantDescription: "no trailing newline",
+			wantContent:     "",
+		},
+		{
+			name:            "single line json frontmatter",
+			input:           "{\"description\": \"one line\", \"tags\": [\"a\"]}\n# Content",
+			wantFormat:      FormatJSON,
+			wantDescription: "one line",
+			wantTags:        []string{"a"},
+			wantContent:     "# Content",
+		},
+		{
+			name:        "empty json object",
+			input:       "{}\nContent",
+			wantFormat:  FormatJSON,
+			wantContent: "Content",
+		},
+		{
+			name:        "template directive is content",
+			input:       "{{< include \"x.md\" >}}\nContent",
+			wantFormat:  FormatNone,
+			wantContent: "{{< include \"x.md\" >}}\nContent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, content, err := DecodeFrontmatter([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, fm.Format)
+			assert.Equal(t, tt.wantDescription, fm.Description)
+			assert.Equal(t, tt.wantTags, fm.Tags)
+			assert.Equal(t, tt.wantContent, string(content))
+		})
+	}
+}
+
+func TestDecodeFrontmatter_TOMLFields(t *testing.T) {
+	input := "+++\ntitle = \"Basic \\\"quoted\\\"\"\ndate = 1979-05-27\nweight = 10\n\n[params]\nauthor = \"me\"\n\n" +
+		"[[menu]]\nname = \"a\"\n+++\nContent"
+	fm, content, err := DecodeFrontmatter([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, "Content", string(content))
+	assert.Equal(t, `Basic "quoted"`, fm.Fields["title"])
+	assert.Equal(t, int64(10), fm.Fields["weight"])
+	assert.Equal(t, map[string]any{"author": "me"}, fm.Fields["params"])
+	assert.Equal(t, []map[string]any{{"name": "a"}}, fm.Fields["menu"])
+	assert.Contains(t, fm.Fields, "date")
+}
+
+func TestDecodeFrontmatter_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "unclosed yaml", input: "---\ndescription: x\nContent", wantErr: "yaml: frontmatter block is not closed"},
+		{name: "unclosed toml", input: "+++\ndescription = \"x\"\nContent", wantErr: "toml: frontmatter block is not closed"},
+		{name: "invalid yaml", input: "---\ndescription: [broken\n---\nContent", wantErr: "invalid yaml frontmatter"},
+		{name: "invalid toml", input: "+++\ndescription = broken\n+++\nContent", wantErr: "invalid toml frontmatter: toml: line 1"},
+		{name: "invalid json", input: "{\n\"description\": \n}\nContent", wantErr: "invalid json frontmatter"},
+		{name: "json trailing garbage", input: "{\n\"description\": \"x\"\n} garbage\nContent", wantErr: "unexpected content after closing brace"},
+		{name: "single line json trailing garbage", input: "{\"description\": \"x\"} # Title\nContent",
+			wantErr: "unexpected content after closing brace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, content, err := DecodeFrontmatter([]byte(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, Frontmatter{}, fm)
+			assert.Equal(t, tt.input, string(content), "original content should be returned")
+		})
+	}
+
+	_, _, err := DecodeFrontmatter([]byte("---\ndescription: x\n"))
+	assert.ErrorIs(t, err, ErrUnclosedFrontmatter)
+}
+
+func TestParseFrontmatter_PriorityAndAudience(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantPriority *int
+		wantAudience []string
+	}{
+		{name: "yaml int", input: "---\npriority: 3\naudience: [sre, dev]\n---\n", wantPriority: intPtr(3), wantAudience: []string{"sre", "dev"}},
+		{name: "toml int", input: "+++\npriority = 4\naudience = \"sre\"\n+++\n", wantPriority: intPtr(4), wantAudience: []string{"sre"}},
+		{name: "json number", input: "{\n\"priority\": 5\n}\n", wantPriority: intPtr(5)},
+		{name: "numeric string", input: "---\npriority: \"6\"\n---\n", wantPriority: intPtr(6)},
+		{name: "fractional number ignored", input: "---\npriority: 1.5\n---\n"},
+		{name: "non-numeric ignored", input: "---\npriority: high\n---\n"},
+		{name: "not set", input: "---\ndescription: x\n---\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, _ := ParseFrontmatter([]byte(tt.input))
+			assert.Equal(t, tt.wantPriority, fm.Priority)
+			assert.Equal(t, tt.wantAudience, fm.Audience)
+		})
+	}
+}
+
+func intPtr(v int) *int { return &v }
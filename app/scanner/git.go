@@ -0,0 +1,272 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/gitrepo"
+)
+
+// GitSource defines markdown docs read from a ref of a local git repository, without checking it out
+type GitSource struct {
+	Name   string // source name used in file names, e.g. "release" for "release@v1.2:guide.md"
+	Repo   string // path to working tree or bare repository
+	Ref    string // branch, tag or commit
+	Subdir string // optional directory inside the repository, empty for the whole tree
+}
+
+// ParseGitSource parses git source definition in "[name=]repo@ref[:subdir]" form.
+// name defaults to the base name of the repository path without .git suffix.
+func ParseGitSource(s string) (GitSource, error) {
+	var g GitSource
+	spec := strings.TrimSpace(s)
+	if name, rest, ok := strings.Cut(spec, "="); ok {
+		g.Name, spec = strings.TrimSpace(name), rest
+	}
+
+	at := strings.LastIndex(spec, "@")
+	if at <= 0 || at == len(spec)-1 {
+		return GitSource{}, fmt.Errorf("invalid git source %q, expected [name=]repo@ref[:subdir]", s)
+	}
+	g.Repo = spec[:at]
+	g.Ref, g.Subdir, _ = strings.Cut(spec[at+1:], ":")
+	g.Subdir = strings.Trim(path.Clean("/"+filepath.ToSlash(g.Subdir)), "/")
+
+	if g.Name == "" {
+		g.Name = strings.TrimSuffix(filepath.Base(filepath.Clean(g.Repo)), ".git")
+	}
+	if g.Ref == "" || g.Name == "" || g.Name == "." {
+		return GitSource{}, fmt.Errorf("invalid git source %q, expected [name=]repo@ref[:subdir]", s)
+	}
+	if strings.ContainsAny(g.Name, ":@/") {
+		return GitSource{}, fmt.Errorf("invalid git source name %q, must not contain ':', '@' or '/'", g.Name)
+	}
+	return g, nil
+}
+
+// Prefix returns the source part of file names, "name@ref"
+func (g GitSource) Prefix() string {
+	return g.Name + "@" + g.Ref
+}
+
+// GitSources returns configured git sources
+func (s *Scanner) GitSources() []GitSource {
+	return s.gitSources
+}
+
+// GitRefsChanged checks if any git source ref points to a different commit than on the last scan.
+// sources never scanned count as changed, a ref failing to resolve is compared as zero hash.
+func (s *Scanner) GitRefsChanged() bool {
+	for _, g := range s.gitSources {
+		head, _ := s.resolveGitRef(g)
+		s.mu.Lock()
+		last, scanned := s.gitHeads[g.Prefix()]
+		s.mu.Unlock()
+		if !scanned || head != last {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadGitFile reads a markdown file from the git source with the given "name@ref" prefix.
+// the path is validated the same way as for directory sources and .md extension is added if missing.
+func (s *Scanner) ReadGitFile(prefix, userPath string, maxSize int64) ([]byte, error) {
+	g, ok := s.gitSource(prefix)
+	if !ok {
+		return nil, fmt.Errorf("unknown git source: %s", prefix)
+	}
+
+	cleanPath, err := CleanUserPath(userPath)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := s.resolveGitRef(g)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := s.gitRepo(g)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := repo.ReadFileAt(head, path.Join(g.Subdir, filepath.ToSlash(cleanPath)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, cleanPath)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file too large: %d bytes (max %d)", len(data), maxSize)
+	}
+	return data, nil
+}
+
+// scanGitSources scans all git sources, a source which can't be read is reported and skipped
+func (s *Scanner) scanGitSources(ctx context.Context) ([]FileInfo, error) {
+	var results []FileInfo
+	for _, g := range s.gitSources {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+		default:
+		}
+
+		files, head, err := s.scanGit(ctx, g)
+		s.mu.Lock()
+		s.gitHeads[g.Prefix()] = head
+		s.mu.Unlock()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+			}
+			slog.Warn("skipping git source", "source", g.Prefix(), "repo", g.Repo, "error", err)
+			continue
+		}
+		results = append(results, files...)
+	}
+	return results, nil
+}
+
+// scanGit walks the tree of the resolved ref and collects markdown files with their metadata.
+// returns the commit the ref pointed to, even if the walk failed.
+func (s *Scanner) scanGit(ctx context.Context, g GitSource) ([]FileInfo, gitrepo.Hash, error) {
+	head, err := s.resolveGitRef(g)
+	if err != nil {
+		return nil, head, err
+	}
+	repo, err := s.gitRepo(g)
+	if err != nil {
+		return nil, head, err
+	}
+	commit, err := repo.ReadCommit(head)
+	if err != nil {
+		return nil, head, err // nolint:wrapcheck // gitrepo error is descriptive
+	}
+	root, err := repo.FindEntry(commit.Tree, g.Subdir)
+	if err != nil {
+		return nil, head, fmt.Errorf("subdir %q: %w", g.Subdir, err)
+	}
+	if !root.IsDir() {
+		return nil, head, fmt.Errorf("subdir %q is not a directory", g.Subdir)
+	}
+
+	var results []FileInfo
+	err = s.walkGitTree(ctx, repo, g, root.Hash, "", Frontmatter{}, &results)
+	return results, head, err
+}
+
+// walkGitTree collects markdown files of the tree, applying the same hidden and excluded
+// directory rules as project docs. directory metadata cascades the same way as on disk.
+func (s *Scanner) walkGitTree(ctx context.Context, repo *gitrepo.Repo, g GitSource, tree gitrepo.Hash,
+	relDir string, parentMeta Frontmatter, results *[]FileInfo) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+	default:
+	}
+
+	entries, err := repo.ReadTree(tree)
+	if err != nil {
+		return err // nolint:wrapcheck // gitrepo error is descriptive
+	}
+	dirMeta := mergeMeta(parentMeta, s.gitDirMeta(repo, g, relDir, entries))
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, ".") {
+			continue
+		}
+		relPath := path.Join(relDir, e.Name)
+
+		if e.IsDir() {
+			if s.shouldExcludeDir(e.Name) {
+				continue
+			}
+			if err := s.walkGitTree(ctx, repo, g, e.Hash, relPath, dirMeta, results); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !e.IsFile() || !strings.HasSuffix(e.Name, ".md") {
+			continue
+		}
+		data, err := repo.ReadBlob(e.Hash)
+		if err != nil {
+			slog.Debug("skipping git file, cannot read", "source", g.Prefix(), "path", relPath, "error", err)
+			continue
+		}
+		filename := g.Prefix() + ":" + relPath
+		fm := mergeMeta(dirMeta, parseHeadFrontmatter(filename, data))
+		*results = append(*results, fileInfoWithMeta(FileInfo{
+			Name:       e.Name,
+			Filename:   filename,
+			Normalized: strings.ToLower(e.Name),
+			Source:     SourceGit,
+			Size:       int64(len(data)),
+		}, fm))
+	}
+	return nil
+}
+
+// gitDirMeta reads directory metadata from _index.md and _meta.yml blobs of the tree
+func (s *Scanner) gitDirMeta(repo *gitrepo.Repo, g GitSource, relDir string, entries []gitrepo.TreeEntry) Frontmatter {
+	var index Frontmatter
+	var meta []byte
+	for _, e := range entries {
+		if !e.IsFile() || (e.Name != IndexFileName && e.Name != MetaFileName) {
+			continue
+		}
+		data, err := repo.ReadBlob(e.Hash)
+		if err != nil {
+			slog.Debug("can't read git directory metadata", "source", g.Prefix(), "path", path.Join(relDir, e.Name), "error", err)
+			continue
+		}
+		if e.Name == IndexFileName {
+			index = parseHeadFrontmatter(g.Prefix()+":"+path.Join(relDir, e.Name), data)
+			continue
+		}
+		meta = data
+	}
+	if meta == nil {
+		return index
+	}
+	return mergeMeta(index, parseMetaFile(g.Prefix()+":"+path.Join(relDir, MetaFileName), meta))
+}
+
+// gitSource finds git source by "name@ref" prefix
+func (s *Scanner) gitSource(prefix string) (GitSource, bool) {
+	for _, g := range s.gitSources {
+		if g.Prefix() == prefix {
+			return g, true
+		}
+	}
+	return GitSource{}, false
+}
+
+// gitRepo returns opened repository of the source, opening it on first use
+func (s *Scanner) gitRepo(g GitSource) (*gitrepo.Repo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if repo, ok := s.gitRepos[g.Repo]; ok {
+		return repo, nil
+	}
+	repo, err := gitrepo.Open(g.Repo)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // gitrepo error is descriptive
+	}
+	s.gitRepos[g.Repo] = repo
+	return repo, nil
+}
+
+// resolveGitRef resolves the source ref to the commit it currently points to
+func (s *Scanner) resolveGitRef(g GitSource) (gitrepo.Hash, error) {
+	repo, err := s.gitRepo(g)
+	if err != nil {
+		return gitrepo.Hash{}, err
+	}
+	return repo.ResolveRevision(g.Ref) // nolint:wrapcheck // gitrepo error is descriptive
+}
@@ -0,0 +1,275 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runGit runs git command in dir with a fixed identity
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+	return strings.TrimSpace(string(out))
+}
+
+// writeFiles creates files with content under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+// makeGitDocsRepo creates repository with docs committed to main and a different version on release branch
+func makeGitDocsRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeFiles(t, dir, map[string]string{
+		"README.md":               "# Readme\n",
+		"docs/guide.md":           "---\ndescription: release guide\ntags: [guide]\n---\n# Guide v1\n",
+		"docs/api/_meta.yml":      "tags: [api]\npriority: 5\n",
+		"docs/api/endpoints.md":   "# Endpoints\n",
+		"docs/plans/draft.md":     "# Draft\n",
+		"docs/.hidden/secret.md":  "# Secret\n",
+		"docs/notes.txt":          "not markdown\n",
+		"docs/broken/readme.md":   "---\ntags: [oops\n---\n# Broken\n",
+		"docs/nested/deep/doc.md": "# Deep\n",
+	})
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "docs v1")
+	runGit(t, dir, "branch", "release")
+
+	writeFiles(t, dir, map[string]string{"docs/guide.md": "# Guide v2\n", "docs/new.md": "# New\n"})
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "docs v2")
+	return dir
+}
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    GitSource
+		wantErr string
+	}{
+		{name: "full", spec: "rel=/repos/app@release:docs",
+			want: GitSource{Name: "rel", Repo: "/repos/app", Ref: "release", Subdir: "docs"}},
+		{name: "default name", spec: "/repos/app@v1.0",
+			want: GitSource{Name: "app", Repo: "/repos/app", Ref: "v1.0"}},
+		{name: "bare repo name", spec: "../clones/lib.git@main:docs/guides/",
+			want: GitSource{Name: "lib", Repo: "../clones/lib.git", Ref: "main", Subdir: "docs/guides"}},
+		{name: "ref with slashes", spec: "app=/repos/app@release/1.x",
+			want: GitSource{Name: "app", Repo: "/repos/app", Ref: "release/1.x"}},
+		{name: "subdir traversal is cleaned", spec: "app=/repos/app@main:../../etc",
+			want: GitSource{Name: "app", Repo: "/repos/app", Ref: "main", Subdir: "etc"}},
+		{name: "no ref", spec: "/repos/app", wantErr: "expected [name=]repo@ref[:subdir]"},
+		{name: "empty ref", spec: "/repos/app@", wantErr: "expected [name=]repo@ref[:subdir]"},
+		{name: "empty ref with subdir", spec: "/repos/app@:docs", wantErr: "expected [name=]repo@ref[:subdir]"},
+		{name: "no repo", spec: "name=@main", wantErr: "expected [name=]repo@ref[:subdir]"},
+		{name: "invalid name", spec: "a:b=/repos/app@main", wantErr: "must not contain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGitSource(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.Equal(t, "rel@release", GitSource{Name: "rel", Ref: "release"}.Prefix())
+}
+
+func TestScanner_ScanGitSource(t *testing.T) {
+	repoDir := makeGitDocsRepo(t)
+
+	sc := NewScanner(Params{
+		MaxFileSize: 1024 * 1024,
+		ExcludeDirs: []string{"plans"},
+		GitSources: []GitSource{
+			{Name: "rel", Repo: repoDir, Ref: "release", Subdir: "docs"},
+			{Name: "app", Repo: repoDir, Ref: "main"},
+		},
+	})
+	defer sc.Close()
+
+	files, err := sc.Scan(context.Background())
+	require.NoError(t, err)
+
+	byName := map[string]FileInfo{}
+	for _, f := range files {
+		assert.Equal(t, SourceGit, f.Source)
+		assert.Empty(t, f.Path, "git files have no path on disk")
+		byName[f.Filename] = f
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{
+		"rel@release:api/endpoints.md", "rel@release:broken/readme.md", "rel@release:guide.md", "rel@release:nested/deep/doc.md",
+		"app@main:README.md", "app@main:docs/api/endpoints.md", "app@main:docs/broken/readme.md", "app@main:docs/guide.md",
+		"app@main:docs/nested/deep/doc.md", "app@main:docs/new.md",
+	}, names)
+
+	guide := byName["rel@release:guide.md"]
+	assert.Equal(t, "guide.md", guide.Name)
+	assert.Equal(t, "release guide", guide.Description)
+	assert.Equal(t, []string{"guide"}, guide.Tags)
+	assert.Equal(t, int64(len("---\ndescription: release guide\ntags: [guide]\n---\n# Guide v1\n")), guide.Size)
+	assert.Empty(t, byName["app@main:docs/guide.md"].Description, "main has guide without frontmatter")
+
+	endpoints := byName["rel@release:api/endpoints.md"]
+	assert.Equal(t, []string{"api"}, endpoints.Tags, "tags inherited from _meta.yml blob")
+	assert.Equal(t, 5, endpoints.Priority)
+
+	assert.Empty(t, byName["rel@release:broken/readme.md"].Tags, "malformed frontmatter results in empty metadata")
+}
+
+func TestScanner_ScanGitSourceErrors(t *testing.T) {
+	repoDir := makeGitDocsRepo(t)
+
+	sc := NewScanner(Params{
+		MaxFileSize: 1024 * 1024,
+		GitSources: []GitSource{
+			{Name: "missing-repo", Repo: filepath.Join(t.TempDir(), "nope"), Ref: "main"},
+			{Name: "missing-ref", Repo: repoDir, Ref: "nonexistent"},
+			{Name: "missing-dir", Repo: repoDir, Ref: "main", Subdir: "nope"},
+			{Name: "file-dir", Repo: repoDir, Ref: "main", Subdir: "README.md"},
+			{Name: "ok", Repo: repoDir, Ref: "release", Subdir: "docs/api"},
+		},
+	})
+	defer sc.Close()
+
+	files, err := sc.Scan(context.Background())
+	require.NoError(t, err, "broken git sources are skipped")
+	require.Len(t, files, 1)
+	assert.Equal(t, "ok@release:endpoints.md", files[0].Filename)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sc.scanGitSources(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestScanner_ReadGitFile(t *testing.T) {
+	repoDir := makeGitDocsRepo(t)
+
+	sc := NewScanner(Params{GitSources: []GitSource{
+		{Name: "rel", Repo: repoDir, Ref: "release", Subdir: "docs"},
+		{Name: "app", Repo: repoDir, Ref: "main"},
+	}})
+	defer sc.Close()
+
+	tests := []struct {
+		name    string
+		prefix  string
+		path    string
+		maxSize int64
+		want    string
+		wantErr string
+	}{
+		{name: "file in subdir source", prefix: "rel@release", path: "guide.md", maxSize: 1024,
+			want: "---\ndescription: release guide\ntags: [guide]\n---\n# Guide v1\n"},
+		{name: "extension added", prefix: "app@main", path: "docs/guide", maxSize: 1024, want: "# Guide v2\n"},
+		{name: "nested", prefix: "app@main", path: "docs/nested/deep/doc.md", maxSize: 1024, want: "# Deep\n"},
+		{name: "unknown source", prefix: "other@main", path: "guide.md", maxSize: 1024, wantErr: "unknown git source"},
+		{name: "missing file", prefix: "rel@release", path: "new.md", maxSize: 1024, wantErr: "file not found"},
+		{name: "traversal", prefix: "rel@release", path: "../README.md", maxSize: 1024, wantErr: "path traversal not allowed"},
+		{name: "absolute", prefix: "rel@release", path: "/docs/guide.md", maxSize: 1024, wantErr: "absolute paths not allowed"},
+		{name: "directory", prefix: "app@main", path: "docs/api.md", maxSize: 1024, wantErr: "file not found"},
+		{name: "too large", prefix: "app@main", path: "docs/guide.md", maxSize: 5, wantErr: "file too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := sc.ReadGitFile(tt.prefix, tt.path, tt.maxSize)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestScanner_GitRefsChanged(t *testing.T) {
+	repoDir := makeGitDocsRepo(t)
+
+	sc := NewScanner(Params{GitSources: []GitSource{{Name: "rel", Repo: repoDir, Ref: "release"}}})
+	defer sc.Close()
+	assert.True(t, sc.GitRefsChanged(), "not scanned yet")
+
+	_, err := sc.Scan(context.Background())
+	require.NoError(t, err)
+	assert.False(t, sc.GitRefsChanged())
+
+	runGit(t, repoDir, "branch", "-f", "release", "main")
+	assert.True(t, sc.GitRefsChanged(), "release moved to main")
+
+	_, err = sc.Scan(context.Background())
+	require.NoError(t, err)
+	assert.False(t, sc.GitRefsChanged())
+
+	assert.False(t, NewScanner(Params{}).GitRefsChanged(), "no git sources")
+}
+
+func TestCachedScanner_GitRefMoved(t *testing.T) {
+	repoDir := makeGitDocsRepo(t)
+
+	sc := NewScanner(Params{MaxFileSize: 1024 * 1024, GitSources: []GitSource{{Name: "rel", Repo: repoDir, Ref: "release"}}})
+	cs, err := NewCachedScanner(sc, time.Hour)
+	require.NoError(t, err)
+	defer cs.Close()
+
+	hasNew := func(files []FileInfo) bool {
+		for _, f := range files {
+			if f.Filename == "rel@release:docs/new.md" {
+				return true
+			}
+		}
+		return false
+	}
+
+	files, err := cs.Scan(context.Background())
+	require.NoError(t, err)
+	assert.False(t, hasNew(files))
+
+	// moving the ref in the repository invalidates cached file list
+	runGit(t, repoDir, "update-ref", "refs/heads/release", "main")
+	files, err = cs.Scan(context.Background())
+	require.NoError(t, err)
+	assert.True(t, hasNew(files))
+
+	data, err := cs.ReadGitFile("rel@release", "docs/new.md", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "# New\n", string(data))
+}
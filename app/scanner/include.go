@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/markdown"
+)
+
+// MaxIncludeDepth is the maximum nesting level of include directives
+const MaxIncludeDepth = 5
+
+// includeRe matches include directives, <!-- include: path --> and {{< include "path" >}}
+var includeRe = regexp.MustCompile(`<!--\s*include:\s*(\S+?)\s*-->|\{\{<\s*include\s+"([^"]+)"\s*>\}\}`)
+
+// IncludeResolver resolves include target of the doc with the given name (with source prefix)
+// to the name and absolute path of the included doc
+type IncludeResolver func(ctx context.Context, from, target string) (name, path string, err error)
+
+// Expansion is a doc with include directives replaced by contents of included docs
+type Expansion struct {
+	Content    []byte
+	Includes   []string // names of expanded docs, in order of expansion
+	Unresolved []string // directives left as is, "target: reason"
+	Deps       []string // absolute paths of expanded docs, the expansion is stale if any of them changes
+}
+
+// ExpandIncludes replaces include directives of the doc content, recursively up to MaxIncludeDepth.
+// directives inside fenced code blocks are ignored, directives which can't be expanded (not found,
+// cycle, depth or size limit) are kept and reported in Unresolved. included docs are stripped of
+// frontmatter and the expanded content is limited to maxSize bytes.
+func ExpandIncludes(ctx context.Context, name, path string, content []byte, resolve IncludeResolver, maxSize int64) (*Expansion, error) {
+	e := &includeExpander{resolve: resolve, maxSize: maxSize, size: int64(len(content)), res: &Expansion{}}
+	out, err := e.expand(ctx, name, content, []string{path})
+	if err != nil {
+		return nil, err
+	}
+	e.res.Content = out
+	return e.res, nil
+}
+
+// includeExpander keeps state of a single expansion
+type includeExpander struct {
+	resolve IncludeResolver
+	maxSize int64
+	size    int64 // current size of expanded content
+	res     *Expansion
+}
+
+// expand replaces directives in content of the doc, stack holds paths of docs being expanded
+func (e *includeExpander) expand(ctx context.Context, name string, content []byte, stack []string) ([]byte, error) {
+	var sb strings.Builder
+	var fences markdown.FenceTracker
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if fences.Inside(line) {
+			sb.WriteString(line)
+			continue
+		}
+
+		last := 0
+		for _, m := range includeRe.FindAllStringSubmatchIndex(line, -1) {
+			sb.WriteString(line[last:m[0]])
+			last = m[1]
+			var target string
+			if m[2] >= 0 {
+				target = line[m[2]:m[3]] // html comment form
+			} else {
+				target = line[m[4]:m[5]] // shortcode form
+			}
+			included, err := e.include(ctx, name, target, stack)
+			if err != nil {
+				return nil, err
+			}
+			if included == nil {
+				sb.WriteString(line[m[0]:m[1]])
+				continue
+			}
+			// directive usually takes the whole line, avoid doubled line break
+			sb.WriteString(strings.TrimSuffix(string(included), "\n"))
+		}
+		sb.WriteString(line[last:])
+	}
+	return []byte(sb.String()), nil
+}
+
+// include returns expanded content of the included doc, or nil if the directive can't be expanded
+func (e *includeExpander) include(ctx context.Context, from, target string, stack []string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err // nolint:wrapcheck // context errors should be returned as-is
+	}
+	unresolved := func(format string, args ...any) ([]byte, error) {
+		e.res.Unresolved = append(e.res.Unresolved, target+": "+fmt.Sprintf(format, args...))
+		return nil, nil
+	}
+
+	if len(stack) > MaxIncludeDepth {
+		return unresolved("include depth limit %d reached", MaxIncludeDepth)
+	}
+	name, path, err := e.resolve(ctx, from, target)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+		}
+		return unresolved("%v", err)
+	}
+	for _, p := range stack {
+		if p == path {
+			return unresolved("include cycle")
+		}
+	}
+
+	// #nosec G304 - path is validated by the resolver
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return unresolved("%v", err)
+	}
+	_, data = ParseFrontmatter(data)
+	if e.size+int64(len(data)) > e.maxSize {
+		return unresolved("expanded doc exceeds max size %d bytes", e.maxSize)
+	}
+	e.size += int64(len(data))
+
+	e.res.Includes = append(e.res.Includes, name)
+	e.res.Deps = append(e.res.Deps, path)
+	return e.expand(ctx, name, data, append(stack[:len(stack):len(stack)], path))
+}
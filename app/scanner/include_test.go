@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dirResolver resolves include targets relative to the including doc inside baseDir, names have "docs:" prefix
+func dirResolver(baseDir string) IncludeResolver {
+	return func(_ context.Context, from, target string) (name, p string, err error) {
+		rel := path.Join(path.Dir(strings.TrimPrefix(from, "docs:")), target)
+		if p, err = SafeResolvePath(baseDir, rel, 1024); err != nil {
+			return "", "", err
+		}
+		return "docs:" + rel, p, nil
+	}
+}
+
+func TestExpandIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"shared/conventions.md": "---\ntags: [shared]\n---\n## Conventions\n<!-- include: setup.md -->\n",
+		"shared/setup.md":       "## Local setup\nrun make\n",
+		"cycle/a.md":            "A\n<!-- include: b.md -->\n",
+		"cycle/b.md":            "B\n<!-- include: a.md -->\n",
+		"deep/1.md":             "1\n<!-- include: 2.md -->\n",
+		"deep/2.md":             "2\n<!-- include: 3.md -->\n",
+		"deep/3.md":             "3\n<!-- include: 4.md -->\n",
+		"deep/4.md":             "4\n<!-- include: 5.md -->\n",
+		"deep/5.md":             "5\n<!-- include: 6.md -->\n",
+		"deep/6.md":             "6\n<!-- include: 7.md -->\n",
+		"deep/7.md":             "7\n",
+		"big.md":                strings.Repeat("x", 900),
+	})
+
+	expand := func(name, content string) *Expansion {
+		t.Helper()
+		p := filepath.Join(dir, strings.TrimPrefix(name, "docs:"))
+		exp, err := ExpandIncludes(context.Background(), name, p, []byte(content), dirResolver(dir), 1024)
+		require.NoError(t, err)
+		return exp
+	}
+
+	t.Run("nested includes in both forms", func(t *testing.T) {
+		exp := expand("docs:guides/setup.md", "# Guide\n<!-- include: ../shared/conventions.md -->\n"+
+			"text {{< include \"../shared/setup.md\" >}} end\n")
+		assert.Equal(t, "# Guide\n## Conventions\n## Local setup\nrun make\n"+
+			"text ## Local setup\nrun make end\n", string(exp.Content))
+		assert.Equal(t, []string{"docs:shared/conventions.md", "docs:shared/setup.md", "docs:shared/setup.md"}, exp.Includes)
+		assert.Equal(t, []string{filepath.Join(dir, "shared", "conventions.md"), filepath.Join(dir, "shared", "setup.md"),
+			filepath.Join(dir, "shared", "setup.md")}, exp.Deps)
+		assert.Empty(t, exp.Unresolved)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		exp := expand("docs:cycle/a.md", "A\n<!-- include: b.md -->\n")
+		assert.Equal(t, "A\nB\n<!-- include: a.md -->\n", string(exp.Content))
+		assert.Equal(t, []string{"a.md: include cycle"}, exp.Unresolved)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		exp := expand("docs:deep/0.md", "0\n<!-- include: 1.md -->\n")
+		assert.Equal(t, "0\n1\n2\n3\n4\n5\n<!-- include: 6.md -->\n", string(exp.Content))
+		assert.Equal(t, []string{fmt.Sprintf("6.md: include depth limit %d reached", MaxIncludeDepth)}, exp.Unresolved)
+	})
+
+	t.Run("missing, escaping and oversize", func(t *testing.T) {
+		exp := expand("docs:intro.md", "<!-- include: missing.md -->\n<!-- include: ../outside.md -->\n"+
+			strings.Repeat("y", 200)+"\n<!-- include: big.md -->\n")
+		assert.Equal(t, "<!-- include: missing.md -->\n<!-- include: ../outside.md -->\n"+
+			strings.Repeat("y", 200)+"\n<!-- include: big.md -->\n", string(exp.Content))
+		require.Len(t, exp.Unresolved, 3)
+		assert.Contains(t, exp.Unresolved[0], "missing.md: file not found")
+		assert.Contains(t, exp.Unresolved[1], "../outside.md: path traversal not allowed")
+		assert.Equal(t, "big.md: expanded doc exceeds max size 1024 bytes", exp.Unresolved[2])
+		assert.Empty(t, exp.Includes)
+	})
+
+	t.Run("fenced code is not expanded", func(t *testing.T) {
+		content := "```md\n<!-- include: shared/setup.md -->\n```\n"
+		exp := expand("docs:intro.md", content)
+		assert.Equal(t, content, string(exp.Content))
+		assert.Empty(t, exp.Includes)
+
+		// nested shorter fence and inline code don't toggle the block
+		content = "````md\n```\n<!-- include: shared/setup.md -->\n````\n"
+		exp = expand("docs:intro.md", content)
+		assert.Equal(t, content, string(exp.Content))
+		assert.Empty(t, exp.Includes)
+		exp = expand("docs:intro.md", "```echo `date` ```\n<!-- include: shared/setup.md -->\n")
+		assert.Equal(t, []string{"docs:shared/setup.md"}, exp.Includes)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := ExpandIncludes(ctx, "docs:intro.md", filepath.Join(dir, "intro.md"),
+			[]byte("<!-- include: shared/setup.md -->"), dirResolver(dir), 1024)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("no directives", func(t *testing.T) {
+		exp := expand("docs:intro.md", "plain\n")
+		assert.Equal(t, "plain\n", string(exp.Content))
+		assert.Empty(t, exp.Includes)
+		assert.Empty(t, exp.Unresolved)
+	})
+}
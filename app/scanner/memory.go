@@ -0,0 +1,178 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// memoryGlobalDir is the path prefix of memory files from the global (user level) directory
+	memoryGlobalDir = "global"
+	// memoryUpDir is the path prefix of memory files from directories above the project root, "up/1" is the parent
+	memoryUpDir = "up"
+)
+
+const (
+	// maxMemoryDepth is the deepest level below the project root memory files are discovered at
+	maxMemoryDepth = 4
+)
+
+// memorySkipDirs are directories of dependencies and build output, never searched for memory files
+var memorySkipDirs = []string{"node_modules", "vendor", "dist", "build", "target", "__pycache__"}
+
+// MemoryFileNames are names of agent instruction files discovered as memory
+var MemoryFileNames = []string{"CLAUDE.md", "CLAUDE.local.md", "AGENTS.md"}
+
+// isMemoryFile checks if file name is one of MemoryFileNames
+func isMemoryFile(name string) bool {
+	for _, n := range MemoryFileNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// scanMemory discovers memory files: the global ones, ones in directories above the project root
+// and ones in the project root and its subdirectories up to maxMemoryDepth levels down, skipping hidden,
+// excluded and dependency or build output directories.
+func (s *Scanner) scanMemory(ctx context.Context) ([]FileInfo, error) {
+	var results []FileInfo
+
+	if s.globalMemoryDir != "" {
+		results = append(results, memoryFilesIn(s.globalMemoryDir, memoryGlobalDir)...)
+	}
+
+	if s.memoryRootDir == "" {
+		return results, nil
+	}
+
+	// walk upward, from the parent of the project root to the filesystem root
+	dir := filepath.Clean(s.memoryRootDir)
+	for level := 1; ; level++ {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		results = append(results, memoryFilesIn(dir, path.Join(memoryUpDir, strconv.Itoa(level)))...)
+	}
+
+	// walk downward through the project tree
+	err := filepath.WalkDir(s.memoryRootDir, func(p string, d fs.DirEntry, err error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err != nil {
+			slog.Debug("skipping path due to walk error", "path", p, "error", err)
+			return nil
+		}
+		if d.IsDir() {
+			if p != s.memoryRootDir && s.skipMemoryDir(p, d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !isMemoryFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // skip files we can't stat
+		}
+		rel, err := filepath.Rel(s.memoryRootDir, p)
+		if err != nil {
+			return nil
+		}
+		results = append(results, newMemoryFileInfo(filepath.ToSlash(rel), p, info.Size()))
+		return nil
+	})
+	if err != nil {
+		return nil, err // nolint:wrapcheck // filepath.WalkDir error is descriptive as-is
+	}
+	return results, nil
+}
+
+// skipMemoryDir checks if directory below the project root is not searched for memory files: hidden, excluded,
+// of dependencies or build output, or deeper than maxMemoryDepth
+func (s *Scanner) skipMemoryDir(p, name string) bool {
+	if strings.HasPrefix(name, ".") || s.shouldExcludeDir(name) || slices.Contains(memorySkipDirs, name) {
+		return true
+	}
+	rel, err := filepath.Rel(s.memoryRootDir, p)
+	return err != nil || strings.Count(filepath.ToSlash(rel), "/")+1 > maxMemoryDepth
+}
+
+// memoryFilesIn returns memory files located directly in dir, named with the given prefix
+func memoryFilesIn(dir, prefix string) []FileInfo {
+	var results []FileInfo
+	for _, name := range MemoryFileNames {
+		p := filepath.Join(dir, name)
+		st, err := os.Stat(p)
+		if err != nil || st.IsDir() {
+			continue
+		}
+		results = append(results, newMemoryFileInfo(path.Join(prefix, name), p, st.Size()))
+	}
+	return results
+}
+
+// newMemoryFileInfo makes FileInfo for a memory file with the given name relative to the memory source
+func newMemoryFileInfo(name, p string, size int64) FileInfo {
+	return fileInfoWithMeta(FileInfo{
+		Name:       filepath.Base(p),
+		Filename:   string(SourceMemory) + ":" + name,
+		Normalized: strings.ToLower(filepath.Base(p)),
+		Source:     SourceMemory,
+		Path:       p,
+		Size:       size,
+	}, extractFrontmatter(p))
+}
+
+// ResolveMemoryPath resolves memory file name, as listed by the scanner without the source prefix,
+// to its absolute path. "global/NAME" is a global file, "up/N/NAME" is located N directories above
+// the project root and anything else is relative to the project root. only memory file names are allowed.
+func (s *Scanner) ResolveMemoryPath(name string, maxSize int64) (string, error) {
+	cleanName, err := CleanUserPath(name)
+	if err != nil {
+		return "", err
+	}
+	cleanName = filepath.ToSlash(cleanName)
+	if !isMemoryFile(path.Base(cleanName)) {
+		return "", fmt.Errorf("not a memory file: %s", name)
+	}
+
+	dir, rel := s.memoryRootDir, cleanName
+	switch first, rest, _ := strings.Cut(cleanName, "/"); {
+	case first == memoryGlobalDir && !strings.Contains(rest, "/"):
+		dir, rel = s.globalMemoryDir, rest
+	case first == memoryUpDir && strings.Count(rest, "/") == 1:
+		levelStr, file, _ := strings.Cut(rest, "/")
+		level, convErr := strconv.Atoi(levelStr)
+		if convErr != nil || level < 1 || s.memoryRootDir == "" {
+			return "", fmt.Errorf("invalid memory path: %s", name)
+		}
+		dir, rel = filepath.Clean(s.memoryRootDir), file
+		for i := 0; i < level; i++ {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return "", fmt.Errorf("invalid memory path: %s", name)
+			}
+			dir = parent
+		}
+	}
+	if dir == "" {
+		return "", fmt.Errorf("memory source is not configured for %s", name)
+	}
+	return SafeResolvePath(dir, rel, maxSize)
+}
@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeMemoryLayout creates global dir, workspace with memory files above the project and the project itself
+func makeMemoryLayout(t *testing.T) (global, project string) {
+	t.Helper()
+	base := t.TempDir()
+	global = filepath.Join(base, "home", ".claude")
+	project = filepath.Join(base, "work", "project")
+	writeFiles(t, base, map[string]string{
+		"home/.claude/CLAUDE.md":                      "# Global",
+		"home/.claude/notes.md":                       "# not memory",
+		"work/AGENTS.md":                              "# Workspace agents",
+		"work/project/CLAUDE.md":                      "---\ndescription: project memory\n---\n# Project",
+		"work/project/CLAUDE.local.md":                "# Local",
+		"work/project/app/server/CLAUDE.md":           "# Server",
+		"work/project/app/server/handler.md":          "# not memory",
+		"work/project/.git/CLAUDE.md":                 "# hidden",
+		"work/project/plans/CLAUDE.md":                "# excluded",
+		"work/project/web/node_modules/pkg/CLAUDE.md": "# dependency",
+		"work/project/vendor/lib/AGENTS.md":           "# dependency",
+		"work/project/a/b/c/d/CLAUDE.md":              "# deepest",
+		"work/project/a/b/c/d/e/CLAUDE.md":            "# too deep",
+	})
+	return global, project
+}
+
+func TestScanner_ScanMemory(t *testing.T) {
+	global, project := makeMemoryLayout(t)
+
+	s := NewScanner(Params{MemoryRootDir: project, GlobalMemoryDir: global, ExcludeDirs: []string{"plans"}, MaxFileSize: 1024})
+	files, err := s.Scan(context.Background())
+	require.NoError(t, err)
+
+	byName := map[string]FileInfo{}
+	for _, f := range files {
+		assert.Equal(t, SourceMemory, f.Source)
+		byName[f.Filename] = f
+	}
+	// the temp dir can be below other directories with memory files, check only known ones
+	for name, path := range map[string]string{
+		"memory:global/CLAUDE.md":     filepath.Join(global, "CLAUDE.md"),
+		"memory:up/1/AGENTS.md":       filepath.Join(filepath.Dir(project), "AGENTS.md"),
+		"memory:CLAUDE.md":            filepath.Join(project, "CLAUDE.md"),
+		"memory:CLAUDE.local.md":      filepath.Join(project, "CLAUDE.local.md"),
+		"memory:app/server/CLAUDE.md": filepath.Join(project, "app", "server", "CLAUDE.md"),
+		"memory:a/b/c/d/CLAUDE.md":    filepath.Join(project, "a", "b", "c", "d", "CLAUDE.md"),
+	} {
+		require.Contains(t, byName, name)
+		assert.Equal(t, path, byName[name].Path)
+	}
+	assert.Equal(t, "project memory", byName["memory:CLAUDE.md"].Description)
+	assert.Equal(t, "claude.md", byName["memory:CLAUDE.md"].Normalized)
+	assert.NotContains(t, byName, "memory:.git/CLAUDE.md")
+	assert.NotContains(t, byName, "memory:plans/CLAUDE.md")
+	assert.NotContains(t, byName, "memory:app/server/handler.md")
+	assert.NotContains(t, byName, "memory:web/node_modules/pkg/CLAUDE.md", "dependencies are skipped")
+	assert.NotContains(t, byName, "memory:vendor/lib/AGENTS.md", "dependencies are skipped")
+	assert.NotContains(t, byName, "memory:a/b/c/d/e/CLAUDE.md", "deeper than max depth")
+
+	t.Run("disabled", func(t *testing.T) {
+		files, err := NewScanner(Params{}).scanMemory(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+}
+
+func TestScanner_ResolveMemoryPath(t *testing.T) {
+	global, project := makeMemoryLayout(t)
+	s := NewScanner(Params{MemoryRootDir: project, GlobalMemoryDir: global})
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "CLAUDE.md", want: filepath.Join(project, "CLAUDE.md")},
+		{name: "app/server/CLAUDE.md", want: filepath.Join(project, "app", "server", "CLAUDE.md")},
+		{name: "global/CLAUDE.md", want: filepath.Join(global, "CLAUDE.md")},
+		{name: "up/1/AGENTS.md", want: filepath.Join(filepath.Dir(project), "AGENTS.md")},
+		{name: "up/1/CLAUDE.md", wantErr: "file not found"},
+		{name: "up/x/AGENTS.md", wantErr: "invalid memory path"},
+		{name: "up/0/AGENTS.md", wantErr: "invalid memory path"},
+		{name: "app/server/handler.md", wantErr: "not a memory file"},
+		{name: "../CLAUDE.md", wantErr: "path traversal"},
+		{name: "/etc/CLAUDE.md", wantErr: "absolute paths"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ResolveMemoryPath(tt.name, 1024)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := s.ResolveMemoryPath("CLAUDE.md", 5)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file too large")
+	})
+
+	t.Run("global not configured", func(t *testing.T) {
+		_, err := NewScanner(Params{MemoryRootDir: project}).ResolveMemoryPath("global/CLAUDE.md", 1024)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not configured")
+	})
+
+	require.NoError(t, os.Remove(filepath.Join(project, "CLAUDE.local.md")))
+	_, err := s.ResolveMemoryPath("CLAUDE.local.md", 1024)
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MetaFileName is the name of per-directory metadata file with defaults for all descendant docs
+	MetaFileName = "_meta.yml"
+	// IndexFileName is the name of section index doc, its frontmatter is used as directory metadata too
+	IndexFileName = "_index.md"
+)
+
+// loadDirMeta reads directory metadata from _index.md frontmatter and _meta.yml.
+// values from _meta.yml take precedence over _index.md. missing files result in empty metadata.
+func loadDirMeta(dir string) Frontmatter {
+	var meta Frontmatter
+
+	indexPath := filepath.Join(dir, IndexFileName)
+	if _, err := os.Stat(indexPath); err == nil {
+		meta = extractFrontmatter(indexPath)
+	}
+
+	metaPath := filepath.Join(dir, MetaFileName)
+	// #nosec G304 - path is from scanner, not user input
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("can't read directory metadata", "path", metaPath, "error", err)
+		}
+		return meta
+	}
+
+	return mergeMeta(meta, parseMetaFile(metaPath, data))
+}
+
+// parseMetaFile parses _meta.yml content, malformed content is reported and results in empty metadata
+func parseMetaFile(name string, data []byte) Frontmatter {
+	var fields map[string]any
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		slog.Warn("malformed directory metadata", "path", name, "error", err)
+		return Frontmatter{}
+	}
+	return frontmatterFromFields(fields)
+}
+
+// mergeMeta merges child metadata over parent. description, priority and audience from child
+// override parent ones if set, tags are combined with parent tags first and duplicates removed.
+func mergeMeta(parent, child Frontmatter) Frontmatter {
+	res := child
+	if res.Description == "" {
+		res.Description = parent.Description
+	}
+	if res.Priority == nil {
+		res.Priority = parent.Priority
+	}
+	if len(res.Audience) == 0 {
+		res.Audience = parent.Audience
+	}
+
+	if len(parent.Tags) > 0 {
+		seen := make(map[string]bool, len(parent.Tags)+len(child.Tags))
+		tags := make([]string, 0, len(parent.Tags)+len(child.Tags))
+		for _, list := range [][]string{parent.Tags, child.Tags} {
+			for _, tag := range list {
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+		res.Tags = tags
+	}
+	return res
+}
+
+// newFileInfo makes FileInfo for a markdown file, with its own frontmatter merged over directory metadata
+func newFileInfo(source Source, relPath, path string, size int64, dirMeta Frontmatter) FileInfo {
+	fm := mergeMeta(dirMeta, extractFrontmatter(path))
+	info := fileInfoWithMeta(FileInfo{
+		Name:       filepath.Base(path),
+		Filename:   string(source) + ":" + filepath.ToSlash(relPath),
+		Normalized: strings.ToLower(filepath.Base(path)),
+		Source:     source,
+		Path:       path,
+		Size:       size,
+	}, fm)
+	if source == SourceCommands {
+		info.Command = commandMetaFromFields(relPath, fm.Fields)
+	}
+	return info
+}
+
+// fileInfoWithMeta sets metadata fields of FileInfo from effective frontmatter
+func fileInfoWithMeta(info FileInfo, fm Frontmatter) FileInfo {
+	info.Description = fm.Description
+	info.Tags = fm.Tags
+	info.Audience = fm.Audience
+	if fm.Priority != nil {
+		info.Priority = *fm.Priority
+	}
+	return info
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMeta(t *testing.T) {
+	one, two := 1, 2
+
+	tests := []struct {
+		name   string
+		parent Frontmatter
+		child  Frontmatter
+		want   Frontmatter
+	}{
+		{
+			name:   "empty parent",
+			parent: Frontmatter{},
+			child:  Frontmatter{Description: "own", Tags: []string{"a"}, Priority: &one},
+			want:   Frontmatter{Description: "own", Tags: []string{"a"}, Priority: &one},
+		},
+		{
+			name:   "inherit everything",
+			parent: Frontmatter{Description: "dir", Tags: []string{"ops"}, Priority: &two, Audience: []string{"sre"}},
+			child:  Frontmatter{},
+			want:   Frontmatter{Description: "dir", Tags: []string{"ops"}, Priority: &two, Audience: []string{"sre"}},
+		},
+		{
+			name:   "child overrides scalars and merges tags",
+			parent: Frontmatter{Description: "dir", Tags: []string{"ops", "oncall"}, Priority: &two, Audience: []string{"sre"}},
+			child:  Frontmatter{Description: "own", Tags: []string{"oncall", "db"}, Priority: &one, Audience: []string{"dev"}},
+			want: Frontmatter{Description: "own", Tags: []string{"ops", "oncall", "db"}, Priority: &one,
+				Audience: []string{"dev"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mergeMeta(tt.parent, tt.child))
+		})
+	}
+}
+
+func TestLoadDirMeta(t *testing.T) {
+	t.Run("meta file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, MetaFileName),
+			[]byte("description: Runbooks\ntags: [ops, oncall]\npriority: 5\naudience: sre, oncall\n"), 0600))
+
+		meta := loadDirMeta(dir)
+		assert.Equal(t, "Runbooks", meta.Description)
+		assert.Equal(t, []string{"ops", "oncall"}, meta.Tags)
+		require.NotNil(t, meta.Priority)
+		assert.Equal(t, 5, *meta.Priority)
+		assert.Equal(t, []string{"sre", "oncall"}, meta.Audience)
+	})
+
+	t.Run("index frontmatter with meta file override", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, IndexFileName),
+			[]byte("+++\ndescription = \"From index\"\ntags = [\"hugo\"]\npriority = 1\n+++\n# Section"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, MetaFileName), []byte("priority: 3\ntags: [meta]\n"), 0600))
+
+		meta := loadDirMeta(dir)
+		assert.Equal(t, "From index", meta.Description)
+		assert.Equal(t, []string{"hugo", "meta"}, meta.Tags)
+		require.NotNil(t, meta.Priority)
+		assert.Equal(t, 3, *meta.Priority)
+	})
+
+	t.Run("malformed meta file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, MetaFileName), []byte("tags: [broken"), 0600))
+		assert.Equal(t, Frontmatter{}, loadDirMeta(dir))
+	})
+
+	t.Run("no metadata", func(t *testing.T) {
+		assert.Equal(t, Frontmatter{}, loadDirMeta(t.TempDir()))
+	})
+}
+
+func TestScanner_DirectoryMetaInheritance(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	runbooksDir := filepath.Join(docsDir, "runbooks")
+	dbDir := filepath.Join(runbooksDir, "db")
+	require.NoError(t, os.MkdirAll(dbDir, 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, MetaFileName), []byte("audience: [dev]\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooksDir, MetaFileName),
+		[]byte("description: Operational runbook\ntags: [ops, oncall]\npriority: 2\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dbDir, MetaFileName), []byte("tags: [db]\naudience: [dba]\n"), 0600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "intro.md"), []byte("# Intro"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooksDir, "restart.md"), []byte("# Restart"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dbDir, "failover.md"),
+		[]byte("---\ndescription: Database failover\ntags: [postgres]\npriority: 9\n---\n# Failover"), 0600))
+
+	sc := NewScanner(Params{ProjectDocsDir: docsDir, ProjectRootDir: docsDir, MaxFileSize: 1024 * 1024})
+	files, err := sc.Scan(context.Background())
+	require.NoError(t, err)
+
+	byName := map[string]FileInfo{}
+	for _, f := range files {
+		byName[f.Filename] = f
+	}
+
+	intro := byName["project-docs:intro.md"]
+	assert.Empty(t, intro.Description)
+	assert.Empty(t, intro.Tags)
+	assert.Equal(t, []string{"dev"}, intro.Audience)
+
+	restart := byName["project-docs:runbooks/restart.md"]
+	assert.Equal(t, "Operational runbook", restart.Description)
+	assert.Equal(t, []string{"ops", "oncall"}, restart.Tags)
+	assert.Equal(t, 2, restart.Priority)
+	assert.Equal(t, []string{"dev"}, restart.Audience)
+
+	failover := byName["project-docs:runbooks/db/failover.md"]
+	assert.Equal(t, "Database failover", failover.Description)
+	assert.Equal(t, []string{"ops", "oncall", "db", "postgres"}, failover.Tags)
+	assert.Equal(t, 9, failover.Priority)
+	assert.Equal(t, []string{"dba"}, failover.Audience)
+
+	// flat scan of project root applies metadata of that directory only
+	rootIntro := byName["project-root:intro.md"]
+	assert.Equal(t, []string{"dev"}, rootIntro.Audience)
+}
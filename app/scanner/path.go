@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,40 +10,29 @@ import (
 
 // FileInfo contains metadata about a documentation file
 type FileInfo struct {
-	Name        string   // original filename
-	Filename    string   // filename with source prefix (e.g., "commands:action/commit.md")
-	Normalized  string   // lowercase for matching
-	Source      Source   // source type
-	Path        string   // absolute path
-	Size        int64    // file size in bytes
-	Description string   // description from frontmatter (if present)
-	Tags        []string // tags from frontmatter (if present)
+	Name        string       // original filename
+	Filename    string       // filename with source prefix (e.g., "commands:action/commit.md")
+	Normalized  string       // lowercase for matching
+	Source      Source       // source type
+	Path        string       // absolute path, empty for files read from git sources
+	Size        int64        // file size in bytes
+	Description string       // description from frontmatter (if present), or inherited from directory metadata
+	Tags        []string     // tags from frontmatter merged with tags inherited from directory metadata
+	Priority    int          // priority from frontmatter or directory metadata, 0 if not set
+	Audience    []string     // audience from frontmatter or directory metadata
+	Resources   []string     // files bundled with a skill, relative to the skill directory
+	Command     *CommandMeta // slash command metadata, set for files of the commands source only
 }
 
+// ErrNotFound is returned when the requested doc doesn't exist
+var ErrNotFound = errors.New("file not found")
+
 // SafeResolvePath resolves a user-provided path relative to baseDir with security checks.
 // It prevents path traversal, validates file existence and size, and adds .md extension if missing.
 func SafeResolvePath(baseDir, userPath string, maxSize int64) (string, error) {
-	// reject empty path
-	if userPath == "" {
-		return "", fmt.Errorf("empty path provided")
-	}
-
-	// reject absolute paths
-	if filepath.IsAbs(userPath) {
-		return "", fmt.Errorf("absolute paths not allowed: %s", userPath)
-	}
-
-	// add .md extension if missing
-	if !strings.HasSuffix(userPath, ".md") {
-		userPath += ".md"
-	}
-
-	// clean the path to normalize it
-	userPath = filepath.Clean(userPath)
-
-	// check for path traversal attempts
-	if strings.Contains(userPath, "..") {
-		return "", fmt.Errorf("path traversal not allowed: %s", userPath)
+	userPath, err := CleanUserPath(userPath)
+	if err != nil {
+		return "", err
 	}
 
 	// resolve to absolute path
@@ -61,7 +51,7 @@ func SafeResolvePath(baseDir, userPath string, maxSize int64) (string, error) {
 	info, err := os.Stat(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("file not found: %s", userPath)
+			return "", fmt.Errorf("%w: %s", ErrNotFound, userPath)
 		}
 		return "", fmt.Errorf("failed to stat file: %w", err)
 	}
@@ -73,3 +63,31 @@ func SafeResolvePath(baseDir, userPath string, maxSize int64) (string, error) {
 
 	return absPath, nil
 }
+
+// CleanUserPath validates a user-provided relative path, adds .md extension if missing and normalizes it
+func CleanUserPath(userPath string) (string, error) {
+	// reject empty path
+	if userPath == "" {
+		return "", fmt.Errorf("empty path provided")
+	}
+
+	// reject absolute paths
+	if filepath.IsAbs(userPath) {
+		return "", fmt.Errorf("absolute paths not allowed: %s", userPath)
+	}
+
+	// add .md extension if missing
+	if !strings.HasSuffix(userPath, ".md") {
+		userPath += ".md"
+	}
+
+	// clean the path to normalize it
+	userPath = filepath.Clean(userPath)
+
+	// check for path traversal attempts
+	if strings.Contains(userPath, "..") {
+		return "", fmt.Errorf("path traversal not allowed: %s", userPath)
+	}
+
+	return userPath, nil
+}
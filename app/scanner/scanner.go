@@ -9,6 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/umputun/local-docs-mcp/app/gitrepo"
 )
 
 // Source represents documentation source type
@@ -21,34 +24,61 @@ const (
 	SourceProjectDocs Source = "project-docs"
 	// SourceProjectRoot represents root-level markdown files
 	SourceProjectRoot Source = "project-root"
+	// SourceGit represents markdown files read from a ref of a local git repository
+	SourceGit Source = "git"
+	// SourceSkills represents skills, SKILL.md files in subdirectories of ~/.claude/skills
+	SourceSkills Source = "skills"
+	// SourceMemory represents agent instruction files, CLAUDE.md and AGENTS.md, around the project
+	SourceMemory Source = "memory"
 )
 
+// FrontmatterWindow is the number of bytes read from the head of each file to extract frontmatter during scan
+const FrontmatterWindow = 2048
+
 // Params contains parameters for creating a scanner
 type Params struct {
-	CommandsDir    string
-	ProjectDocsDir string
-	ProjectRootDir string
-	MaxFileSize    int64
-	ExcludeDirs    []string
+	CommandsDir     string
+	ProjectDocsDir  string
+	ProjectRootDir  string
+	MaxFileSize     int64
+	ExcludeDirs     []string
+	GitSources      []GitSource
+	SkillsDir       string // directory with skills, empty disables skills
+	MemoryRootDir   string // project root to discover memory files from, empty disables project memory
+	GlobalMemoryDir string // directory with global memory files, e.g. ~/.claude
 }
 
 // Scanner discovers and indexes documentation files from multiple sources
 type Scanner struct {
-	commandsDir    string
-	projectDocsDir string
-	projectRootDir string
-	maxFileSize    int64
-	excludeDirs    []string
+	commandsDir     string
+	projectDocsDir  string
+	projectRootDir  string
+	maxFileSize     int64
+	excludeDirs     []string
+	gitSources      []GitSource
+	skillsDir       string
+	memoryRootDir   string
+	globalMemoryDir string
+
+	mu       sync.Mutex
+	gitRepos map[string]*gitrepo.Repo // opened repositories by path
+	gitHeads map[string]gitrepo.Hash  // commits of git sources on the last scan, by prefix
 }
 
 // NewScanner creates a new scanner instance
 func NewScanner(params Params) *Scanner {
 	return &Scanner{
-		commandsDir:    params.CommandsDir,
-		projectDocsDir: params.ProjectDocsDir,
-		projectRootDir: params.ProjectRootDir,
-		maxFileSize:    params.MaxFileSize,
-		excludeDirs:    params.ExcludeDirs,
+		commandsDir:     params.CommandsDir,
+		projectDocsDir:  params.ProjectDocsDir,
+		projectRootDir:  params.ProjectRootDir,
+		maxFileSize:     params.MaxFileSize,
+		excludeDirs:     params.ExcludeDirs,
+		gitSources:      params.GitSources,
+		skillsDir:       params.SkillsDir,
+		memoryRootDir:   params.MemoryRootDir,
+		globalMemoryDir: params.GlobalMemoryDir,
+		gitRepos:        map[string]*gitrepo.Repo{},
+		gitHeads:        map[string]gitrepo.Hash{},
 	}
 }
 
@@ -67,6 +97,16 @@ func (s *Scanner) ProjectRootDir() string {
 	return s.projectRootDir
 }
 
+// SkillsDir returns the skills directory path
+func (s *Scanner) SkillsDir() string {
+	return s.skillsDir
+}
+
+// MemoryDirs returns directories memory files are discovered in, the project root and the global memory dir
+func (s *Scanner) MemoryDirs() (root, global string) {
+	return s.memoryRootDir, s.globalMemoryDir
+}
+
 // Scan discovers all markdown files from all configured sources
 func (s *Scanner) Scan(ctx context.Context) ([]FileInfo, error) {
 	var results []FileInfo
@@ -125,6 +165,26 @@ func (s *Scanner) Scan(ctx context.Context) ([]FileInfo, error) {
 		results = append(results, rootFiles...)
 	}
 
+	// scan skills and memory files
+	skillFiles, err := s.scanSkills(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, skillFiles...)
+
+	memoryFiles, err := s.scanMemory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, memoryFiles...)
+
+	// scan git sources, files are read from the object database of the configured refs
+	gitFiles, err := s.scanGitSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, gitFiles...)
+
 	return results, nil
 }
 
@@ -153,6 +213,7 @@ func (s *Scanner) scanSource(ctx context.Context, source Source, dir, pattern st
 // scanRecursive performs recursive directory scanning for markdown files
 func (s *Scanner) scanRecursive(ctx context.Context, source Source, dir string) ([]FileInfo, error) {
 	var results []FileInfo
+	dirMetas := map[string]Frontmatter{} // effective metadata of each visited directory
 
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		// check context cancellation
@@ -180,8 +241,14 @@ func (s *Scanner) scanRecursive(ctx context.Context, source Source, dir string)
 			return fs.SkipDir
 		}
 
+		// directory metadata cascades from parent directories, walk visits parents first
+		if d.IsDir() {
+			dirMetas[path] = mergeMeta(dirMetas[filepath.Dir(path)], loadDirMeta(path))
+			return nil
+		}
+
 		// process only .md files
-		if !d.IsDir() && strings.HasSuffix(d.Name(), ".md") {
+		if strings.HasSuffix(d.Name(), ".md") {
 			info, err := d.Info()
 			if err != nil {
 				slog.Debug("skipping file, cannot stat", "path", path, "error", err)
@@ -194,20 +261,7 @@ func (s *Scanner) scanRecursive(ctx context.Context, source Source, dir string)
 				return nil
 			}
 
-			// extract frontmatter
-			description, tags := extractFrontmatter(path)
-
-			fileInfo := FileInfo{
-				Name:        filepath.Base(path),
-				Filename:    string(source) + ":" + filepath.ToSlash(relPath),
-				Normalized:  strings.ToLower(filepath.Base(path)),
-				Source:      source,
-				Path:        path,
-				Size:        info.Size(),
-				Description: description,
-				Tags:        tags,
-			}
-			results = append(results, fileInfo)
+			results = append(results, newFileInfo(source, relPath, path, info.Size(), dirMetas[filepath.Dir(path)]))
 		}
 
 		return nil
@@ -226,6 +280,7 @@ func (s *Scanner) scanFlat(ctx context.Context, source Source, dir string) ([]Fi
 	if err != nil {
 		return nil, err // nolint:wrapcheck // os.ReadDir error is descriptive as-is
 	}
+	dirMeta := loadDirMeta(dir)
 
 	for _, entry := range entries {
 		// check context cancellation
@@ -254,50 +309,59 @@ func (s *Scanner) scanFlat(ctx context.Context, source Source, dir string) ([]Fi
 				continue // skip files we can't stat
 			}
 
-			// extract frontmatter
-			description, tags := extractFrontmatter(path)
-
-			fileInfo := FileInfo{
-				Name:        entry.Name(),
-				Filename:    string(source) + ":" + entry.Name(),
-				Normalized:  strings.ToLower(entry.Name()),
-				Source:      source,
-				Path:        path,
-				Size:        info.Size(),
-				Description: description,
-				Tags:        tags,
-			}
-			results = append(results, fileInfo)
+			results = append(results, newFileInfo(source, entry.Name(), path, info.Size(), dirMeta))
 		}
 	}
 
 	return results, nil
 }
 
-// Close is a no-op for Scanner but required to implement Interface
+// Close releases git repositories opened by the scanner
 func (s *Scanner) Close() error {
-	return nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for path, repo := range s.gitRepos {
+		if err := repo.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(s.gitRepos, path)
+	}
+	return errors.Join(errs...)
 }
 
 // extractFrontmatter reads frontmatter from a file (max 2KB header read)
-func extractFrontmatter(path string) (description string, tags []string) {
+func extractFrontmatter(path string) Frontmatter {
 	// #nosec G304 - path is from scanner, not user input
 	f, err := os.Open(path)
 	if err != nil {
-		return "", nil // can't read, return empty
+		return Frontmatter{} // can't read, return empty
 	}
 	defer f.Close()
 
 	// read first 2KB (enough for frontmatter)
-	buf := make([]byte, 2048)
+	buf := make([]byte, FrontmatterWindow)
 	n, err := f.Read(buf)
 	if err != nil && !errors.Is(err, io.EOF) {
-		return "", nil // read error, return empty
+		return Frontmatter{} // read error, return empty
 	}
 
-	// parse frontmatter (ParseFrontmatter returns empty metadata on parse errors)
-	fm, _ := ParseFrontmatter(buf[:n])
-	return fm.Description, fm.Tags
+	return parseHeadFrontmatter(path, buf[:n])
+}
+
+// parseHeadFrontmatter parses frontmatter from the first FrontmatterWindow bytes of content.
+// malformed blocks result in empty metadata and are logged at debug level only, as it runs on each scan and
+// lint reports them; a block longer than the window is cut and looks unclosed here.
+func parseHeadFrontmatter(name string, content []byte) Frontmatter {
+	if len(content) > FrontmatterWindow {
+		content = content[:FrontmatterWindow]
+	}
+	fm, _, err := DecodeFrontmatter(content)
+	if err != nil {
+		slog.Debug("malformed frontmatter", "path", name, "error", err)
+		return Frontmatter{}
+	}
+	return fm
 }
 
 // shouldExcludeDir checks if directory should be excluded based on excludeDirs list
@@ -590,14 +590,26 @@ func TestExtractFrontmatter(t *testing.T) {
 			wantDesc: "",
 			wantTags: nil,
 		},
+		{
+			name:     "toml frontmatter",
+			file:     "toml-frontmatter.md",
+			wantDesc: "Frontmatter in TOML format",
+			wantTags: []string{"testing", "hugo"},
+		},
+		{
+			name:     "json frontmatter",
+			file:     "json-frontmatter.md",
+			wantDesc: "Frontmatter in JSON format",
+			wantTags: []string{"testing", "json"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			path := filepath.Join(testdataDir, tt.file)
-			desc, tags := extractFrontmatter(path)
-			assert.Equal(t, tt.wantDesc, desc)
-			assert.Equal(t, tt.wantTags, tags)
+			fm := extractFrontmatter(path)
+			assert.Equal(t, tt.wantDesc, fm.Description)
+			assert.Equal(t, tt.wantTags, fm.Tags)
 		})
 	}
 }
@@ -616,12 +628,12 @@ func TestExtractFrontmatter_2KBTruncation(t *testing.T) {
 	require.NoError(t, os.WriteFile(filePath, []byte(largeFrontmatter), 0600))
 
 	// extractFrontmatter should handle truncation gracefully
-	desc, tags := extractFrontmatter(filePath)
+	fm := extractFrontmatter(filePath)
 
 	// with 3KB description, the frontmatter block is truncated at 2KB
 	// YAML parsing should fail, resulting in empty metadata
-	assert.Empty(t, desc, "description should be empty due to truncation")
-	assert.Empty(t, tags, "tags should be empty due to truncation")
+	assert.Empty(t, fm.Description, "description should be empty due to truncation")
+	assert.Empty(t, fm.Tags, "tags should be empty due to truncation")
 }
 
 func TestExtractFrontmatter_MalformedYAML(t *testing.T) {
@@ -662,11 +674,11 @@ description: test
 			require.NoError(t, os.WriteFile(filePath, []byte(tt.content), 0600))
 
 			// should handle malformed frontmatter gracefully
-			desc, tags := extractFrontmatter(filePath)
+			fm := extractFrontmatter(filePath)
 
 			// malformed frontmatter should result in empty metadata (not panic)
-			assert.Empty(t, desc, "description should be empty for malformed YAML")
-			assert.Empty(t, tags, "tags should be empty for malformed YAML")
+			assert.Empty(t, fm.Description, "description should be empty for malformed YAML")
+			assert.Empty(t, fm.Tags, "tags should be empty for malformed YAML")
 		})
 	}
 }
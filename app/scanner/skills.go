@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// SkillFileName is the name of the skill definition file inside each skill directory
+	SkillFileName = "SKILL.md"
+	// maxSkillResources limits the number of bundled resource files listed for a skill
+	maxSkillResources = 100
+)
+
+// scanSkills discovers skills, each skill is a direct subdirectory of the skills dir with SKILL.md inside.
+// skill name comes from frontmatter "name", or from the directory name if not set.
+func (s *Scanner) scanSkills(ctx context.Context) ([]FileInfo, error) {
+	if s.skillsDir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(s.skillsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err // nolint:wrapcheck // os.ReadDir error is descriptive as-is
+	}
+
+	var results []FileInfo
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+		default:
+		}
+
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		// follow symlinks, skills are often linked from other locations
+		skillDir := filepath.Join(s.skillsDir, entry.Name())
+		if st, statErr := os.Stat(skillDir); statErr != nil || !st.IsDir() {
+			continue
+		}
+
+		skillPath := filepath.Join(skillDir, SkillFileName)
+		st, err := os.Stat(skillPath)
+		if err != nil {
+			slog.Debug("skipping skill without definition file", "path", skillDir, "error", err)
+			continue
+		}
+
+		fm := extractFrontmatter(skillPath)
+		name := fm.Name
+		if name == "" {
+			name = entry.Name()
+		}
+		info := fileInfoWithMeta(FileInfo{
+			Name:       name,
+			Filename:   string(SourceSkills) + ":" + entry.Name() + "/" + SkillFileName,
+			Normalized: strings.ToLower(name),
+			Source:     SourceSkills,
+			Path:       skillPath,
+			Size:       st.Size(),
+		}, fm)
+		info.Resources = skillResources(skillDir)
+		results = append(results, info)
+	}
+	return results, nil
+}
+
+// skillResources lists files bundled with a skill, relative to the skill directory and sorted.
+// hidden files and directories are skipped, the list is capped at maxSkillResources.
+func skillResources(skillDir string) []string {
+	var res []string
+	err := filepath.WalkDir(skillDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if path != skillDir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(skillDir, path)
+		if err != nil || rel == SkillFileName {
+			return nil
+		}
+		res = append(res, filepath.ToSlash(rel))
+		if len(res) >= maxSkillResources {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Debug("failed to list skill resources", "path", skillDir, "error", err)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanner_ScanSkills(t *testing.T) {
+	skillsDir := t.TempDir()
+	writeFiles(t, skillsDir, map[string]string{
+		"pdf/SKILL.md":           "---\nname: pdf-tools\ndescription: work with PDF files\ntags: [pdf]\n---\n# PDF\n",
+		"pdf/reference.md":       "# Reference",
+		"pdf/scripts/extract.py": "print(1)",
+		"pdf/.cache/tmp":         "hidden",
+		"review/SKILL.md":        "# Review without frontmatter\n",
+		"no-skill/README.md":     "# not a skill",
+		".hidden/SKILL.md":       "# hidden skill",
+		"loose.md":               "# not in a skill dir",
+	})
+
+	s := NewScanner(Params{SkillsDir: skillsDir, MaxFileSize: 1024})
+	files, err := s.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	pdf := files[0]
+	assert.Equal(t, "pdf-tools", pdf.Name)
+	assert.Equal(t, "skills:pdf/SKILL.md", pdf.Filename)
+	assert.Equal(t, "pdf-tools", pdf.Normalized)
+	assert.Equal(t, SourceSkills, pdf.Source)
+	assert.Equal(t, filepath.Join(skillsDir, "pdf", "SKILL.md"), pdf.Path)
+	assert.Equal(t, "work with PDF files", pdf.Description)
+	assert.Equal(t, []string{"pdf"}, pdf.Tags)
+	assert.Equal(t, []string{"reference.md", "scripts/extract.py"}, pdf.Resources)
+
+	review := files[1]
+	assert.Equal(t, "review", review.Name, "directory name is used without frontmatter name")
+	assert.Equal(t, "skills:review/SKILL.md", review.Filename)
+	assert.Empty(t, review.Resources)
+
+	t.Run("missing or disabled dir", func(t *testing.T) {
+		for _, dir := range []string{"", filepath.Join(skillsDir, "missing")} {
+			files, err := NewScanner(Params{SkillsDir: dir}).scanSkills(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := s.scanSkills(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestSkillResources_Limit(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxSkillResources+10; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d.txt", i)), []byte("x"), 0o600))
+	}
+	assert.Len(t, skillResources(dir), maxSkillResources)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/audit"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// servedDocs is implemented by tool outputs carrying doc content, paths are recorded in the audit log
+type servedDocs interface {
+	servedPaths() []string
+}
+
+// docRequest is implemented by inputs of tools reading a doc by path, the path is recorded in the audit log
+// if the doc doesn't exist
+type docRequest interface {
+	requestedDoc() string
+}
+
+// missingDocs is implemented by outputs of tools reading several docs, reporting docs which don't exist
+type missingDocs interface {
+	missingPaths() []string
+}
+
+// addTool registers tool with handler wrapped to record calls in metrics and the audit log
+func addTool[In any](s *Server, tool *mcp.Tool, h mcp.ToolHandlerFor[In, any]) {
+	mcp.AddTool(s.mcp, tool, func(ctx context.Context, req *mcp.CallToolRequest, in In) (*mcp.CallToolResult, any, error) {
+		start := time.Now()
+		res, out, err := h(ctx, req, in)
+		latency := time.Since(start)
+		s.observeCall(tool.Name, latency, err)
+		s.recordCall(req, tool.Name, latency, in, res, out, err)
+		return res, out, err
+	})
+}
+
+// recordCall writes tool call to the audit log, if enabled. failures to write are logged and not returned,
+// the call itself has succeeded.
+func (s *Server) recordCall(req *mcp.CallToolRequest, tool string, latency time.Duration, in any, res *mcp.CallToolResult,
+	out any, callErr error) {
+	if s.audit == nil {
+		return
+	}
+
+	rec := audit.Record{Time: time.Now().UTC(), Tool: tool, LatencyMs: float64(latency.Microseconds()) / 1000}
+	if req != nil {
+		if req.Session != nil {
+			rec.Session = req.Session.ID()
+		}
+		if req.Params != nil {
+			rec.Arguments = req.Params.Arguments
+		}
+	}
+	if callErr != nil {
+		rec.Error = callErr.Error()
+	}
+	if docs, ok := out.(servedDocs); ok && callErr == nil {
+		rec.Paths = docs.servedPaths()
+	}
+	if docs, ok := out.(missingDocs); ok && callErr == nil {
+		rec.NotFound = docs.missingPaths()
+	}
+	if doc, ok := in.(docRequest); ok && errors.Is(callErr, scanner.ErrNotFound) {
+		rec.NotFound = []string{doc.requestedDoc()}
+	}
+	if res != nil {
+		h := sha256.New()
+		for _, c := range res.Content {
+			if text, ok := c.(*mcp.TextContent); ok {
+				h.Write([]byte(text.Text))
+				rec.Bytes += len(text.Text)
+			}
+		}
+		if rec.Bytes > 0 {
+			rec.Hash = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+
+	if err := s.audit.Log(rec); err != nil {
+		slog.Warn("failed to write audit log", "tool", tool, "error", err)
+	}
+}
+
+// requestedName returns doc path as requested, with source prefix if source is set
+func requestedName(path string, source *string) string {
+	if source != nil && *source != "" {
+		return *source + ":" + path
+	}
+	return path
+}
+
+// docName returns doc path with source prefix, git docs have the prefix in path already
+func docName(source, path string) string {
+	if source == string(scanner.SourceGit) {
+		return path
+	}
+	return source + ":" + filepath.ToSlash(path)
+}
+
+func (r *ReadOutput) servedPaths() []string {
+	return append([]string{docName(r.Source, r.Path)}, r.Includes...)
+}
+
+func (e *ExtractOutput) servedPaths() []string {
+	return []string{docName(e.Source, e.Path)}
+}
+
+func (r *RenderOutput) servedPaths() []string {
+	return append([]string{r.Path}, r.Files...)
+}
+
+func (d *DocDiffOutput) servedPaths() []string {
+	return []string{d.doc}
+}
+
+func (p *PlanStatusOutput) servedPaths() []string {
+	return []string{"plans:" + p.Path}
+}
+
+func (r *ReadDocsOutput) missingPaths() []string {
+	var res []string
+	for _, item := range r.Results {
+		if item.missing != "" {
+			res = append(res, item.missing)
+		}
+	}
+	return res
+}
+
+func (r ReadInput) requestedDoc() string {
+	return requestedName(r.Path, r.Source)
+}
+
+func (r ReadAtInput) requestedDoc() string {
+	return requestedName(r.Path, r.Source)
+}
+
+func (e ExtractInput) requestedDoc() string {
+	return requestedName(e.Path, e.Source)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/audit"
+)
+
+func TestServer_AuditLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "guide.md"), []byte("# Guide\n<!-- include: part.md -->\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "part.md"), []byte("part\n"), 0600))
+	logPath := filepath.Join(tmpDir, "audit.jsonl")
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server", AuditLog: logPath})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ct, st := mcp.NewInMemoryTransports()
+	ss, err := srv.mcp.Connect(ctx, st, nil)
+	require.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil)
+	session, err := client.Connect(ctx, ct, nil)
+	require.NoError(t, err)
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "read_doc",
+		Arguments: map[string]any{"path": "guide.md", "expand_includes": true}})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "read_doc", Arguments: map[string]any{"path": "missing.md"}})
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+	_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "search_docs", Arguments: map[string]any{"query": "guide"}})
+	require.NoError(t, err)
+	res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "read_docs", Arguments: map[string]any{"docs": []any{
+		map[string]any{"path": "part.md"}, map[string]any{"path": "gone.md", "source": "project-docs"},
+		map[string]any{"path": "guide.md", "section": "missing"}}}})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+
+	require.NoError(t, session.Close())
+	require.NoError(t, srv.Close())
+
+	records, err := audit.ReadRecords(logPath)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	read := records[0]
+	assert.Equal(t, "read_doc", read.Tool)
+	assert.Equal(t, ss.ID(), read.Session)
+	assert.JSONEq(t, `{"path":"guide.md","expand_includes":true}`, string(read.Arguments))
+	assert.Equal(t, []string{"project-docs:guide.md", "project-docs:part.md"}, read.Paths)
+	assert.Len(t, read.Hash, 64)
+	assert.Positive(t, read.Bytes)
+	assert.Empty(t, read.Error)
+	assert.Empty(t, read.NotFound)
+	assert.False(t, read.Time.IsZero())
+
+	assert.Contains(t, records[1].Error, "file not found")
+	assert.Empty(t, records[1].Paths)
+	assert.Equal(t, []string{"missing.md"}, records[1].NotFound)
+	assert.Zero(t, records[1].Bytes)
+
+	assert.Equal(t, "search_docs", records[2].Tool)
+	assert.Empty(t, records[2].Paths, "search results are not served docs")
+	assert.Positive(t, records[2].Bytes)
+
+	assert.Equal(t, []string{"project-docs:part.md"}, records[3].Paths)
+	assert.Equal(t, []string{"project-docs:gone.md"}, records[3].NotFound, "missing section is not a missing doc")
+}
+
+func TestServer_AuditLogDisabled(t *testing.T) {
+	srv, err := New(Config{MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+	assert.Nil(t, srv.audit)
+	srv.recordCall(&mcp.CallToolRequest{}, "read_doc", 0, nil, nil, nil, nil) // no-op without the log
+
+	_, err = New(Config{MaxFileSize: 1024, ServerName: "test-server", AuditLog: filepath.Join(t.TempDir(), "missing", "audit.jsonl")})
+	require.Error(t, err)
+}
+
+func TestServedPaths(t *testing.T) {
+	assert.Equal(t, []string{"project-docs:a/b.md", "commands:inc.md"},
+		(&ReadOutput{Path: "a/b.md", Source: "project-docs", Includes: []string{"commands:inc.md"}}).servedPaths())
+	assert.Equal(t, []string{"release@v1:guide.md"}, (&ReadOutput{Path: "release@v1:guide.md", Source: "git"}).servedPaths())
+	assert.Equal(t, []string{"commands:x.md"}, (&ExtractOutput{Path: "x.md", Source: "commands"}).servedPaths())
+	assert.Equal(t, []string{"commands:deploy.md", "project-docs:creds.md"},
+		(&RenderOutput{Path: "commands:deploy.md", Files: []string{"project-docs:creds.md"}}).servedPaths())
+	assert.Equal(t, []string{"project-docs:runbook.md"}, (&DocDiffOutput{doc: "project-docs:runbook.md"}).servedPaths())
+	assert.Equal(t, []string{"plans:auth.md"}, (&PlanStatusOutput{PlanInfo: PlanInfo{Path: "auth.md"}}).servedPaths())
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+const (
+	closestMinScore    = 0.75 // similarity a doc needs to match a path without exact match
+	closestMargin      = 0.1  // lead the best match needs over the next one to be read without asking
+	closestBasename    = 0.8  // score of a doc with the same basename as the path
+	closestSuggestions = 5    // docs listed when the match is ambiguous
+)
+
+// NoUniqueMatchError is returned when a doc path has no exact match and several docs match it closely
+type NoUniqueMatchError struct {
+	Path        string
+	Suggestions []string // doc paths with source prefix, best first
+}
+
+func (e *NoUniqueMatchError) Error() string {
+	return fmt.Sprintf("file not found: %s, closest docs: %s", e.Path, strings.Join(e.Suggestions, ", "))
+}
+
+// Unwrap returns scanner.ErrNotFound, the path itself has no doc
+func (e *NoUniqueMatchError) Unwrap() error {
+	return scanner.ErrNotFound
+}
+
+// closestDoc is a doc matching a path without exact match
+type closestDoc struct {
+	filename string // with source prefix
+	score    float64
+}
+
+// readDocClosest reads a doc like readDoc. If the path has no exact match, it reads the doc matching
+// the path by basename, command name or edit distance and sets ResolvedFrom to the requested path.
+func (s *Server) readDocClosest(ctx context.Context, path string, source *string) (*ReadOutput, error) {
+	res, err := s.readDoc(ctx, path, source)
+	if err == nil || ctx.Err() != nil {
+		return res, err
+	}
+
+	matches, ok := s.closestDocs(ctx, path, source)
+	switch {
+	case !ok || len(matches) == 0:
+		return nil, err
+	case len(matches) > 1 && matches[0].score-matches[1].score < closestMargin:
+		suggestions := make([]string, 0, closestSuggestions)
+		for _, m := range matches[:min(closestSuggestions, len(matches))] {
+			suggestions = append(suggestions, m.filename)
+		}
+		return nil, &NoUniqueMatchError{Path: path, Suggestions: suggestions}
+	}
+
+	slog.Debug("read_doc resolved by closest match", "path", path, "doc", matches[0].filename, "score", matches[0].score)
+	res, err = s.readDoc(ctx, matches[0].filename, nil)
+	if err != nil {
+		return nil, err
+	}
+	res.ResolvedFrom = path
+	return res, nil
+}
+
+// closestDocs returns docs matching path with score of closestMinScore or above, best first.
+// docs of the given source are matched, or of sources in precedence order if the path has no source,
+// skipping shadowed ones. returns false if the path can't be matched, e.g. a git source or a path
+// which exists but failed to read for another reason.
+func (s *Server) closestDocs(ctx context.Context, path string, source *string) ([]closestDoc, bool) {
+	sourceStr, cleanPath := parseDocPath(path, source)
+	switch {
+	case strings.Contains(sourceStr, "@"):
+		return nil, false // git sources are read by exact path only
+	case sourceStr != "" && sourceStr != string(scanner.SourceMemory):
+		if _, _, err := s.sourceDir(sourceStr); err != nil {
+			// not a source, can be a namespaced command name like "action:commit"
+			sourceStr, cleanPath = "", path
+		}
+	}
+	userPath, err := scanner.CleanUserPath(strings.TrimPrefix(cleanPath, "/"))
+	if err != nil {
+		return nil, false
+	}
+	userPath = filepath.ToSlash(userPath)
+
+	files, err := s.scanner.Scan(ctx)
+	if err != nil {
+		return nil, false
+	}
+	allowed := map[scanner.Source]bool{}
+	if sourceStr != "" {
+		allowed[scanner.Source(sourceStr)] = true
+	} else {
+		for _, src := range s.config.sourcePrecedence() {
+			allowed[src] = true
+		}
+	}
+	shadowed := s.shadowedDocs(files)
+
+	query := strings.ToLower(strings.TrimSuffix(userPath, ".md"))
+	var res []closestDoc
+	for _, f := range files {
+		if !allowed[f.Source] || shadowed[f.Filename] != "" {
+			continue
+		}
+		if _, rel, _ := strings.Cut(f.Filename, ":"); rel == userPath {
+			return nil, false // the doc exists, reading failed for another reason
+		}
+		if score := closestScore(query, f); score >= closestMinScore {
+			res = append(res, closestDoc{filename: f.Filename, score: score})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].score != res[j].score {
+			return res[i].score > res[j].score
+		}
+		return res[i].filename < res[j].filename
+	})
+	return res, true
+}
+
+// closestScore scores how close a doc is to the lowercase query path without extension.
+// the query is compared to as many trailing path segments of the doc as it has and to the
+// command name, a doc with the same basename scores at least closestBasename.
+func closestScore(query string, f scanner.FileInfo) float64 {
+	_, rel, _ := strings.Cut(f.Filename, ":")
+	parts := strings.Split(strings.ToLower(strings.TrimSuffix(rel, ".md")), "/")
+	queryParts := strings.Split(query, "/")
+
+	score := similarity(query, strings.Join(parts[max(0, len(parts)-len(queryParts)):], "/"))
+	if parts[len(parts)-1] == queryParts[len(queryParts)-1] {
+		score = max(score, closestBasename)
+	}
+	if f.Command != nil {
+		score = max(score, similarity(query, strings.ToLower(strings.TrimPrefix(f.Command.Name, "/"))))
+	}
+	return score
+}
+
+// similarity returns 1 minus edit distance of strings relative to the longer one
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance returns the number of rune insertions, deletions, substitutions and
+// transpositions of adjacent runes turning a into b, each substring edited once
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
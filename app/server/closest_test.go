@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+func TestServer_ReadDocClosest(t *testing.T) {
+	files := map[string]string{
+		"commands/action/commit.md": "action commit",
+		"commands/git/commit.md":    "git commit",
+		"commands/review.md":        "review",
+		"docs/ops/deploy.md":        "deploy",
+		"docs/testing.md":           "testing",
+		"docs/big.md":               "too large for the limit",
+	}
+	srv, _ := newTestServer(t, files, Config{MaxFileSize: 16})
+
+	source := func(s string) *string { return &s }
+
+	tbl := []struct {
+		name     string
+		path     string
+		source   *string
+		content  string
+		resolved string
+		err      string
+	}{
+		{name: "exact path", path: "review.md", content: "review"},
+		{name: "basename", path: "deploy", content: "deploy", resolved: "deploy"},
+		{name: "wrong directory", path: "actions/commit.md", content: "action commit", resolved: "actions/commit.md"},
+		{name: "misspelled", path: "deplyo", content: "deploy", resolved: "deplyo"},
+		{name: "command name", path: "/git:commit", content: "git commit", resolved: "/git:commit"},
+		{name: "namespaced command", path: "action:commit", content: "action commit", resolved: "action:commit"},
+		{name: "source prefix", path: "project-docs:tesing", content: "testing", resolved: "project-docs:tesing"},
+		{name: "source parameter", path: "revew", source: source("commands"), content: "review", resolved: "revew"},
+		{name: "ambiguous", path: "commit",
+			err: "file not found: commit, closest docs: commands:action/commit.md, commands:git/commit.md"},
+		{name: "wrong source", path: "commands:deploy",
+			err: "failed to resolve path in commands: file not found: deploy.md"},
+		{name: "no match", path: "kubernetes", err: "file not found in any source: kubernetes"},
+		{name: "too large", path: "project-docs:big.md", err: "failed to resolve path in project-docs: file too large: 23 bytes (max 16)"},
+		{name: "git source", path: "release@v1:deploy", err: "failed to read from release@v1"},
+		{name: "traversal", path: "../commit", err: "file not found in any source: ../commit"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := srv.readDocClosest(context.Background(), tt.path, tt.source)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				assert.Equal(t, strings.Contains(tt.err, "file not found"), errors.Is(err, scanner.ErrNotFound))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, res.Content)
+			assert.Equal(t, tt.resolved, res.ResolvedFrom)
+		})
+	}
+
+	t.Run("ambiguous error lists suggestions", func(t *testing.T) {
+		_, err := srv.readDocClosest(context.Background(), "commit", nil)
+		var noUnique *NoUniqueMatchError
+		require.ErrorAs(t, err, &noUnique)
+		assert.Equal(t, []string{"commands:action/commit.md", "commands:git/commit.md"}, noUnique.Suggestions)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := srv.readDocClosest(ctx, "deploy", nil)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestClosestScore(t *testing.T) {
+	commit := scanner.FileInfo{Filename: "commands:action/commit.md", Source: scanner.SourceCommands,
+		Command: &scanner.CommandMeta{Name: "/action:commit"}}
+	tbl := []struct {
+		query string
+		file  scanner.FileInfo
+		score float64
+	}{
+		{"commit", commit, 1},
+		{"action/commit", commit, 1},
+		{"actions/commit", commit, 1 - 1.0/14},
+		{"docs/action/commit", commit, closestBasename},
+		{"other/commit", commit, closestBasename},
+		{"action/comit", commit, 1 - 1.0/13},
+		{"action:commit", commit, 1},
+		{"comit", commit, 1 - 1.0/6},
+		{"deploy", commit, 1 - 12.0/13},
+		{"guide", scanner.FileInfo{Filename: "release@v1:Guide.md"}, 1},
+	}
+	for _, tt := range tbl {
+		assert.InDelta(t, tt.score, closestScore(tt.query, tt.file), 0.0001, tt.query)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tbl := []struct {
+		a, b  string
+		score float64
+	}{
+		{"", "", 1},
+		{"commit", "commit", 1},
+		{"commit", "comit", 1 - 1.0/6},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"deploy", "deplyo", 1 - 1.0/6},
+		{"api-guide", "main-guide", 1 - 3.0/10},
+		{"abc", "", 0},
+		{"日本語", "日本", 1 - 1.0/3},
+	}
+	for _, tt := range tbl {
+		assert.InDelta(t, tt.score, similarity(tt.a, tt.b), 0.0001, "%s %s", tt.a, tt.b)
+	}
+}
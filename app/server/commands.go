@@ -0,0 +1,339 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/markdown"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// CommandInfo represents slash command metadata of a commands source file
+type CommandInfo struct {
+	Name         string   `json:"name"` // slash command, e.g. "/action:commit"
+	ArgumentHint string   `json:"argument_hint,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+	Model        string   `json:"model,omitempty"`
+}
+
+// CommandEntry represents a single slash command in list_commands output
+type CommandEntry struct {
+	CommandInfo
+	Path        string `json:"path"` // doc path with source prefix, for read_doc
+	Description string `json:"description,omitempty"`
+}
+
+// CommandsOutput contains the result of listing slash commands
+type CommandsOutput struct {
+	Commands []CommandEntry `json:"commands"`
+	Total    int            `json:"total"`
+}
+
+// newCommandInfo converts scanner command metadata, returns nil for files without it
+func newCommandInfo(meta *scanner.CommandMeta) *CommandInfo {
+	if meta == nil {
+		return nil
+	}
+	return &CommandInfo{Name: meta.Name, ArgumentHint: meta.ArgumentHint, AllowedTools: meta.AllowedTools, Model: meta.Model}
+}
+
+// listCommands returns slash commands from the commands source, sorted by name
+func (s *Server) listCommands(ctx context.Context) (*CommandsOutput, error) {
+	files, err := s.scanner.Scan(ctx)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // scanner error is descriptive
+	}
+
+	commands := []CommandEntry{}
+	for _, f := range files {
+		if f.Command == nil {
+			continue
+		}
+		commands = append(commands, CommandEntry{CommandInfo: *newCommandInfo(f.Command), Path: f.Filename, Description: f.Description})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+
+	return &CommandsOutput{Commands: commands, Total: len(commands)}, nil
+}
+
+// registerCommandTools registers tools working with slash commands
+func (s *Server) registerCommandTools() {
+	addTool(s, &mcp.Tool{
+		Name: "list_commands",
+		Description: "List slash commands from the commands source with their names (e.g. '/action:commit' for action/commit.md), " +
+			"descriptions, argument hints, allowed tools and models. Use it to find the right command to suggest.",
+	}, s.handleListCommands)
+
+	addTool(s, &mcp.Tool{
+		Name: "render_command",
+		Description: "Render a command template with arguments: substitutes $ARGUMENTS and positional $1, $2, ..., " +
+			"inlines @path references to docs and lists shell commands (! lines) without executing them. " +
+			"Placeholders and references that can't be resolved are kept in the prompt and listed as unresolved.",
+	}, s.handleRenderCommand)
+}
+
+// handleListCommands handles list_commands tool calls.
+// input is required by MCP SDK signature but list_commands takes no parameters.
+func (s *Server) handleListCommands(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+	slog.Debug("list_commands called")
+
+	result, err := s.listCommands(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list commands failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
+
+var (
+	// placeholderRe matches argument placeholders, $ARGUMENTS and positional $1, $2, ...
+	placeholderRe = regexp.MustCompile(`\$(ARGUMENTS|[1-9][0-9]*)`)
+	// fileRefRe matches @path file references at the start of a line or after whitespace
+	fileRefRe = regexp.MustCompile(`(^|\s)@([^\s@]+)`)
+	// inlineShellRe matches inline shell commands, !`cmd`
+	inlineShellRe = regexp.MustCompile("!`([^`]+)`")
+)
+
+// RenderInput represents input for rendering a command template
+type RenderInput struct {
+	Path      string `json:"path"`                // command path, with or without "commands:" prefix
+	Arguments string `json:"arguments,omitempty"` // arguments as typed after the command name
+	Project   string `json:"project,omitempty"`
+}
+
+// RenderOutput contains the expanded command prompt
+type RenderOutput struct {
+	Command       string         `json:"command"` // slash command name
+	Path          string         `json:"path"`
+	Prompt        string         `json:"prompt"`
+	Files         []string       `json:"files,omitempty"`          // inlined file references, in order of appearance
+	ShellCommands []string       `json:"shell_commands,omitempty"` // shell commands of the template, not executed
+	Unresolved    []string       `json:"unresolved,omitempty"`     // placeholders and references left as is
+	Redactions    map[string]int `json:"redactions,omitempty"`     // secrets masked in the prompt, by rule
+}
+
+// renderCommand expands a command template: substitutes $ARGUMENTS and positional arguments,
+// inlines @file references resolved like read_doc paths and collects shell commands without running them.
+// unresolved placeholders and references are kept in the prompt and listed. references and shell
+// commands inside fenced code blocks are not processed, argument values and inlined files are not expanded.
+func (s *Server) renderCommand(ctx context.Context, path, arguments string) (*RenderOutput, error) {
+	sourceStr, cleanPath := parseDocPath(path, nil)
+	if sourceStr != "" && sourceStr != string(scanner.SourceCommands) {
+		return nil, fmt.Errorf("not a command path: %s", path)
+	}
+	cleanPath, err := scanner.CleanUserPath(cleanPath)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // path error is descriptive
+	}
+
+	resolvedPath, err := scanner.SafeResolvePath(s.scanner.CommandsDir(), cleanPath, s.config.MaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve command %s: %w", path, err)
+	}
+	// #nosec G304 - path is validated by SafeResolvePath
+	content, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command: %w", err)
+	}
+	_, template := scanner.ParseFrontmatter(content)
+
+	res := &RenderOutput{Command: scanner.CommandName(cleanPath), Path: string(scanner.SourceCommands) + ":" + filepath.ToSlash(cleanPath)}
+	args := splitArguments(arguments)
+	unresolved := map[string]bool{}
+	addUnresolved := func(v string) {
+		if !unresolved[v] {
+			unresolved[v] = true
+			res.Unresolved = append(res.Unresolved, v)
+		}
+	}
+
+	substitute := func(text string) string {
+		return placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+			if m == "$ARGUMENTS" {
+				if strings.TrimSpace(arguments) == "" {
+					addUnresolved(m)
+					return m
+				}
+				return arguments
+			}
+			idx, _ := strconv.Atoi(m[1:]) // regexp guarantees a positive number
+			if idx > len(args) {
+				addUnresolved(m)
+				return m
+			}
+			return args[idx-1]
+		})
+	}
+
+	var sb strings.Builder
+	var fences markdown.FenceTracker
+	for _, line := range strings.SplitAfter(string(template), "\n") {
+		if fences.Inside(line) {
+			sb.WriteString(substitute(line))
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		res.ShellCommands = append(res.ShellCommands, shellCommands(trimmed)...)
+		if err := s.inlineFileRefs(ctx, &sb, line, substitute, res, addUnresolved); err != nil {
+			return nil, err
+		}
+	}
+	res.Prompt, res.Redactions = s.redactText(sb.String())
+	return res, nil
+}
+
+// inlineFileRefs writes template line to sb, replacing @path references with contents of the referenced docs
+// and substituting placeholders in the rest of the line. references which can't be resolved are kept
+// and reported with addUnresolved.
+func (s *Server) inlineFileRefs(ctx context.Context, sb *strings.Builder, line string, substitute func(string) string,
+	res *RenderOutput, addUnresolved func(string)) error {
+	last := 0
+	for _, m := range fileRefRe.FindAllStringSubmatchIndex(line, -1) {
+		ref := strings.TrimRight(line[m[4]:m[5]], ".,;:!?)\"'")
+		sb.WriteString(substitute(line[last : m[4]-1]))
+		last = m[4] + len(ref)
+		ref = substitute(ref) // reference may be built from arguments, e.g. @$1
+
+		data, err := s.readFileRef(ctx, ref)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+			}
+			if ref != "" {
+				addUnresolved("@" + ref)
+			}
+			sb.WriteString("@" + ref)
+			continue
+		}
+		res.Files = append(res.Files, ref)
+		fmt.Fprintf(sb, "<file path=%q>\n%s", ref, data)
+		if !strings.HasSuffix(string(data), "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("</file>")
+	}
+	sb.WriteString(substitute(line[last:]))
+	return nil
+}
+
+// readFileRef reads doc referenced from a command template, resolved like read_doc path, without frontmatter.
+// only docs of the sources are read, as arguments can be substituted into the reference.
+func (s *Server) readFileRef(ctx context.Context, ref string) ([]byte, error) {
+	sourceStr, refPath := parseDocPath(ref, nil)
+	cleanPath, err := scanner.CleanUserPath(refPath)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // path error is descriptive
+	}
+	resolvedPath, _, err := s.resolveDocFile(ctx, sourceStr, cleanPath)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G304 - path is validated by resolveDocFile
+	data, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	_, data = scanner.ParseFrontmatter(data)
+	return data, nil
+}
+
+// shellCommands returns shell commands of a trimmed template line, the whole line prefixed with "!"
+// or inline !`cmd` commands
+func shellCommands(trimmed string) []string {
+	if strings.HasPrefix(trimmed, "!") && !strings.HasPrefix(trimmed, "!`") {
+		if cmd := strings.TrimSpace(trimmed[1:]); cmd != "" {
+			return []string{cmd}
+		}
+		return nil
+	}
+	var res []string
+	for _, m := range inlineShellRe.FindAllStringSubmatch(trimmed, -1) {
+		if cmd := strings.TrimSpace(m[1]); cmd != "" {
+			res = append(res, cmd)
+		}
+	}
+	return res
+}
+
+// splitArguments splits arguments on whitespace, single or double quotes group words into one argument
+func splitArguments(s string) []string {
+	var res []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				res = append(res, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		res = append(res, cur.String())
+	}
+	return res
+}
+
+// handleRenderCommand handles render_command tool calls
+func (s *Server) handleRenderCommand(ctx context.Context, _ *mcp.CallToolRequest, input RenderInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("render_command called", "path", input.Path, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.renderCommand(ctx, input.Path, input.Arguments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ListCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	docsDir := filepath.Join(tmpDir, "docs")
+	for path, content := range map[string]string{
+		filepath.Join(commandsDir, "action", "commit.md"): "---\ndescription: commit changes\nargument-hint: [message]\n" +
+			"allowed-tools: Bash(git add:*), Bash(git commit:*)\nmodel: haiku\n---\nCommit\n",
+		filepath.Join(commandsDir, "review.md"): "# Review\n",
+		filepath.Join(docsDir, "guide.md"):      "# Guide\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	srv, err := New(Config{CommandsDir: commandsDir, ProjectDocsDir: docsDir, MaxFileSize: 1024,
+		ServerName: "test-server", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	result, out, err := srv.handleListCommands(context.Background(), &mcp.CallToolRequest{}, struct{}{})
+	require.NoError(t, err)
+	commands, ok := out.(*CommandsOutput)
+	require.True(t, ok)
+	assert.Equal(t, 2, commands.Total)
+	assert.Equal(t, []CommandEntry{
+		{CommandInfo: CommandInfo{Name: "/action:commit", ArgumentHint: "[message]",
+			AllowedTools: []string{"Bash(git add:*)", "Bash(git commit:*)"}, Model: "haiku"},
+			Path: "commands:action/commit.md", Description: "commit changes"},
+		{CommandInfo: CommandInfo{Name: "/review"}, Path: "commands:review.md"},
+	}, commands.Commands)
+
+	// command fields are flattened in JSON output
+	var raw struct {
+		Commands []map[string]any `json:"commands"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &raw))
+	assert.Equal(t, "/action:commit", raw.Commands[0]["name"])
+	assert.Equal(t, "[message]", raw.Commands[0]["argument_hint"])
+
+	// list_all_docs exposes the same metadata
+	list, err := srv.listAllDocs(context.Background())
+	require.NoError(t, err)
+	for _, d := range list.Docs {
+		if d.Source == "commands" {
+			require.NotNil(t, d.Command, d.Filename)
+			continue
+		}
+		assert.Nil(t, d.Command, d.Filename)
+	}
+}
+
+func TestServer_RenderCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	docsDir := filepath.Join(tmpDir, "docs")
+	for path, content := range map[string]string{
+		filepath.Join(commandsDir, "action", "commit.md"): "---\ndescription: commit\n---\n" +
+			"Commit $1 with message: $ARGUMENTS\n" +
+			"- status: !`git status --short`\n" +
+			"!git diff --cached\n" +
+			"Follow @project-docs:style.md, see @missing.md and @architecture.\n" +
+			"Target $3 of $2.\n" +
+			"```go\n@Override $1\n!not a command\n```\n" +
+			"````md\n```\n@nested.md\n````\n",
+		filepath.Join(commandsDir, "plain.md"):    "Review $ARGUMENTS now\n",
+		filepath.Join(docsDir, "style.md"):        "---\ntags: [style]\n---\nUse gofmt, not $1\n",
+		filepath.Join(docsDir, "architecture.md"): "# Arch",
+		filepath.Join(docsDir, "big.md"):          strings.Repeat("x", 2000),
+		filepath.Join(commandsDir, "big-ref.md"):  "See @big.md\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	srv, err := New(Config{CommandsDir: commandsDir, ProjectDocsDir: docsDir, MaxFileSize: 1024,
+		ServerName: "test-server", Version: "1.0.0"})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	t.Run("full template", func(t *testing.T) {
+		res, err := srv.renderCommand(context.Background(), "commands:action/commit", `main "fix the bug"`)
+		require.NoError(t, err)
+		assert.Equal(t, "/action:commit", res.Command)
+		assert.Equal(t, "commands:action/commit.md", res.Path)
+		assert.Equal(t, "Commit main with message: main \"fix the bug\"\n"+
+			"- status: !`git status --short`\n"+
+			"!git diff --cached\n"+
+			"Follow <file path=\"project-docs:style.md\">\nUse gofmt, not $1\n</file>, see @missing.md and <file path=\"architecture\">\n# Arch\n</file>.\n"+
+			"Target $3 of fix the bug.\n"+
+			"```go\n@Override main\n!not a command\n```\n"+
+			"````md\n```\n@nested.md\n````\n", res.Prompt)
+		assert.Equal(t, []string{"project-docs:style.md", "architecture"}, res.Files)
+		assert.Equal(t, []string{"git status --short", "git diff --cached"}, res.ShellCommands)
+		assert.Equal(t, []string{"@missing.md", "$3"}, res.Unresolved)
+	})
+
+	t.Run("no arguments", func(t *testing.T) {
+		res, err := srv.renderCommand(context.Background(), "plain.md", "")
+		require.NoError(t, err)
+		assert.Equal(t, "Review $ARGUMENTS now\n", res.Prompt)
+		assert.Equal(t, []string{"$ARGUMENTS"}, res.Unresolved)
+	})
+
+	t.Run("referenced file too large", func(t *testing.T) {
+		res, err := srv.renderCommand(context.Background(), "big-ref", "")
+		require.NoError(t, err)
+		assert.Equal(t, "See @big.md\n", res.Prompt)
+		assert.Equal(t, []string{"@big.md"}, res.Unresolved)
+	})
+
+	t.Run("only docs are inlined", func(t *testing.T) {
+		root := t.TempDir()
+		for path, content := range map[string]string{
+			filepath.Join(root, "src", "main.go"):         "package main\n",
+			filepath.Join(root, ".env"):                   "TOKEN=secret\n",
+			filepath.Join(root, ".git", "config"):         "[core]\n",
+			filepath.Join(root, "commands", "explain.md"): "Explain @$1 using @src/main.go and @.env\n",
+		} {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		}
+		prj, err := New(Config{CommandsDir: filepath.Join(root, "commands"), ProjectDocsDir: docsDir, ProjectRoot: root,
+			MaxFileSize: 1024, ServerName: "test-server"})
+		require.NoError(t, err)
+		defer prj.Close()
+
+		res, err := prj.renderCommand(context.Background(), "explain", "architecture")
+		require.NoError(t, err)
+		assert.Equal(t, "Explain <file path=\"architecture\">\n# Arch\n</file> using @src/main.go and @.env\n", res.Prompt)
+		assert.Equal(t, []string{"architecture"}, res.Files)
+		assert.Equal(t, []string{"@src/main.go", "@.env"}, res.Unresolved)
+
+		res, err = prj.renderCommand(context.Background(), "explain", ".git/config")
+		require.NoError(t, err)
+		assert.Equal(t, "Explain @.git/config using @src/main.go and @.env\n", res.Prompt)
+		assert.Empty(t, res.Files)
+		assert.Equal(t, []string{"@.git/config", "@src/main.go", "@.env"}, res.Unresolved)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := srv.renderCommand(context.Background(), "project-docs:style.md", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not a command path")
+		_, err = srv.renderCommand(context.Background(), "missing", "")
+		require.Error(t, err)
+		_, err = srv.renderCommand(context.Background(), "../docs/style.md", "")
+		require.Error(t, err)
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		result, out, err := srv.handleRenderCommand(context.Background(), &mcp.CallToolRequest{},
+			RenderInput{Path: "plain", Arguments: "pr 42"})
+		require.NoError(t, err)
+		assert.Equal(t, "Review pr 42 now\n", out.(*RenderOutput).Prompt)
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &raw))
+		assert.Equal(t, "/plain", raw["command"])
+	})
+}
+
+func TestSplitArguments(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "", want: nil},
+		{in: "  a  b ", want: []string{"a", "b"}},
+		{in: `a "b c" 'd e'`, want: []string{"a", "b c", "d e"}},
+		{in: `x""y ""`, want: []string{"xy", ""}},
+		{in: `"unclosed quote`, want: []string{"unclosed quote"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, splitArguments(tt.in), tt.in)
+	}
+}
+
+func TestShellCommands(t *testing.T) {
+	assert.Equal(t, []string{"git status"}, shellCommands("!git status"))
+	assert.Equal(t, []string{"date", "pwd"}, shellCommands("time: !`date`, dir: !` pwd `"))
+	assert.Nil(t, shellCommands("!"))
+	assert.Nil(t, shellCommands("no commands!"))
+}
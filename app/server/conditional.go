@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// unchangedDoc returns not modified output if the doc has the given hash, nil otherwise. the doc is resolved
+// by exact path only and its content is not read, the hash is cached by scanner.
+func (s *Server) unchangedDoc(ctx context.Context, path string, source *string, hash string) *ReadOutput {
+	sourceStr, cleanPath := parseDocPath(path, source)
+	if strings.Contains(sourceStr, "@") {
+		return nil // git docs have no cached hash, read and compared after reading
+	}
+	resolvedPath, actualSource, err := s.resolveDocFile(ctx, sourceStr, cleanPath)
+	if err != nil {
+		return nil
+	}
+	version, err := s.scanner.Version(resolvedPath)
+	if err != nil || version.Hash != hash {
+		return nil
+	}
+	return &ReadOutput{Path: cleanPath, Source: string(actualSource), Hash: version.Hash,
+		ModTime: version.ModTime.UTC().Format(time.RFC3339), NotModified: true}
+}
+
+// notModified returns read output without content, for a doc read with the hash passed in if_none_match
+func notModified(doc *ReadOutput) *ReadOutput {
+	return &ReadOutput{Path: doc.Path, Source: doc.Source, Revision: doc.Revision, ResolvedFrom: doc.ResolvedFrom,
+		Hash: doc.Hash, ModTime: doc.ModTime, NotModified: true}
+}
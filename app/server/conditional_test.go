@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+func TestServer_ReadDocConditional(t *testing.T) {
+	docsDir := t.TempDir()
+	guide := filepath.Join(docsDir, "ops", "guide.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(guide), 0755))
+	require.NoError(t, os.WriteFile(guide, []byte("---\ntags: [ops]\n---\n# Guide\n\n<!-- include: part.md -->\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "ops", "part.md"), []byte("part"), 0600))
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test"})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+
+	read := func(t *testing.T, args map[string]any) ReadOutput {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "read_doc", Arguments: args})
+		require.NoError(t, err)
+		require.False(t, res.IsError, res.Content[0].(*mcp.TextContent).Text)
+		var out ReadOutput
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+		return out
+	}
+
+	first := read(t, map[string]any{"path": "ops/guide.md"})
+	raw, err := os.ReadFile(guide)
+	require.NoError(t, err)
+	assert.Equal(t, scanner.HashContent(raw), first.Hash, "hash of the file with frontmatter")
+	info, err := os.Stat(guide)
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime().UTC().Format(time.RFC3339), first.ModTime)
+	assert.False(t, first.NotModified)
+
+	t.Run("unchanged", func(t *testing.T) {
+		out := read(t, map[string]any{"path": "ops/guide.md", "if_none_match": first.Hash})
+		assert.Equal(t, ReadOutput{Path: "ops/guide.md", Source: "project-docs", Hash: first.Hash, ModTime: first.ModTime,
+			NotModified: true}, out)
+
+		out = read(t, map[string]any{"path": "guide", "if_none_match": first.Hash})
+		assert.True(t, out.NotModified, "closest doc compared after reading")
+		assert.Equal(t, "guide", out.ResolvedFrom)
+		assert.Empty(t, out.Content)
+	})
+
+	t.Run("other hash", func(t *testing.T) {
+		out := read(t, map[string]any{"path": "ops/guide.md", "if_none_match": "stale"})
+		assert.False(t, out.NotModified)
+		assert.Equal(t, "# Guide\n\n<!-- include: part.md -->\n", out.Content)
+	})
+
+	t.Run("expanded includes", func(t *testing.T) {
+		out := read(t, map[string]any{"path": "ops/guide.md", "expand_includes": true})
+		assert.Equal(t, scanner.HashContent([]byte(out.Content)), out.Hash)
+		assert.NotEqual(t, first.Hash, out.Hash)
+
+		again := read(t, map[string]any{"path": "ops/guide.md", "expand_includes": true, "if_none_match": first.Hash})
+		assert.False(t, again.NotModified, "file hash doesn't match expanded content")
+		again = read(t, map[string]any{"path": "ops/guide.md", "expand_includes": true, "if_none_match": out.Hash})
+		assert.True(t, again.NotModified)
+	})
+
+	t.Run("read_docs", func(t *testing.T) {
+		res, err := srv.readDocs(ctx, ReadDocsInput{Docs: []ReadDocsItem{
+			{Path: "ops/guide.md", IfNoneMatch: first.Hash},
+			{Path: "ops/part.md", IfNoneMatch: first.Hash},
+			{Path: "part", IfNoneMatch: scanner.HashContent([]byte("part"))},
+		}})
+		require.NoError(t, err)
+		assert.True(t, res.Results[0].Doc.NotModified)
+		assert.Equal(t, "part", res.Results[1].Doc.Content)
+		assert.True(t, res.Results[2].Doc.NotModified)
+		assert.Equal(t, 4, res.Bytes)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(guide, []byte("# Guide v2\n"), 0600))
+		out := read(t, map[string]any{"path": "ops/guide.md", "if_none_match": first.Hash})
+		assert.False(t, out.NotModified)
+		assert.Equal(t, "# Guide v2\n", out.Content)
+		assert.Equal(t, scanner.HashContent([]byte("# Guide v2\n")), out.Hash)
+	})
+}
+
+func TestNotModified(t *testing.T) {
+	doc := &ReadOutput{Path: "guide.md", Content: "text", Size: 4, Source: "project-docs", Revision: "abc",
+		Includes: []string{"project-docs:part.md"}, ResolvedFrom: "guid", Hash: "h1", ModTime: "2026-01-02T03:04:05Z"}
+	assert.Equal(t, &ReadOutput{Path: "guide.md", Source: "project-docs", Revision: "abc", ResolvedFrom: "guid", Hash: "h1",
+		ModTime: "2026-01-02T03:04:05Z", NotModified: true}, notModified(doc))
+}
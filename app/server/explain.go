@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/query"
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// kinds of file name match against the whole query
+const (
+	nameMatchExact     = "exact"
+	nameMatchSubstring = "substring"
+	nameMatchFuzzy     = "fuzzy"
+	nameMatchNone      = "none"
+)
+
+// ScoreExplanation is a breakdown of the search score of a doc
+type ScoreExplanation struct {
+	Name       *NameScore  `json:"name,omitempty"`        // plain query as a whole against file name and frontmatter
+	Terms      *TermsScore `json:"terms,omitempty"`       // plain query of several terms, term by term
+	QueryTerms []NameScore `json:"query_terms,omitempty"` // unqualified terms of a query with syntax
+	Qualifiers []string    `json:"qualifiers,omitempty"`  // qualifiers of a query with syntax matched by the doc
+	Score      float64     `json:"score"`
+}
+
+// NameScore explains score of the file name and frontmatter against the query or a query term
+type NameScore struct {
+	Query            string     `json:"query"`
+	Match            string     `json:"match"`               // exact, substring, fuzzy or none
+	Base             float64    `json:"base"`                // score of the file name match
+	FuzzyRaw         int        `json:"fuzzy_raw,omitempty"` // score of the fuzzy matcher, used if at least 100*fuzzy threshold
+	DescriptionBoost float64    `json:"description_boost,omitempty"`
+	TagBoosts        []TagBoost `json:"tag_boosts,omitempty"`
+	Boost            float64    `json:"boost"`            // frontmatter boost after the cap
+	BoostCap         float64    `json:"boost_cap"`        // max frontmatter boost
+	Capped           bool       `json:"capped,omitempty"` // frontmatter boost was cut to the cap
+	Score            float64    `json:"score"`            // base plus boost
+}
+
+// TagBoost is a boost of a single tag matching the query
+type TagBoost struct {
+	Tag   string  `json:"tag"`
+	Match string  `json:"match"` // exact or substring
+	Boost float64 `json:"boost"`
+}
+
+// TermsScore explains score of the query scored term by term, details of each term are in SearchMatch.Terms
+type TermsScore struct {
+	Matched  int     `json:"matched"`
+	Total    int     `json:"total"`
+	Average  float64 `json:"average"`  // sum of term scores divided by the number of terms
+	Coverage float64 `json:"coverage"` // share of matched terms
+	Score    float64 `json:"score"`    // average multiplied by coverage
+}
+
+// explainScore returns breakdown of the search score of the file, repeating the scoring done by searchDocs
+func (s *Server) explainScore(p ranking.Profile, q *query.Query, terms []string, filenameQuery, frontmatterQuery string,
+	file scanner.FileInfo) *ScoreExplanation {
+	if !q.Plain() {
+		res := &ScoreExplanation{Score: s.scoreQuery(p, q, file)}
+		for _, t := range q.Terms() {
+			if t.Field == "" {
+				normalized := strings.ToLower(t.Value)
+				res.QueryTerms = append(res.QueryTerms, s.scoreName(p, strings.ReplaceAll(normalized, " ", "-"), normalized, file))
+				continue
+			}
+			if matchField(t, file) {
+				res.Qualifiers = append(res.Qualifiers, t.Field+":"+t.Value)
+			}
+		}
+		return res
+	}
+
+	name := s.scoreName(p, filenameQuery, frontmatterQuery, file)
+	res := &ScoreExplanation{Name: &name, Score: name.Score}
+	if len(terms) > 1 {
+		score, matched := s.scoreTerms(p, terms, file)
+		ts := &TermsScore{Matched: len(matched), Total: len(terms), Score: score,
+			Coverage: float64(len(matched)) / float64(len(terms))}
+		if ts.Coverage > 0 {
+			ts.Average = score / ts.Coverage
+		}
+		res.Terms = ts
+		res.Score = max(res.Score, score)
+	}
+	return res
+}
+
+// WriteText writes search results as text, with score breakdown of each result if explained
+func (o *SearchOutput) WriteText(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	for i, m := range o.Results {
+		fmt.Fprintf(b, "%2d. %s  %.2f\n", i+1, m.Path, m.Score)
+		if m.Explain == nil {
+			continue
+		}
+		if m.Explain.Name != nil {
+			fmt.Fprintf(b, "    name: %s\n", m.Explain.Name.text())
+		}
+		if ts := m.Explain.Terms; ts != nil {
+			fmt.Fprintf(b, "    terms: %d of %d matched, average %.2f, coverage %.2f, score %.2f\n",
+				ts.Matched, ts.Total, ts.Average, ts.Coverage, ts.Score)
+			for _, t := range m.Terms {
+				fmt.Fprintf(b, "      %s: %s %.2f\n", t.Term, strings.Join(t.In, ", "), t.Score)
+			}
+		}
+		for _, ns := range m.Explain.QueryTerms {
+			fmt.Fprintf(b, "    term: %s\n", ns.text())
+		}
+		if len(m.Explain.Qualifiers) > 0 {
+			fmt.Fprintf(b, "    qualifiers: %s\n", strings.Join(m.Explain.Qualifiers, " "))
+		}
+	}
+	fmt.Fprintf(b, "%d of %d matches shown\n", len(o.Results), o.Total)
+	if err := b.Flush(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// text returns name score breakdown in a single line
+func (ns NameScore) text() string {
+	parts := []string{fmt.Sprintf("%q %s %.2f", ns.Query, ns.Match, ns.Base)}
+	if ns.FuzzyRaw > 0 {
+		parts = append(parts, fmt.Sprintf("fuzzy raw %d", ns.FuzzyRaw))
+	}
+	if ns.DescriptionBoost > 0 {
+		parts = append(parts, fmt.Sprintf("description +%.2f", ns.DescriptionBoost))
+	}
+	for _, tb := range ns.TagBoosts {
+		parts = append(parts, fmt.Sprintf("tag %q %s +%.2f", tb.Tag, tb.Match, tb.Boost))
+	}
+	if ns.Capped {
+		parts = append(parts, fmt.Sprintf("boost capped at %.2f", ns.BoostCap))
+	}
+	return strings.Join(parts, ", ") + fmt.Sprintf(", score %.2f", ns.Score)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+func TestServer_SearchDocsExplain(t *testing.T) {
+	files := map[string]string{
+		"commands/testing.md":     "---\ntags: [Testing, go-testing]\ndescription: testing in go\n---\n",
+		"docs/go-testing.md":      "---\ntags: [testing, go]\n---\n",
+		"docs/commit-message.md":  "---\ndescription: style of messages\n---\n",
+		"docs/tst-fixtures.md":    "# Fixtures\n",
+		"docs/architecture.md":    "# Architecture\n",
+		"docs/release-process.md": "---\ntags: [ops]\n---\n",
+	}
+
+	srv, _ := newTestServer(t, files, Config{})
+
+	search := func(t *testing.T, q string) map[string]SearchMatch {
+		t.Helper()
+		res, err := srv.searchDocs(context.Background(), q, searchOptions{explain: true})
+		require.NoError(t, err)
+		byPath := map[string]SearchMatch{}
+		for _, r := range res.Results {
+			require.NotNil(t, r.Explain, r.Path)
+			assert.InDelta(t, r.Score, r.Explain.Score, 0.0001, "explained score is the result score, %s", r.Path)
+			byPath[r.Path] = r
+		}
+		return byPath
+	}
+
+	t.Run("exact match with frontmatter boosts", func(t *testing.T) {
+		res := search(t, "testing")
+		name := res["commands:testing.md"].Explain.Name
+		require.NotNil(t, name)
+		assert.Equal(t, "exact", name.Match)
+		assert.InDelta(t, 1.0, name.Base, 0.0001)
+		assert.InDelta(t, 0.5, name.DescriptionBoost, 0.0001)
+		assert.Equal(t, []TagBoost{{Tag: "Testing", Match: "exact", Boost: 0.3}, {Tag: "go-testing", Match: "substring", Boost: 0.15}},
+			name.TagBoosts)
+		assert.InDelta(t, 0.95, name.Boost, 0.0001)
+		assert.InDelta(t, 1.0, name.BoostCap, 0.0001)
+		assert.False(t, name.Capped)
+		assert.Nil(t, res["commands:testing.md"].Explain.Terms)
+
+		name = res["project-docs:go-testing.md"].Explain.Name
+		assert.Equal(t, "substring", name.Match)
+		assert.InDelta(t, 0.8*7/13, name.Base, 0.0001)
+	})
+
+	t.Run("fuzzy match", func(t *testing.T) {
+		res := search(t, "tstfix")
+		name := res["project-docs:tst-fixtures.md"].Explain.Name
+		require.NotNil(t, name)
+		assert.Equal(t, "fuzzy", name.Match)
+		assert.Positive(t, name.FuzzyRaw)
+		assert.InDelta(t, min(float64(name.FuzzyRaw)/100, 1)*0.7, name.Base, 0.0001)
+	})
+
+	t.Run("terms", func(t *testing.T) {
+		res := search(t, "message style commit")
+		m := res["project-docs:commit-message.md"]
+		require.NotNil(t, m.Explain.Terms)
+		assert.Equal(t, TermsScore{Matched: 3, Total: 3, Average: 1, Coverage: 1, Score: 1}, *m.Explain.Terms)
+		assert.Equal(t, "none", m.Explain.Name.Match)
+		assert.Len(t, m.Terms, 3)
+	})
+
+	t.Run("query with syntax", func(t *testing.T) {
+		res := search(t, "tag:ops release -source:commands")
+		m := res["project-docs:release-process.md"]
+		assert.Nil(t, m.Explain.Name)
+		assert.Equal(t, []string{"tag:ops"}, m.Explain.Qualifiers)
+		require.Len(t, m.Explain.QueryTerms, 1)
+		assert.Equal(t, "release", m.Explain.QueryTerms[0].Query)
+		assert.Equal(t, "substring", m.Explain.QueryTerms[0].Match)
+	})
+
+	t.Run("not explained by default", func(t *testing.T) {
+		res, err := srv.searchDocs(context.Background(), "testing", searchOptions{})
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Results)
+		for _, r := range res.Results {
+			assert.Nil(t, r.Explain)
+		}
+	})
+
+	t.Run("tool", func(t *testing.T) {
+		ctx := context.Background()
+		session := newTestSession(t, srv)
+
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "search_docs",
+			Arguments: map[string]any{"query": "architecture", "explain": true}})
+		require.NoError(t, err)
+		require.False(t, res.IsError)
+		var out SearchOutput
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+		require.NotEmpty(t, out.Results)
+		require.NotNil(t, out.Results[0].Explain)
+		assert.Equal(t, "exact", out.Results[0].Explain.Name.Match)
+	})
+}
+
+func TestServer_FrontmatterBoostCapped(t *testing.T) {
+	srv := &Server{}
+	var ns NameScore
+	srv.frontmatterBoost(ranking.Default(), "test", scanner.FileInfo{Description: "test description",
+		Tags: []string{"test", "testing", "tester", "tested"}}, &ns)
+	assert.True(t, ns.Capped)
+	assert.InDelta(t, 1.0, ns.Boost, 0.0001)
+	assert.Len(t, ns.TagBoosts, 4)
+}
+
+func TestSearchOutput_WriteText(t *testing.T) {
+	out := SearchOutput{Total: 3, Results: []SearchMatch{
+		{Path: "commands:testing.md", Score: 1.8, Explain: &ScoreExplanation{Score: 1.8, Name: &NameScore{Query: "testing",
+			Match: "exact", Base: 1, DescriptionBoost: 0.5, TagBoosts: []TagBoost{{Tag: "testing", Match: "exact", Boost: 0.3}},
+			Boost: 0.8, BoostCap: 1, Score: 1.8}}},
+		{Path: "project-docs:commit-message.md", Score: 0.5, Terms: []TermMatch{{Term: "commit", In: []string{"path"}, Score: 1}},
+			Explain: &ScoreExplanation{Score: 0.5, Name: &NameScore{Query: "commit style", Match: "none", FuzzyRaw: 12, BoostCap: 1},
+				Terms: &TermsScore{Matched: 1, Total: 2, Average: 0.5, Coverage: 0.5, Score: 0.25}}},
+		{Path: "project-docs:ops.md", Score: 1, Explain: &ScoreExplanation{Score: 1, Qualifiers: []string{"tag:ops"},
+			QueryTerms: []NameScore{{Query: "ops", Match: "exact", Base: 1, Boost: 1, BoostCap: 1, Capped: true, Score: 2}}}},
+	}}
+	var buf bytes.Buffer
+	require.NoError(t, out.WriteText(&buf))
+	assert.Equal(t, ` 1. commands:testing.md  1.80
+    name: "testing" exact 1.00, description +0.50, tag "testing" exact +0.30, score 1.80
+ 2. project-docs:commit-message.md  0.50
+    name: "commit style" none 0.00, fuzzy raw 12, score 0.00
+    terms: 1 of 2 matched, average 0.50, coverage 0.50, score 0.25
+      commit: path 1.00
+ 3. project-docs:ops.md  1.00
+    term: "ops" exact 1.00, boost capped at 1.00, score 2.00
+    qualifiers: tag:ops
+3 of 3 matches shown
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, (&SearchOutput{Results: []SearchMatch{{Path: "a.md", Score: 0.5}}, Total: 1}).WriteText(&buf))
+	assert.Equal(t, " 1. a.md  0.50\n1 of 1 matches shown\n", buf.String())
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/markdown"
+)
+
+// extract kinds
+const (
+	extractCode      = "code"
+	extractTable     = "table"
+	extractChecklist = "checklist"
+	extractLinks     = "links"
+)
+
+// ExtractInput represents input for extracting structured elements from a doc
+type ExtractInput struct {
+	Path     string  `json:"path"`
+	Source   *string `json:"source,omitempty"`
+	Kind     string  `json:"kind"`               // code, table, checklist or links
+	Language *string `json:"language,omitempty"` // filters code blocks by language, case-insensitive
+	Project  string  `json:"project,omitempty"`
+}
+
+// ExtractOutput contains elements of the requested kind, in order of appearance
+type ExtractOutput struct {
+	Path       string                   `json:"path"`
+	Source     string                   `json:"source"`
+	Kind       string                   `json:"kind"`
+	CodeBlocks []markdown.CodeBlock     `json:"code_blocks,omitempty"`
+	Tables     []markdown.Table         `json:"tables,omitempty"`
+	Checklist  []markdown.ChecklistItem `json:"checklist,omitempty"`
+	Links      []markdown.Link          `json:"links,omitempty"`
+	Total      int                      `json:"total"`
+}
+
+// extract reads the doc and returns its elements of the given kind
+func (s *Server) extract(ctx context.Context, input ExtractInput) (*ExtractOutput, error) {
+	switch input.Kind {
+	case extractCode, extractTable, extractChecklist, extractLinks:
+	default:
+		return nil, fmt.Errorf("invalid kind %q, expected one of code, table, checklist, links", input.Kind)
+	}
+
+	doc, err := s.readDoc(ctx, input.Path, input.Source)
+	if err != nil {
+		return nil, err
+	}
+	parsed := markdown.Parse(doc.Content)
+
+	res := &ExtractOutput{Path: doc.Path, Source: doc.Source, Kind: input.Kind}
+	switch input.Kind {
+	case extractCode:
+		res.CodeBlocks = []markdown.CodeBlock{}
+		for _, b := range parsed.CodeBlocks {
+			if input.Language == nil || *input.Language == "" || strings.EqualFold(b.Language, *input.Language) {
+				res.CodeBlocks = append(res.CodeBlocks, b)
+			}
+		}
+		res.Total = len(res.CodeBlocks)
+	case extractTable:
+		res.Tables, res.Total = parsed.Tables, len(parsed.Tables)
+	case extractChecklist:
+		res.Checklist, res.Total = parsed.Checklist, len(parsed.Checklist)
+	case extractLinks:
+		res.Links, res.Total = parsed.Links, len(parsed.Links)
+	}
+	return res, nil
+}
+
+// handleExtract handles extract tool calls
+func (s *Server) handleExtract(ctx context.Context, _ *mcp.CallToolRequest, input ExtractInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("extract called", "path", input.Path, "kind", input.Kind, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.extract(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extract failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/markdown"
+)
+
+func TestServer_Extract(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	content := "---\ndescription: runbook\n---\n# Runbook\n\n```bash\nmake run\n```\n\n```Go\nfunc main() {}\n```\n\n" +
+		"## Ports\n\n| Name | Port |\n|---|---|\n| api | 8080 |\n\n## Release\n\n- [x] tag\n- [ ] publish, see [guide](guide.md)\n"
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "runbook.md"), []byte(content), 0600))
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+	ctx := context.Background()
+	lang := func(s string) *string { return &s }
+
+	t.Run("code", func(t *testing.T) {
+		res, err := srv.extract(ctx, ExtractInput{Path: "project-docs:runbook.md", Kind: "code"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.Total)
+		assert.Equal(t, "runbook.md", res.Path)
+		assert.Equal(t, "project-docs", res.Source)
+		assert.Equal(t, markdown.CodeBlock{Language: "bash", Content: "make run\n", Section: "Runbook", Line: 3}, res.CodeBlocks[0])
+
+		res, err = srv.extract(ctx, ExtractInput{Path: "runbook", Kind: "code", Language: lang("go")})
+		require.NoError(t, err)
+		require.Len(t, res.CodeBlocks, 1)
+		assert.Equal(t, "func main() {}\n", res.CodeBlocks[0].Content)
+
+		res, err = srv.extract(ctx, ExtractInput{Path: "runbook", Kind: "code", Language: lang("rust")})
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Total)
+		assert.NotNil(t, res.CodeBlocks)
+	})
+
+	t.Run("table, checklist and links", func(t *testing.T) {
+		res, err := srv.extract(ctx, ExtractInput{Path: "runbook", Kind: "table"})
+		require.NoError(t, err)
+		require.Len(t, res.Tables, 1)
+		assert.Equal(t, [][]string{{"api", "8080"}}, res.Tables[0].Rows)
+		assert.Equal(t, "Ports", res.Tables[0].Section)
+
+		res, err = srv.extract(ctx, ExtractInput{Path: "runbook", Kind: "checklist"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.Total)
+		assert.True(t, res.Checklist[0].Checked)
+		assert.Equal(t, "Release", res.Checklist[1].Section)
+
+		res, err = srv.extract(ctx, ExtractInput{Path: "runbook", Kind: "links"})
+		require.NoError(t, err)
+		assert.Equal(t, []markdown.Link{{Text: "guide", URL: "guide.md", Section: "Release", Line: 20}}, res.Links)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := srv.extract(ctx, ExtractInput{Path: "runbook", Kind: "images"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid kind")
+		_, err = srv.extract(ctx, ExtractInput{Path: "missing", Kind: "code"})
+		require.Error(t, err)
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		result, _, err := srv.handleExtract(ctx, &mcp.CallToolRequest{}, ExtractInput{Path: "runbook", Kind: "table"})
+		require.NoError(t, err)
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &raw))
+		assert.Contains(t, raw, "tables")
+		assert.NotContains(t, raw, "code_blocks")
+	})
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/gaps"
+	"github.com/umputun/local-docs-mcp/app/query"
+	"github.com/umputun/local-docs-mcp/app/ranking"
+)
+
+const (
+	// maxNearMisses is the number of closest docs kept for a gap
+	maxNearMisses = 3
+	// defaultGapsLimit is the number of gaps returned by doc_gaps if limit is not set
+	defaultGapsLimit = 20
+)
+
+// GapsInput represents input for doc_gaps tool
+type GapsInput struct {
+	Limit int `json:"limit,omitempty"`
+}
+
+// GapsOutput contains the most frequent unmet queries
+type GapsOutput struct {
+	Gaps  []gaps.Gap `json:"gaps"`
+	Total int        `json:"total"` // number of recorded gaps
+}
+
+// recordGap records search query if it found nothing or its best match scored below the threshold.
+// the store saves changes in background, failures to save are logged by it.
+func (s *Server) recordGap(ctx context.Context, q string, result *SearchOutput) {
+	if s.gaps == nil || strings.TrimSpace(q) == "" {
+		return
+	}
+
+	var topScore float64
+	if len(result.Results) > 0 {
+		topScore = result.Results[0].Score
+	}
+	if len(result.Results) > 0 && topScore >= s.config.GapsThreshold {
+		return
+	}
+
+	var nearMisses []gaps.NearMiss
+	for _, m := range result.Results {
+		if len(nearMisses) == maxNearMisses {
+			break
+		}
+		nearMisses = append(nearMisses, gaps.NearMiss{Path: m.Path, Score: m.Score})
+	}
+	if len(nearMisses) == 0 {
+		nearMisses = s.nearMisses(ctx, q)
+	}
+
+	s.gaps.Record(q, topScore, nearMisses)
+}
+
+// nearMisses returns docs best matching any single unqualified term of the query, for queries which matched
+// nothing as a whole. qualifiers, operators and negated terms are not matched on their own.
+func (s *Server) nearMisses(ctx context.Context, q string) []gaps.NearMiss {
+	parsed, err := query.Parse(q)
+	if err != nil {
+		return nil
+	}
+	files, err := s.scanner.Scan(ctx)
+	if err != nil {
+		return nil
+	}
+
+	var terms []string
+	for _, t := range parsed.Terms() {
+		if t.Field == "" && len(t.Value) >= 3 { // short terms fuzzy-match almost everything
+			terms = append(terms, strings.ToLower(t.Value))
+		}
+	}
+	if len(terms) == 0 || (len(terms) == 1 && parsed.Plain()) {
+		return nil // single plain term is the query itself, which matched nothing
+	}
+
+	profile, _ := ranking.Select(s.config.RankingProfiles, "") // the default profile is always available
+	var res []gaps.NearMiss
+	for _, f := range files {
+		var best float64
+		for _, t := range terms {
+			best = max(best, s.calculateScore(profile, t, t, f))
+		}
+		if best > 0 {
+			res = append(res, gaps.NearMiss{Path: f.Filename, Score: best})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].Path < res[j].Path
+	})
+	if len(res) > maxNearMisses {
+		res = res[:maxNearMisses]
+	}
+	return res
+}
+
+// registerGapsTools registers doc_gaps tool if gaps recording is enabled
+func (s *Server) registerGapsTools() {
+	if s.gaps == nil {
+		return
+	}
+
+	addTool(s, &mcp.Tool{
+		Name: "doc_gaps",
+		Description: "Show the most frequent search queries which found no docs or only weak matches, with the closest docs " +
+			"for each. Use it to decide which documentation to write next.",
+	}, s.handleDocGaps)
+}
+
+// handleDocGaps handles doc_gaps tool calls
+func (s *Server) handleDocGaps(_ context.Context, _ *mcp.CallToolRequest, input GapsInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("doc_gaps called", "limit", input.Limit)
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultGapsLimit
+	}
+	result := &GapsOutput{Gaps: s.gaps.Top(limit), Total: s.gaps.Len()}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/gaps"
+)
+
+func TestServer_DocGaps(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "deployment.md"), []byte("# Deployment\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "testing.md"), []byte("# Testing\n"), 0600))
+	gapsPath := filepath.Join(tmpDir, "gaps.json")
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server",
+		GapsFile: gapsPath, GapsThreshold: 0.5})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+
+	for _, q := range []string{"deployment", "kubernetes deployment rollback", "Kubernetes Deployment rollback", "deploy", "zzzzqqq", ""} {
+		_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "search_docs", Arguments: map[string]any{"query": q}})
+		require.NoError(t, err)
+	}
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "doc_gaps", Arguments: map[string]any{}})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	var out GapsOutput
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+
+	assert.Equal(t, 3, out.Total, "exact match and empty query are not gaps")
+	require.Len(t, out.Gaps, 3)
+	assert.Equal(t, "kubernetes deployment rollback", out.Gaps[0].Query)
+	assert.Equal(t, 2, out.Gaps[0].Count)
+	assert.InDelta(t, 1.0/3*1/3, out.Gaps[0].TopScore, 1e-9, "exact match of one of three terms, weighted by coverage")
+	require.NotEmpty(t, out.Gaps[0].NearMisses, "near misses by single terms")
+	assert.Equal(t, "project-docs:deployment.md", out.Gaps[0].NearMisses[0].Path)
+
+	byQuery := map[string]gaps.Gap{}
+	for _, g := range out.Gaps {
+		byQuery[g.Query] = g
+	}
+	weak := byQuery["deploy"]
+	assert.InDelta(t, 0.8*6/13, weak.TopScore, 1e-9, "weak substring match is recorded with its score")
+	assert.Equal(t, "project-docs:deployment.md", weak.NearMisses[0].Path)
+	assert.Empty(t, byQuery["zzzzqqq"].NearMisses)
+
+	res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "doc_gaps", Arguments: map[string]any{"limit": 1}})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+	assert.Len(t, out.Gaps, 1)
+	assert.Equal(t, 3, out.Total)
+
+	// gaps are persisted on close
+	require.NoError(t, srv.Close())
+	store, err := gaps.Open(gapsPath, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, store.Len())
+}
+
+func TestServer_NearMisses(t *testing.T) {
+	docsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "deployment.md"), []byte("# Deployment\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "kafka.md"), []byte("# Kafka\n"), 0600))
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+	ctx := context.Background()
+
+	paths := func(misses []gaps.NearMiss) []string {
+		var res []string
+		for _, m := range misses {
+			res = append(res, m.Path)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"project-docs:deployment.md", "project-docs:kafka.md"},
+		paths(srv.nearMisses(ctx, "deployment kafka")))
+	assert.Equal(t, []string{"project-docs:deployment.md"}, paths(srv.nearMisses(ctx, "tag:kafka deployment")),
+		"qualified terms are not matched on their own")
+	assert.Equal(t, []string{"project-docs:deployment.md"}, paths(srv.nearMisses(ctx, "deployment -kafka")),
+		"negated terms are not matched")
+	assert.Empty(t, srv.nearMisses(ctx, "deployment"), "single plain term is the query itself")
+	assert.Empty(t, srv.nearMisses(ctx, "tag:kafka source:project-docs"), "no unqualified terms")
+	assert.Empty(t, srv.nearMisses(ctx, "(deployment"), "invalid query")
+}
+
+func TestServer_DocGapsDisabled(t *testing.T) {
+	srv, err := New(Config{MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+	assert.Nil(t, srv.gaps)
+	srv.recordGap(context.Background(), "query", &SearchOutput{}) // no-op without the store
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+	tools, err := session.ListTools(ctx, nil)
+	require.NoError(t, err)
+	for _, tool := range tools.Tools {
+		assert.NotEqual(t, "doc_gaps", tool.Name)
+	}
+
+	bad := filepath.Join(t.TempDir(), "gaps.json")
+	require.NoError(t, os.WriteFile(bad, []byte("{not json"), 0600))
+	_, err = New(Config{MaxFileSize: 1024, ServerName: "test-server", GapsFile: bad})
+	require.Error(t, err)
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/gitrepo"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+const (
+	// defaultHistoryLimit is the number of commits returned by doc_history if limit is not set
+	defaultHistoryLimit = 20
+	// maxHistoryLimit is the maximum number of commits returned by doc_history
+	maxHistoryLimit = 100
+	// diffContextLines is the number of unchanged lines around changes in doc_diff output
+	diffContextLines = 3
+	// workingTree is reported as the "to" side of diffs against files on disk
+	workingTree = "working-tree"
+)
+
+// DocHistoryInput represents input for listing commits of a documentation file
+type DocHistoryInput struct {
+	Path     string  `json:"path"`
+	Source   *string `json:"source,omitempty"`
+	Revision string  `json:"revision,omitempty"` // start revision, HEAD (or ref of git source) if empty
+	Limit    int     `json:"limit,omitempty"`
+	Project  string  `json:"project,omitempty"`
+}
+
+// CommitInfo is a commit which changed a documentation file
+type CommitInfo struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Email   string `json:"email"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+// DocHistoryOutput contains commits touching a documentation file, newest first
+type DocHistoryOutput struct {
+	Path    string       `json:"path"` // path inside the repository
+	Commits []CommitInfo `json:"commits"`
+	Total   int          `json:"total"`
+}
+
+// ReadAtInput represents input for reading a documentation file at a revision
+type ReadAtInput struct {
+	Path     string  `json:"path"`
+	Source   *string `json:"source,omitempty"`
+	Revision string  `json:"revision"`
+	Project  string  `json:"project,omitempty"`
+}
+
+// DocDiffInput represents input for diff of a documentation file between revisions
+type DocDiffInput struct {
+	Path    string  `json:"path"`
+	Source  *string `json:"source,omitempty"`
+	From    string  `json:"from"`
+	To      string  `json:"to,omitempty"` // working tree (or ref of git source) if empty
+	Project string  `json:"project,omitempty"`
+}
+
+// DocDiffOutput contains unified diff of a documentation file
+type DocDiffOutput struct {
+	Path    string `json:"path"` // path inside the repository
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff"`
+	// Redactions counts secrets masked in the diff, by rule
+	Redactions map[string]int `json:"redactions,omitempty"`
+
+	doc string // doc name with source prefix, recorded in the audit log
+}
+
+// docLocation is a documentation file located in a git repository
+type docLocation struct {
+	repo    *gitrepo.Repo
+	relPath string         // slash-separated path inside the repository
+	absPath string         // file in the working tree, empty for git sources
+	head    string         // default revision, HEAD or ref of git source
+	path    string         // doc path as returned by read_doc
+	source  scanner.Source // source of the doc
+}
+
+// locateDoc finds the repository and path inside it for a documentation file.
+// files of directory sources must be inside a git working tree, git source files use their repository.
+func (s *Server) locateDoc(ctx context.Context, docPath string, source *string) (*docLocation, error) {
+	sourceStr, cleanPath := parseDocPath(docPath, source)
+
+	if strings.Contains(sourceStr, "@") {
+		g, ok := s.gitSource(sourceStr)
+		if !ok {
+			return nil, fmt.Errorf("unknown git source: %s", sourceStr)
+		}
+		p, err := scanner.CleanUserPath(cleanPath)
+		if err != nil {
+			return nil, err // nolint:wrapcheck // path error is descriptive
+		}
+		repo, err := s.openRepo(g.Repo)
+		if err != nil {
+			return nil, err
+		}
+		return &docLocation{repo: repo, relPath: path.Join(g.Subdir, filepath.ToSlash(p)), head: g.Ref,
+			path: sourceStr + ":" + filepath.ToSlash(p), source: scanner.SourceGit}, nil
+	}
+
+	absPath, src, err := s.resolveDocFile(ctx, sourceStr, cleanPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// compare real paths, temp and home directories are often behind symlinks
+	realPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", absPath, err)
+	}
+	root, err := gitrepo.FindRepoRoot(filepath.Dir(realPath))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository: %w", cleanPath, err)
+	}
+	relPath, err := filepath.Rel(root, realPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get path in repository: %w", err)
+	}
+	repo, err := s.openRepo(root)
+	if err != nil {
+		return nil, err
+	}
+	return &docLocation{repo: repo, relPath: filepath.ToSlash(relPath), absPath: absPath, head: "HEAD",
+		path: cleanPath, source: src}, nil
+}
+
+// gitSource finds configured git source by "name@ref" prefix
+func (s *Server) gitSource(prefix string) (scanner.GitSource, bool) {
+	for _, g := range s.config.GitSources {
+		if g.Prefix() == prefix {
+			return g, true
+		}
+	}
+	return scanner.GitSource{}, false
+}
+
+// openRepo returns repository opened at path, reusing already opened ones
+func (s *Server) openRepo(repoPath string) (*gitrepo.Repo, error) {
+	s.reposMu.Lock()
+	defer s.reposMu.Unlock()
+	if repo, ok := s.repos[repoPath]; ok {
+		return repo, nil
+	}
+	repo, err := gitrepo.Open(repoPath)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // gitrepo error is descriptive
+	}
+	if s.repos == nil {
+		s.repos = map[string]*gitrepo.Repo{}
+	}
+	s.repos[repoPath] = repo
+	return repo, nil
+}
+
+// closeRepos closes all repositories opened for history tools
+func (s *Server) closeRepos() error {
+	s.reposMu.Lock()
+	defer s.reposMu.Unlock()
+	var errs []error
+	for p, repo := range s.repos {
+		if err := repo.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(s.repos, p)
+	}
+	return errors.Join(errs...)
+}
+
+// docHistory returns commits which changed the documentation file
+func (s *Server) docHistory(ctx context.Context, input DocHistoryInput) (*DocHistoryOutput, error) {
+	loc, err := s.locateDoc(ctx, input.Path, input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := input.Revision
+	if rev == "" {
+		rev = loc.head
+	}
+	start, err := loc.repo.ResolveRevision(rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve revision %q: %w", rev, err)
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	limit = min(limit, maxHistoryLimit)
+
+	commits, err := loc.repo.Log(ctx, start, loc.relPath, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	res := &DocHistoryOutput{Path: loc.relPath, Commits: make([]CommitInfo, 0, len(commits))}
+	for _, c := range commits {
+		res.Commits = append(res.Commits, CommitInfo{
+			Hash:    c.Hash.String(),
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Date:    c.Author.When.Format(time.RFC3339),
+			Subject: c.Subject(),
+		})
+	}
+	res.Total = len(res.Commits)
+	return res, nil
+}
+
+// readDocAt reads documentation file content at the given revision, frontmatter is stripped as in readDoc
+func (s *Server) readDocAt(ctx context.Context, docPath string, source *string, revision string) (*ReadOutput, error) {
+	if revision == "" {
+		return nil, errors.New("revision is required")
+	}
+	loc, err := s.locateDoc(ctx, docPath, source)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, content, err := s.contentAt(loc, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	// strip frontmatter from content
+	_, strippedContent := scanner.ParseFrontmatter(content)
+
+	res := &ReadOutput{
+		Path:     loc.path,
+		Content:  string(strippedContent),
+		Size:     len(strippedContent),
+		Source:   string(loc.source),
+		Revision: hash,
+		Hash:     scanner.HashContent(content),
+	}
+	s.redactDoc(res)
+	return res, nil
+}
+
+// docDiff returns unified diff of the documentation file between two revisions,
+// or between a revision and the working tree if "to" is empty
+func (s *Server) docDiff(ctx context.Context, input DocDiffInput) (*DocDiffOutput, error) {
+	if input.From == "" {
+		return nil, errors.New("from revision is required")
+	}
+	loc, err := s.locateDoc(ctx, input.Path, input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	from, oldContent, err := s.contentAt(loc, input.From)
+	if err != nil && !errors.Is(err, gitrepo.ErrNotFound) {
+		return nil, err
+	}
+	fromMissing := err != nil
+
+	var to string
+	var newContent []byte
+	toMissing := false
+	switch {
+	case input.To == "" && loc.absPath != "":
+		to = workingTree
+		// #nosec G304 - path is validated by SafeResolvePath
+		if newContent, err = os.ReadFile(loc.absPath); err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+	default:
+		rev := input.To
+		if rev == "" {
+			rev = loc.head
+		}
+		to, newContent, err = s.contentAt(loc, rev)
+		if err != nil && !errors.Is(err, gitrepo.ErrNotFound) {
+			return nil, err
+		}
+		toMissing = err != nil
+	}
+	if fromMissing && toMissing {
+		return nil, fmt.Errorf("file %s not found in both revisions", loc.relPath)
+	}
+
+	diff := gitrepo.UnifiedDiff("a/"+loc.relPath, "b/"+loc.relPath, oldContent, newContent, diffContextLines)
+	res := &DocDiffOutput{Path: loc.relPath, From: from, To: to, Changed: diff != "", doc: docName(string(loc.source), loc.path)}
+	res.Diff, res.Redactions = s.redactText(diff)
+	return res, nil
+}
+
+// contentAt returns resolved commit hash and raw file content at the revision.
+// the hash is returned even if the file doesn't exist in that revision, error wraps gitrepo.ErrNotFound then.
+func (s *Server) contentAt(loc *docLocation, revision string) (string, []byte, error) {
+	hash, err := loc.repo.ResolveRevision(revision)
+	if err != nil {
+		// not wrapped, unknown revision is an error and not a missing file
+		return "", nil, fmt.Errorf("failed to resolve revision %q: %s", revision, err.Error())
+	}
+	content, err := loc.repo.ReadFileAt(hash, loc.relPath)
+	if err != nil {
+		return hash.String(), nil, fmt.Errorf("%s at %s: %w", loc.relPath, revision, err)
+	}
+	if int64(len(content)) > s.config.MaxFileSize {
+		return "", nil, fmt.Errorf("file too large: %d bytes (max %d)", len(content), s.config.MaxFileSize)
+	}
+	return hash.String(), content, nil
+}
+
+// registerHistoryTools registers tools reading documentation history from git
+func (s *Server) registerHistoryTools() {
+	addTool(s, &mcp.Tool{
+		Name: "doc_history",
+		Description: "List commits that changed a documentation file (hash, author, date, subject), newest first. " +
+			"Works for docs inside a git working tree and for git sources. Optional revision to start from and limit (default 20, max 100).",
+	}, s.handleDocHistory)
+
+	addTool(s, &mcp.Tool{
+		Name:        "read_doc_at",
+		Description: "Read a documentation file as it was at a git revision (commit hash, branch, tag, HEAD~N).",
+	}, s.handleReadDocAt)
+
+	addTool(s, &mcp.Tool{
+		Name: "doc_diff",
+		Description: "Unified diff of a documentation file between two git revisions. " +
+			"If 'to' is omitted, compares with the current file in the working tree.",
+	}, s.handleDocDiff)
+}
+
+// handleDocHistory handles doc_history tool calls
+func (s *Server) handleDocHistory(ctx context.Context, _ *mcp.CallToolRequest, input DocHistoryInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("doc_history called", "path", input.Path, "source", input.Source, "revision", input.Revision, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.docHistory(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("history failed: %w", err)
+	}
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
+
+// handleReadDocAt handles read_doc_at tool calls
+func (s *Server) handleReadDocAt(ctx context.Context, _ *mcp.CallToolRequest, input ReadAtInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("read_doc_at called", "path", input.Path, "source", input.Source, "revision", input.Revision, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.readDocAt(ctx, input.Path, input.Source, input.Revision)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read failed: %w", err)
+	}
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
+
+// handleDocDiff handles doc_diff tool calls
+func (s *Server) handleDocDiff(ctx context.Context, _ *mcp.CallToolRequest, input DocDiffInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("doc_diff called", "path", input.Path, "source", input.Source, "from", input.From, "to", input.To, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.docDiff(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("diff failed: %w", err)
+	}
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// historyTestRepo is a project with docs in a git repository, runbook.md has three versions
+type historyTestRepo struct {
+	dir     string
+	commits []string // hashes of commits, oldest first
+	gitCmd  func(args ...string) string
+}
+
+func newHistoryTestRepo(t *testing.T) *historyTestRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available")
+	}
+
+	r := &historyTestRepo{dir: t.TempDir()}
+	step := 0
+	r.gitCmd = func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = r.dir
+		date := fmt.Sprintf("2024-03-%02dT12:00:00Z", step+1)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane Doe", "GIT_COMMITTER_EMAIL=jane@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date, "GIT_CONFIG_NOSYSTEM=1", "HOME="+r.dir)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(content, msg string) {
+		require.NoError(t, os.WriteFile(filepath.Join(r.dir, "docs", "runbook.md"), []byte(content), 0600))
+		r.gitCmd("add", ".")
+		r.gitCmd("commit", "-q", "-m", msg)
+		r.commits = append(r.commits, r.gitCmd("rev-parse", "HEAD"))
+		step++
+	}
+
+	r.gitCmd("init", "-q", "-b", "main")
+	require.NoError(t, os.MkdirAll(filepath.Join(r.dir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, "docs", "other.md"), []byte("# Other\n"), 0600))
+	commit("---\ndescription: runbook\n---\n# Runbook\nstep 1\n", "add runbook")
+	commit("---\ndescription: runbook\n---\n# Runbook\nstep 1\nstep 2\n", "add step 2")
+	commit("---\ndescription: runbook\n---\n# Runbook\nstep one\nstep 2\n", "rename step 1\n\ndetails")
+	return r
+}
+
+func (r *historyTestRepo) server(t *testing.T) *Server {
+	t.Helper()
+	srv, err := New(Config{
+		CommandsDir:    filepath.Join(r.dir, "commands"),
+		ProjectDocsDir: filepath.Join(r.dir, "docs"),
+		MaxFileSize:    1024 * 1024,
+		ServerName:     "test-server",
+		Version:        "1.0.0",
+		GitSources:     []scanner.GitSource{{Name: "old", Repo: r.dir, Ref: r.commits[0][:8], Subdir: "docs"}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func TestServer_DocHistory(t *testing.T) {
+	r := newHistoryTestRepo(t)
+	srv := r.server(t)
+
+	t.Run("all commits", func(t *testing.T) {
+		res, err := srv.docHistory(context.Background(), DocHistoryInput{Path: "runbook.md"})
+		require.NoError(t, err)
+		assert.Equal(t, "docs/runbook.md", res.Path)
+		require.Equal(t, 3, res.Total)
+		assert.Equal(t, CommitInfo{Hash: r.commits[2], Author: "Jane Doe", Email: "jane@example.com",
+			Date: "2024-03-03T12:00:00Z", Subject: "rename step 1"}, res.Commits[0])
+		assert.Equal(t, r.commits[1], res.Commits[1].Hash)
+		assert.Equal(t, r.commits[0], res.Commits[2].Hash)
+	})
+
+	t.Run("limit and revision", func(t *testing.T) {
+		source := "project-docs"
+		res, err := srv.docHistory(context.Background(), DocHistoryInput{Path: "runbook", Source: &source, Revision: "HEAD~1", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, res.Commits, 1)
+		assert.Equal(t, r.commits[1], res.Commits[0].Hash)
+	})
+
+	t.Run("git source starts at its ref", func(t *testing.T) {
+		res, err := srv.docHistory(context.Background(), DocHistoryInput{Path: "old@" + r.commits[0][:8] + ":runbook.md"})
+		require.NoError(t, err)
+		require.Len(t, res.Commits, 1)
+		assert.Equal(t, r.commits[0], res.Commits[0].Hash)
+	})
+
+	t.Run("file not committed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(r.dir, "docs", "new.md"), []byte("# New\n"), 0600))
+		res, err := srv.docHistory(context.Background(), DocHistoryInput{Path: "new.md"})
+		require.NoError(t, err)
+		assert.Empty(t, res.Commits)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := srv.docHistory(context.Background(), DocHistoryInput{Path: "missing.md"})
+		require.Error(t, err)
+		_, err = srv.docHistory(context.Background(), DocHistoryInput{Path: "runbook.md", Revision: "nope"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `failed to resolve revision "nope"`)
+		_, err = srv.docHistory(context.Background(), DocHistoryInput{Path: "other@main:runbook.md"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown git source")
+	})
+
+	t.Run("not in a repository", func(t *testing.T) {
+		docsDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(docsDir, "plain.md"), []byte("# Plain\n"), 0600))
+		plain, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test"})
+		require.NoError(t, err)
+		defer plain.Close()
+		_, err = plain.docHistory(context.Background(), DocHistoryInput{Path: "plain.md"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not in a git repository")
+	})
+}
+
+func TestServer_ReadDocAt(t *testing.T) {
+	r := newHistoryTestRepo(t)
+	srv := r.server(t)
+
+	res, err := srv.readDocAt(context.Background(), "runbook.md", nil, "HEAD~2")
+	require.NoError(t, err)
+	assert.Equal(t, "# Runbook\nstep 1\n", res.Content, "frontmatter stripped")
+	assert.Equal(t, r.commits[0], res.Revision)
+	assert.Equal(t, "project-docs", res.Source)
+	assert.Equal(t, "runbook.md", res.Path)
+
+	res, err = srv.readDocAt(context.Background(), "project-docs:runbook.md", nil, r.commits[1][:7])
+	require.NoError(t, err)
+	assert.Equal(t, "# Runbook\nstep 1\nstep 2\n", res.Content)
+
+	// read_doc with revision uses the same code path
+	rev := "main"
+	result, _, err := srv.handleReadDoc(context.Background(), &mcp.CallToolRequest{}, ReadInput{Path: "runbook.md", Revision: &rev})
+	require.NoError(t, err)
+	var out ReadOutput
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &out))
+	assert.Equal(t, "# Runbook\nstep one\nstep 2\n", out.Content)
+	assert.Equal(t, r.commits[2], out.Revision)
+
+	_, err = srv.readDocAt(context.Background(), "runbook.md", nil, "")
+	require.Error(t, err)
+	_, err = srv.readDocAt(context.Background(), "other.md", nil, "nope")
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, "docs", "new.md"), []byte("# New\n"), 0600))
+	_, err = srv.readDocAt(context.Background(), "new.md", nil, "HEAD")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestServer_DocDiff(t *testing.T) {
+	r := newHistoryTestRepo(t)
+	srv := r.server(t)
+
+	t.Run("between revisions", func(t *testing.T) {
+		res, err := srv.docDiff(context.Background(), DocDiffInput{Path: "runbook.md", From: "HEAD~2", To: "HEAD~1"})
+		require.NoError(t, err)
+		assert.True(t, res.Changed)
+		assert.Equal(t, r.commits[0], res.From)
+		assert.Equal(t, r.commits[1], res.To)
+		assert.Equal(t, "--- a/docs/runbook.md\n+++ b/docs/runbook.md\n@@ -3,3 +3,4 @@\n ---\n # Runbook\n step 1\n+step 2\n", res.Diff)
+		assert.Equal(t, []string{"project-docs:runbook.md"}, res.servedPaths())
+	})
+
+	t.Run("with working tree", func(t *testing.T) {
+		res, err := srv.docDiff(context.Background(), DocDiffInput{Path: "runbook.md", From: "HEAD"})
+		require.NoError(t, err)
+		assert.False(t, res.Changed)
+		assert.Empty(t, res.Diff)
+		assert.Equal(t, workingTree, res.To)
+
+		require.NoError(t, os.WriteFile(filepath.Join(r.dir, "docs", "runbook.md"),
+			[]byte("---\ndescription: runbook\n---\n# Runbook\nstep one\nstep 2\nstep 3\n"), 0600))
+		res, err = srv.docDiff(context.Background(), DocDiffInput{Path: "runbook.md", From: "HEAD"})
+		require.NoError(t, err)
+		assert.True(t, res.Changed)
+		assert.Contains(t, res.Diff, "+step 3\n")
+	})
+
+	t.Run("file added after revision", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(r.dir, "docs", "new.md"), []byte("# New\n"), 0600))
+		res, err := srv.docDiff(context.Background(), DocDiffInput{Path: "new.md", From: "HEAD"})
+		require.NoError(t, err)
+		assert.Equal(t, "--- a/docs/new.md\n+++ b/docs/new.md\n@@ -0,0 +1 @@\n+# New\n", res.Diff)
+
+		_, err = srv.docDiff(context.Background(), DocDiffInput{Path: "new.md", From: "HEAD", To: "HEAD~1"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found in both revisions")
+	})
+
+	t.Run("git source compares with its ref", func(t *testing.T) {
+		res, err := srv.docDiff(context.Background(), DocDiffInput{Path: "old@" + r.commits[0][:8] + ":runbook.md", From: "main"})
+		require.NoError(t, err)
+		assert.Equal(t, r.commits[0], res.To)
+		assert.Contains(t, res.Diff, "-step one\n")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := srv.docDiff(context.Background(), DocDiffInput{Path: "runbook.md"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "from revision is required")
+		_, err = srv.docDiff(context.Background(), DocDiffInput{Path: "runbook.md", From: "HEAD", To: "nope"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `failed to resolve revision "nope"`)
+	})
+}
+
+func TestServer_HistoryHandlers(t *testing.T) {
+	r := newHistoryTestRepo(t)
+	srv := r.server(t)
+
+	result, _, err := srv.handleDocHistory(context.Background(), &mcp.CallToolRequest{}, DocHistoryInput{Path: "runbook.md", Limit: 2})
+	require.NoError(t, err)
+	var history DocHistoryOutput
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &history))
+	assert.Equal(t, 2, history.Total)
+
+	result, _, err = srv.handleReadDocAt(context.Background(), &mcp.CallToolRequest{}, ReadAtInput{Path: "runbook.md", Revision: "HEAD~1"})
+	require.NoError(t, err)
+	var doc ReadOutput
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &doc))
+	assert.Equal(t, r.commits[1], doc.Revision)
+
+	result, _, err = srv.handleDocDiff(context.Background(), &mcp.CallToolRequest{}, DocDiffInput{Path: "runbook.md", From: "HEAD~1", To: "HEAD"})
+	require.NoError(t, err)
+	var diff DocDiffOutput
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &diff))
+	assert.Contains(t, diff.Diff, "-step 1\n+step one\n")
+
+	_, _, err = srv.handleDocHistory(context.Background(), &mcp.CallToolRequest{}, DocHistoryInput{Path: "missing.md"})
+	require.Error(t, err)
+	_, _, err = srv.handleReadDocAt(context.Background(), &mcp.CallToolRequest{}, ReadAtInput{Path: "runbook.md"})
+	require.Error(t, err)
+	_, _, err = srv.handleDocDiff(context.Background(), &mcp.CallToolRequest{}, DocDiffInput{Path: "runbook.md"})
+	require.Error(t, err)
+}
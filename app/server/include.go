@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// expandIncludes replaces include directives in content of the doc read by readDoc, and lists expanded docs.
+// the raw body of the doc is expanded, not the redacted content, and the result is redacted once.
+// expansions are cached by the scanner until the doc or any of its includes changes.
+// docs of git sources are returned as is.
+func (s *Server) expandIncludes(ctx context.Context, doc *ReadOutput) error {
+	if doc.Source == string(scanner.SourceGit) {
+		return nil
+	}
+
+	absPath, _, err := s.resolveDocFile(ctx, doc.Source, doc.Path)
+	if err != nil {
+		return err
+	}
+
+	exp, ok := s.scanner.Expansion(absPath)
+	if !ok {
+		raw, err := s.scanner.Document(absPath)
+		if err != nil {
+			return err // nolint:wrapcheck // scanner error is descriptive
+		}
+		name := doc.Source + ":" + filepath.ToSlash(doc.Path)
+		if exp, err = scanner.ExpandIncludes(ctx, name, absPath, raw.Body, s.resolveInclude, s.config.MaxFileSize); err != nil {
+			return fmt.Errorf("failed to expand includes: %w", err)
+		}
+		s.scanner.SetExpansion(absPath, exp)
+	}
+
+	doc.Content = string(exp.Content)
+	doc.Size = len(exp.Content)
+	doc.Includes = exp.Includes
+	doc.UnresolvedIncludes = exp.Unresolved
+	doc.Hash = scanner.HashContent(exp.Content)
+	doc.Redactions = nil // counted for the whole expanded content
+	s.redactDoc(doc)
+	return nil
+}
+
+// resolveInclude resolves include target of the doc named "source:path". target is relative to the including
+// doc directory in the same source, or has its own source prefix. git sources can't be included.
+func (s *Server) resolveInclude(ctx context.Context, from, target string) (name, absPath string, err error) {
+	fromSource, fromPath := parseDocPath(from, nil)
+	targetSource, targetPath := parseDocPath(target, nil)
+	if targetSource == "" {
+		targetSource = fromSource
+		targetPath = path.Join(path.Dir(filepath.ToSlash(fromPath)), targetPath)
+	}
+	if strings.Contains(targetSource, "@") {
+		return "", "", fmt.Errorf("git sources can't be included")
+	}
+
+	cleanPath, err := scanner.CleanUserPath(targetPath)
+	if err != nil {
+		return "", "", err // nolint:wrapcheck // path error is descriptive
+	}
+	absPath, src, err := s.resolveDocFile(ctx, targetSource, cleanPath)
+	if err != nil {
+		return "", "", err
+	}
+	return string(src) + ":" + filepath.ToSlash(cleanPath), absPath, nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ReadDoc_ExpandIncludes(t *testing.T) {
+	files := map[string]string{
+		"docs/guides/setup.md": "---\ndescription: setup\n---\n# Setup\n" +
+			"<!-- include: ../shared/conventions.md -->\n{{< include \"commands:common.md\" >}}\n<!-- include: missing.md -->\n",
+		"docs/shared/conventions.md": "---\ntags: [shared]\n---\n## Conventions\n",
+		"commands/common.md":         "## Common\n",
+	}
+
+	srv, dir := newTestServer(t, files, Config{Version: "1.0.0"})
+
+	read := func(input ReadInput) *ReadOutput {
+		t.Helper()
+		_, out, err := srv.handleReadDoc(context.Background(), &mcp.CallToolRequest{}, input)
+		require.NoError(t, err)
+		return out.(*ReadOutput)
+	}
+
+	// directives are kept without expand_includes
+	plain := read(ReadInput{Path: "project-docs:guides/setup.md"})
+	assert.Contains(t, plain.Content, "<!-- include: ../shared/conventions.md -->")
+	assert.Empty(t, plain.Includes)
+
+	res := read(ReadInput{Path: "project-docs:guides/setup.md", ExpandIncludes: true})
+	assert.Equal(t, "# Setup\n## Conventions\n## Common\n<!-- include: missing.md -->\n", res.Content)
+	assert.Equal(t, len(res.Content), res.Size)
+	assert.Equal(t, []string{"project-docs:shared/conventions.md", "commands:common.md"}, res.Includes)
+	require.Len(t, res.UnresolvedIncludes, 1)
+	assert.Contains(t, res.UnresolvedIncludes[0], "missing.md: failed to resolve path in project-docs")
+
+	// change of an included doc is visible on the next read
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "shared", "conventions.md"), []byte("## New conventions\n"), 0600))
+	assert.Eventually(t, func() bool {
+		return read(ReadInput{Path: "guides/setup", ExpandIncludes: true}).Content ==
+			"# Setup\n## New conventions\n## Common\n<!-- include: missing.md -->\n"
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestServer_ResolveInclude(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(filepath.Join(docsDir, "a"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "top.md"), []byte("top"), 0600))
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	name, path, err := srv.resolveInclude(context.Background(), "project-docs:a/doc.md", "../top")
+	require.NoError(t, err)
+	assert.Equal(t, "project-docs:top.md", name)
+	assert.Equal(t, filepath.Join(docsDir, "top.md"), path)
+
+	_, _, err = srv.resolveInclude(context.Background(), "project-docs:doc.md", "../outside.md")
+	require.Error(t, err)
+	_, _, err = srv.resolveInclude(context.Background(), "project-docs:doc.md", "app@main:guide.md")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git sources can't be included")
+}
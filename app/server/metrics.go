@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/umputun/local-docs-mcp/app/metrics"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+const metricsShutdownTimeout = 5 * time.Second
+
+// serverMetrics are metrics updated by the server, scanner metrics are collected on scrape
+type serverMetrics struct {
+	registry     *metrics.Registry
+	toolCalls    *metrics.CounterVec
+	toolDuration *metrics.HistogramVec
+}
+
+// newServerMetrics registers tool metrics and scanner metrics collected from cached scanner stats
+func newServerMetrics(cacheStats func() scanner.CacheStats) *serverMetrics {
+	reg := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry:  reg,
+		toolCalls: reg.NewCounterVec("local_docs_tool_calls_total", "Number of tool calls.", "tool", "status"),
+		toolDuration: reg.NewHistogramVec("local_docs_tool_duration_seconds", "Duration of tool calls in seconds.",
+			metrics.DefaultBuckets, "tool", "status"),
+	}
+
+	stat := func(fn func(scanner.CacheStats) float64) func() []metrics.Sample {
+		return func() []metrics.Sample { return []metrics.Sample{{Value: fn(cacheStats())}} }
+	}
+	reg.NewCounterFunc("local_docs_cache_hits_total", "Number of file list requests served from cache.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.Hits) }))
+	reg.NewCounterFunc("local_docs_cache_misses_total", "Number of file list requests which scanned the filesystem.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.Misses) }))
+	reg.NewCounterFunc("local_docs_cache_invalidations_total", "Number of file list cache invalidations.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.Invalidations) }))
+	reg.NewCounterFunc("local_docs_content_cache_hits_total", "Number of doc reads served from content cache.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.ContentHits) }))
+	reg.NewCounterFunc("local_docs_content_cache_misses_total", "Number of doc reads from the filesystem.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.ContentMisses) }))
+	reg.NewGaugeFunc("local_docs_content_cache_docs", "Number of docs in content cache.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.ContentDocs) }))
+	reg.NewGaugeFunc("local_docs_content_cache_bytes", "Total size of docs in content cache in bytes.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.ContentBytes) }))
+	reg.NewSummaryFunc("local_docs_scan_duration_seconds", "Duration of full scans in seconds.", func() []metrics.SummarySample {
+		st := cacheStats()
+		return []metrics.SummarySample{{Sum: st.ScanTime.Seconds(), Count: uint64(max(st.Scans, 0))}}
+	})
+	reg.NewCounterFunc("local_docs_watcher_events_total", "Number of file watcher events.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.WatcherEvents) }))
+	reg.NewCounterFunc("local_docs_watcher_errors_total", "Number of file watcher errors.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.WatcherErrors) }))
+	reg.NewGaugeFunc("local_docs_watched_dirs", "Number of directories watched for changes.",
+		stat(func(s scanner.CacheStats) float64 { return float64(s.WatchedDirs) }))
+	reg.NewGaugeFunc("local_docs_indexed_files", "Number of indexed files by source, as of the last scan.", func() []metrics.Sample {
+		var res []metrics.Sample
+		for src, n := range cacheStats().Files {
+			res = append(res, metrics.Sample{LabelValues: []string{string(src)}, Value: float64(n)})
+		}
+		return res
+	}, "source")
+	return m
+}
+
+// observeCall records tool call in metrics, if enabled
+func (s *Server) observeCall(tool string, latency time.Duration, callErr error) {
+	if s.metrics == nil {
+		return
+	}
+	status := "ok"
+	if callErr != nil {
+		status = "error"
+	}
+	s.metrics.toolCalls.Inc(tool, status)
+	s.metrics.toolDuration.Observe(latency.Seconds(), tool, status)
+}
+
+// metricsHandler returns handler serving /metrics, and pprof handlers under /debug/pprof/ if enabled
+func (s *Server) metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.registry.Handler())
+	if s.config.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+// startMetricsServer listens on the metrics address and serves metrics until ctx is done
+func (s *Server) startMetricsServer(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.config.MetricsListen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics address: %w", err)
+	}
+	httpServer := &http.Server{Handler: s.metricsHandler(), ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx) // nolint:contextcheck // parent context is done already
+	}()
+	go func() {
+		if err := httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+
+	slog.Info("metrics server started", "address", ln.Addr().String(), "pprof", s.config.EnablePprof)
+	return nil
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	tmpDir := t.TempDir()
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "guide.md"), []byte("# Guide\n"), 0600))
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server", MetricsListen: "127.0.0.1:0"})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+
+	_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "read_doc", Arguments: map[string]any{"path": "guide.md"}})
+	require.NoError(t, err)
+	_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "read_doc", Arguments: map[string]any{"path": "missing.md"}})
+	require.NoError(t, err)
+	for range 2 { // miss and hit of the file list cache
+		_, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "search_docs", Arguments: map[string]any{"query": "guide"}})
+		require.NoError(t, err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.metricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `local_docs_tool_calls_total{tool="read_doc",status="ok"} 1`)
+	assert.Contains(t, body, `local_docs_tool_calls_total{tool="read_doc",status="error"} 1`)
+	assert.Contains(t, body, `local_docs_tool_duration_seconds_count{tool="read_doc",status="ok"} 1`)
+	// file lists of the shared and the project scanner are counted both
+	assert.Contains(t, body, "local_docs_cache_misses_total 2\n")
+	assert.Contains(t, body, "local_docs_cache_hits_total 4\n", "read of missing doc looks for the closest one")
+	assert.Contains(t, body, "# TYPE local_docs_scan_duration_seconds summary\n")
+	assert.Contains(t, body, "local_docs_scan_duration_seconds_count 2\n")
+	assert.NotContains(t, body, "# TYPE local_docs_scan_duration_seconds_count", "sum and count are a single family")
+	assert.Contains(t, body, `local_docs_indexed_files{source="project-docs"} 1`)
+	assert.Contains(t, body, "# TYPE local_docs_watched_dirs gauge")
+	assert.Contains(t, body, "local_docs_watcher_errors_total 0\n")
+
+	rec = httptest.NewRecorder()
+	srv.metricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", http.NoBody))
+	assert.Equal(t, http.StatusNotFound, rec.Code, "pprof is disabled by default")
+}
+
+func TestServer_ContentCache(t *testing.T) {
+	docsDir := t.TempDir()
+	guide := filepath.Join(docsDir, "guide.md")
+	require.NoError(t, os.WriteFile(guide, []byte("---\ntags: [a]\n---\n# Guide\n"), 0600))
+
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server", MetricsListen: "127.0.0.1:0",
+		ContentCacheSize: 1024})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	for range 3 {
+		res, err := srv.readDoc(context.Background(), "guide.md", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "# Guide\n", res.Content)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.metricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	body := rec.Body.String()
+	assert.Contains(t, body, "local_docs_content_cache_hits_total 2\n")
+	assert.Contains(t, body, "local_docs_content_cache_misses_total 1\n")
+	assert.Contains(t, body, "local_docs_content_cache_docs 1\n")
+	assert.Contains(t, body, "local_docs_content_cache_bytes 8\n")
+
+	// changed doc is read again once the watcher reports the change
+	require.NoError(t, os.WriteFile(guide, []byte("# Guide v2\n"), 0600))
+	assert.Eventually(t, func() bool {
+		res, err := srv.readDoc(context.Background(), "guide.md", nil)
+		return err == nil && res.Content == "# Guide v2\n"
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestServer_MetricsDisabled(t *testing.T) {
+	srv, err := New(Config{MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+	assert.Nil(t, srv.metrics)
+	srv.observeCall("read_doc", time.Millisecond, nil) // no-op without metrics
+}
+
+func TestServer_StartMetricsServer(t *testing.T) {
+	srv, err := New(Config{MaxFileSize: 1024, ServerName: "test-server", MetricsListen: "127.0.0.1:0", EnablePprof: true})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	// find a free port for the listener
+	ln, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv.config.MetricsListen = ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, srv.startMetricsServer(ctx))
+
+	for _, path := range []string{"/metrics", "/debug/pprof/cmdline"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+srv.config.MetricsListen+path, http.NoBody)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, path)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.NotEmpty(t, body, path)
+	}
+
+	// address in use
+	srvBusy, err := New(Config{MaxFileSize: 1024, ServerName: "test-server", MetricsListen: srv.config.MetricsListen})
+	require.NoError(t, err)
+	defer srvBusy.Close()
+	require.ErrorContains(t, srvBusy.startMetricsServer(ctx), "failed to listen on metrics address")
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		conn, err := net.DialTimeout("tcp", srv.config.MetricsListen, 100*time.Millisecond)
+		if err != nil {
+			return true
+		}
+		_ = conn.Close()
+		return false
+	}, time.Second, 10*time.Millisecond, "listener is closed on context cancel")
+}
@@ -0,0 +1,397 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/markdown"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+const (
+	// completedPlansDir is the subdirectory of plans dir with finished plans
+	completedPlansDir = "completed"
+	planActive        = "active"
+	planCompleted     = "completed"
+)
+
+// checkboxMarkRe matches checkbox mark of a task list item, the first "[ ]" or "[x]" after the list marker
+var checkboxMarkRe = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+)\[([ xX])\]`)
+
+// PlanInfo represents a plan with its progress
+type PlanInfo struct {
+	Path     string `json:"path"` // relative to plans dir, e.g. "completed/auth.md"
+	Title    string `json:"title"`
+	Status   string `json:"status"` // active or completed
+	Total    int    `json:"total"`
+	Done     int    `json:"done"`
+	Progress int    `json:"progress"` // done tasks percentage
+}
+
+// ListPlansOutput contains active and completed plans, sorted by path
+type ListPlansOutput struct {
+	Active    []PlanInfo `json:"active"`
+	Completed []PlanInfo `json:"completed"`
+}
+
+// PlanInput represents input for reading plan status
+type PlanInput struct {
+	Path    string `json:"path"` // relative to plans dir
+	Project string `json:"project,omitempty"`
+}
+
+// PlanTask is a task of a plan, line is the line in the plan file
+type PlanTask struct {
+	Text string `json:"text"`
+	Line int    `json:"line"`
+}
+
+// PlanSection groups open tasks under a heading
+type PlanSection struct {
+	Section string     `json:"section"`
+	Tasks   []PlanTask `json:"tasks"`
+}
+
+// PlanStatusOutput contains plan progress and open tasks grouped by heading
+type PlanStatusOutput struct {
+	PlanInfo
+	Open []PlanSection `json:"open"`
+}
+
+// CompleteTaskInput represents input for changing task state
+type CompleteTaskInput struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`              // line of the task in the plan file, as returned by plan_status
+	Text    string `json:"text"`              // expected task text, guards against changed files
+	Checked *bool  `json:"checked,omitempty"` // new state, true if not set
+	Project string `json:"project,omitempty"`
+}
+
+// CompleteTaskOutput contains the changed task and updated plan progress
+type CompleteTaskOutput struct {
+	PlanInfo
+	Task    PlanTask `json:"task"`
+	Checked bool     `json:"checked"`
+	Changed bool     `json:"changed"` // false if the task already had the requested state
+}
+
+// plan is a parsed plan file
+type plan struct {
+	info    PlanInfo
+	items   []markdown.ChecklistItem // lines are lines of the file, frontmatter included
+	lines   []string                 // file lines with line endings
+	absPath string
+}
+
+// listPlans returns plans from the plans dir, plans in the completed subdirectory are completed
+func (s *Server) listPlans(ctx context.Context) (*ListPlansOutput, error) {
+	res := &ListPlansOutput{Active: []PlanInfo{}, Completed: []PlanInfo{}}
+	err := filepath.WalkDir(s.config.PlansDir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if os.IsNotExist(err) && path == s.config.PlansDir {
+				return fs.SkipAll // no plans yet
+			}
+			return nil // skip unreadable entries
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != s.config.PlansDir {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.config.PlansDir, path)
+		if err != nil {
+			return nil
+		}
+		p, err := s.loadPlan(rel)
+		if err != nil {
+			slog.Debug("skipping plan", "path", path, "error", err)
+			return nil
+		}
+		if p.info.Status == planCompleted {
+			res.Completed = append(res.Completed, p.info)
+		} else {
+			res.Active = append(res.Active, p.info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err // nolint:wrapcheck // context or filepath.WalkDir error is descriptive as-is
+	}
+	return res, nil
+}
+
+// planStatus returns progress and open tasks of the plan, grouped by heading in order of appearance
+func (s *Server) planStatus(path string) (*PlanStatusOutput, error) {
+	p, err := s.loadPlan(path)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &PlanStatusOutput{PlanInfo: p.info, Open: []PlanSection{}}
+	for _, item := range p.items {
+		if item.Checked {
+			continue
+		}
+		if len(res.Open) == 0 || res.Open[len(res.Open)-1].Section != item.Section {
+			res.Open = append(res.Open, PlanSection{Section: item.Section})
+		}
+		last := &res.Open[len(res.Open)-1]
+		last.Tasks = append(last.Tasks, PlanTask{Text: item.Text, Line: item.Line})
+	}
+	return res, nil
+}
+
+// completeTask sets state of the task at the given line. the line must still hold a task with the expected
+// text, so a plan changed since plan_status is not corrupted. the file is replaced atomically.
+func (s *Server) completeTask(input CompleteTaskInput) (*CompleteTaskOutput, error) {
+	checked := input.Checked == nil || *input.Checked
+
+	s.plansMu.Lock()
+	defer s.plansMu.Unlock()
+
+	p, err := s.loadPlan(input.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := -1
+	for i, item := range p.items {
+		if item.Line == input.Line {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("no task at line %d of %s", input.Line, input.Path)
+	}
+	item := p.items[idx]
+	if item.Text != strings.TrimSpace(input.Text) {
+		return nil, fmt.Errorf("task at line %d is %q, not %q, the plan has changed", input.Line, item.Text, input.Text)
+	}
+
+	res := &CompleteTaskOutput{Task: PlanTask{Text: item.Text, Line: item.Line}, Checked: checked}
+	if item.Checked != checked {
+		mark := " "
+		if checked {
+			mark = "x"
+		}
+		p.lines[item.Line-1] = checkboxMarkRe.ReplaceAllString(p.lines[item.Line-1], "${1}["+mark+"]")
+		if err := writeFileAtomic(p.absPath, []byte(strings.Join(p.lines, ""))); err != nil {
+			return nil, err
+		}
+		res.Changed = true
+		if p, err = s.loadPlan(input.Path); err != nil {
+			return nil, err
+		}
+	}
+	res.PlanInfo = p.info
+	return res, nil
+}
+
+// loadPlan reads and parses plan with the path relative to plans dir
+func (s *Server) loadPlan(relPath string) (*plan, error) {
+	cleanPath, err := scanner.CleanUserPath(relPath)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // path error is descriptive
+	}
+	absPath, err := scanner.SafeResolvePath(s.config.PlansDir, cleanPath, s.config.MaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plan %s: %w", relPath, err)
+	}
+	// #nosec G304 - path is validated by SafeResolvePath
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	// checklist lines are counted from the start of the file, including frontmatter
+	_, stripped := scanner.ParseFrontmatter(content)
+	offset := strings.Count(string(content[:len(content)-len(stripped)]), "\n")
+	doc := markdown.Parse(string(stripped))
+
+	slashPath := filepath.ToSlash(cleanPath)
+	p := &plan{lines: strings.SplitAfter(string(content), "\n"), info: PlanInfo{Path: slashPath, Status: planActive}}
+	if strings.HasPrefix(slashPath, completedPlansDir+"/") {
+		p.info.Status = planCompleted
+	}
+	p.info.Title = planTitle(string(stripped), filepath.Base(cleanPath))
+	for _, item := range doc.Checklist {
+		item.Line += offset
+		p.items = append(p.items, item)
+		if item.Checked {
+			p.info.Done++
+		}
+	}
+	p.info.Total = len(p.items)
+	if p.info.Total > 0 {
+		p.info.Progress = p.info.Done * 100 / p.info.Total
+	}
+	p.absPath = absPath
+	return p, nil
+}
+
+// planTitle returns text of the first level one heading, or file name without extension
+func planTitle(content, name string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+	}
+	return strings.TrimSuffix(name, ".md")
+}
+
+// writeFileAtomic replaces file content via temporary file in the same directory, keeping file mode
+func writeFileAtomic(path string, data []byte) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after successful rename
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Chmod(st.Mode().Perm())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// registerPlanTools registers plan tools if plans dir is configured, complete_task only if plan editing is enabled
+func (s *Server) registerPlanTools() {
+	if s.config.PlansDir == "" {
+		return
+	}
+
+	addTool(s, &mcp.Tool{
+		Name:        "list_plans",
+		Description: "List implementation plans, active ones and ones moved to completed/, with task completion percentages from markdown checklists.",
+	}, s.handleListPlans)
+
+	addTool(s, &mcp.Tool{
+		Name:        "plan_status",
+		Description: "Show progress of a plan and its open tasks grouped by heading. Path is relative to the plans directory, as returned by list_plans.",
+	}, s.handlePlanStatus)
+
+	if s.config.EnablePlanEdit {
+		addTool(s, &mcp.Tool{
+			Name: "complete_task",
+			Description: "Mark a plan task as done (or not done with checked=false). Requires line and text of the task " +
+				"as returned by plan_status, fails if the plan has changed since.",
+		}, s.handleCompleteTask)
+	}
+}
+
+// handleListPlans handles list_plans tool calls
+func (s *Server) handleListPlans(ctx context.Context, _ *mcp.CallToolRequest, input ProjectInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("list_plans called", "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.listPlans(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list plans failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
+
+// handlePlanStatus handles plan_status tool calls
+func (s *Server) handlePlanStatus(_ context.Context, _ *mcp.CallToolRequest, input PlanInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("plan_status called", "path", input.Path, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.planStatus(input.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("plan status failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
+
+// handleCompleteTask handles complete_task tool calls
+func (s *Server) handleCompleteTask(_ context.Context, _ *mcp.CallToolRequest, input CompleteTaskInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("complete_task called", "path", input.Path, "line", input.Line, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.completeTask(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("complete task failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Plans(t *testing.T) {
+	tmpDir := t.TempDir()
+	plansDir := filepath.Join(tmpDir, "plans")
+	require.NoError(t, os.MkdirAll(filepath.Join(plansDir, "completed"), 0755))
+	authPlan := "---\nstatus: wip\n---\n# Auth rework\n\n## Backend\n\n- [x] add tokens\n- [ ] refresh tokens\n\n" +
+		"```md\n- [ ] not a task\n```\n\n## Frontend\n\n1. [ ] login page\n* [X] logout\n"
+	require.NoError(t, os.WriteFile(filepath.Join(plansDir, "auth.md"), []byte(authPlan), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(plansDir, "empty.md"), []byte("no tasks here\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(plansDir, "completed", "cache.md"),
+		[]byte("# Cache\n- [x] lru\n- [x] ttl\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(plansDir, "notes.txt"), []byte("- [ ] skip\n"), 0600))
+
+	srv, err := New(Config{PlansDir: plansDir, MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+	ctx := context.Background()
+
+	t.Run("list plans", func(t *testing.T) {
+		res, err := srv.listPlans(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []PlanInfo{
+			{Path: "auth.md", Title: "Auth rework", Status: "active", Total: 4, Done: 2, Progress: 50},
+			{Path: "empty.md", Title: "empty", Status: "active"},
+		}, res.Active)
+		assert.Equal(t, []PlanInfo{{Path: "completed/cache.md", Title: "Cache", Status: "completed", Total: 2, Done: 2, Progress: 100}},
+			res.Completed)
+	})
+
+	t.Run("missing plans dir", func(t *testing.T) {
+		s, err := New(Config{PlansDir: filepath.Join(tmpDir, "missing"), MaxFileSize: 1024, ServerName: "test-server"})
+		require.NoError(t, err)
+		defer s.Close()
+		res, err := s.listPlans(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, res.Active)
+		assert.Empty(t, res.Completed)
+	})
+
+	t.Run("plan status", func(t *testing.T) {
+		res, err := srv.planStatus("auth.md")
+		require.NoError(t, err)
+		assert.Equal(t, 50, res.Progress)
+		assert.Equal(t, []string{"plans:auth.md"}, res.servedPaths())
+		assert.Equal(t, []PlanSection{
+			{Section: "Backend", Tasks: []PlanTask{{Text: "refresh tokens", Line: 9}}},
+			{Section: "Frontend", Tasks: []PlanTask{{Text: "login page", Line: 17}}},
+		}, res.Open)
+
+		_, err = srv.planStatus("../secret.md")
+		require.Error(t, err)
+		_, err = srv.planStatus("missing.md")
+		require.Error(t, err)
+	})
+
+	t.Run("complete task", func(t *testing.T) {
+		res, err := srv.completeTask(CompleteTaskInput{Path: "auth.md", Line: 9, Text: "refresh tokens"})
+		require.NoError(t, err)
+		assert.True(t, res.Changed)
+		assert.True(t, res.Checked)
+		assert.Equal(t, 3, res.Done)
+		assert.Equal(t, 75, res.Progress)
+
+		data, err := os.ReadFile(filepath.Join(plansDir, "auth.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "- [x] refresh tokens\n")
+		assert.Contains(t, string(data), "- [ ] not a task\n", "fenced lines are untouched")
+		st, err := os.Stat(filepath.Join(plansDir, "auth.md"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+		res, err = srv.completeTask(CompleteTaskInput{Path: "auth.md", Line: 9, Text: "refresh tokens"})
+		require.NoError(t, err)
+		assert.False(t, res.Changed, "already checked")
+
+		unchecked := false
+		res, err = srv.completeTask(CompleteTaskInput{Path: "auth.md", Line: 9, Text: "refresh tokens", Checked: &unchecked})
+		require.NoError(t, err)
+		assert.True(t, res.Changed)
+		assert.Equal(t, 2, res.Done)
+		data, err = os.ReadFile(filepath.Join(plansDir, "auth.md"))
+		require.NoError(t, err)
+		assert.Equal(t, authPlan, string(data))
+
+		entries, err := os.ReadDir(plansDir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 4, "no temp files left")
+	})
+
+	t.Run("complete task errors", func(t *testing.T) {
+		_, err := srv.completeTask(CompleteTaskInput{Path: "auth.md", Line: 13, Text: "not a task"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no task at line 13")
+
+		_, err = srv.completeTask(CompleteTaskInput{Path: "auth.md", Line: 9, Text: "other task"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the plan has changed")
+
+		data, err := os.ReadFile(filepath.Join(plansDir, "auth.md"))
+		require.NoError(t, err)
+		assert.Equal(t, authPlan, string(data))
+	})
+
+	t.Run("handlers", func(t *testing.T) {
+		result, _, err := srv.handleListPlans(ctx, &mcp.CallToolRequest{}, ProjectInput{})
+		require.NoError(t, err)
+		var out ListPlansOutput
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &out))
+		assert.Len(t, out.Active, 2)
+
+		result, _, err = srv.handlePlanStatus(ctx, &mcp.CallToolRequest{}, PlanInput{Path: "completed/cache.md"})
+		require.NoError(t, err)
+		var status PlanStatusOutput
+		require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &status))
+		assert.Equal(t, "completed", status.Status)
+		assert.Empty(t, status.Open)
+
+		_, _, err = srv.handleCompleteTask(ctx, &mcp.CallToolRequest{}, CompleteTaskInput{Path: "missing.md", Line: 1})
+		require.Error(t, err)
+	})
+}
+
+func TestServer_RegisterPlanTools(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		tools []string
+	}{
+		{name: "disabled", cfg: Config{}, tools: nil},
+		{name: "read only", cfg: Config{PlansDir: "plans"}, tools: []string{"list_plans", "plan_status"}},
+		{name: "editable", cfg: Config{PlansDir: "plans", EnablePlanEdit: true},
+			tools: []string{"complete_task", "list_plans", "plan_status"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.MaxFileSize, tt.cfg.ServerName = 1024, "test-server"
+			srv, err := New(tt.cfg)
+			require.NoError(t, err)
+			defer srv.Close()
+
+			ctx := context.Background()
+			ct, st := mcp.NewInMemoryTransports()
+			go func() { _ = srv.mcp.Run(ctx, st) }()
+			client := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil)
+			session, err := client.Connect(ctx, ct, nil)
+			require.NoError(t, err)
+			defer session.Close()
+
+			list, err := session.ListTools(ctx, nil)
+			require.NoError(t, err)
+			var names []string
+			for _, tool := range list.Tools {
+				if tool.Name == "list_plans" || tool.Name == "plan_status" || tool.Name == "complete_task" {
+					names = append(names, tool.Name)
+				}
+			}
+			assert.Equal(t, tt.tools, names)
+		})
+	}
+}
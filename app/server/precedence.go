@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// source precedence modes, orders of sources tried for doc paths without source prefix
+const (
+	PrecedenceSharedFirst  = "shared-first"  // commands, project docs, project root
+	PrecedenceProjectFirst = "project-first" // project docs, project root, commands
+)
+
+var (
+	sharedFirst  = []scanner.Source{scanner.SourceCommands, scanner.SourceProjectDocs, scanner.SourceProjectRoot}
+	projectFirst = []scanner.Source{scanner.SourceProjectDocs, scanner.SourceProjectRoot, scanner.SourceCommands}
+)
+
+// ParseSourcePrecedence parses source precedence, a mode name or comma-separated list of commands,
+// project-docs and project-root sources. sources not in the list are not tried for paths without prefix.
+func ParseSourcePrecedence(value string) ([]scanner.Source, error) {
+	switch value {
+	case "", PrecedenceSharedFirst:
+		return sharedFirst, nil
+	case PrecedenceProjectFirst:
+		return projectFirst, nil
+	}
+	var res []scanner.Source
+	for _, name := range strings.Split(value, ",") {
+		res = append(res, scanner.Source(strings.TrimSpace(name)))
+	}
+	if err := validatePrecedence(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// validatePrecedence checks that precedence has only directory sources of docs, each one once
+func validatePrecedence(sources []scanner.Source) error {
+	seen := map[scanner.Source]bool{}
+	for _, src := range sources {
+		switch src {
+		case scanner.SourceCommands, scanner.SourceProjectDocs, scanner.SourceProjectRoot:
+		default:
+			return fmt.Errorf("invalid source %q in source precedence, expected %s, %s or list of commands, project-docs and project-root",
+				src, PrecedenceSharedFirst, PrecedenceProjectFirst)
+		}
+		if seen[src] {
+			return fmt.Errorf("duplicate source %s in source precedence", src)
+		}
+		seen[src] = true
+	}
+	return nil
+}
+
+// sourcePrecedence returns sources tried for doc paths without source prefix, in order
+func (c *Config) sourcePrecedence() []scanner.Source {
+	if len(c.SourcePrecedence) == 0 {
+		return sharedFirst
+	}
+	return c.SourcePrecedence
+}
+
+// AmbiguousPathError is returned in strict mode for doc path without source prefix found in several sources
+type AmbiguousPathError struct {
+	Path       string
+	Candidates []string // doc paths with source prefix, in precedence order
+}
+
+func (e *AmbiguousPathError) Error() string {
+	return fmt.Sprintf("ambiguous path %s, found in %s, use a source prefix", e.Path, strings.Join(e.Candidates, ", "))
+}
+
+// checkAmbiguous returns AmbiguousPathError if doc path without source found in several sources
+func (s *Server) checkAmbiguous(ctx context.Context, path string, source *string) error {
+	sourceStr, cleanPath := parseDocPath(path, source)
+	if sourceStr != "" {
+		return nil
+	}
+	var candidates []string
+	for _, src := range s.config.sourcePrecedence() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+		default:
+		}
+		baseDir, _, _ := s.sourceDir(string(src))
+		if _, err := scanner.SafeResolvePath(baseDir, cleanPath, s.config.MaxFileSize); err == nil {
+			candidates = append(candidates, string(src)+":"+cleanPath)
+		}
+	}
+	if len(candidates) > 1 {
+		return &AmbiguousPathError{Path: cleanPath, Candidates: candidates}
+	}
+	return nil
+}
+
+// shadowedDocs maps docs shadowed by a doc with the same path in a source of higher precedence
+// to the winning doc, both with source prefix
+func (s *Server) shadowedDocs(files []scanner.FileInfo) map[string]string {
+	rank := map[scanner.Source]int{}
+	for i, src := range s.config.sourcePrecedence() {
+		rank[src] = i
+	}
+
+	winners := map[string]scanner.FileInfo{} // by path without source prefix
+	for _, f := range files {
+		r, ok := rank[f.Source]
+		if !ok {
+			continue
+		}
+		_, p, _ := strings.Cut(f.Filename, ":")
+		if w, found := winners[p]; !found || r < rank[w.Source] {
+			winners[p] = f
+		}
+	}
+
+	res := map[string]string{}
+	for _, f := range files {
+		if _, ok := rank[f.Source]; !ok {
+			continue
+		}
+		_, p, _ := strings.Cut(f.Filename, ":")
+		if w := winners[p]; w.Filename != f.Filename {
+			res[f.Filename] = w.Filename
+		}
+	}
+	return res
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// setupPrecedence creates commands, docs and root dirs with guide.md in all of them and unique docs in each
+func setupPrecedence(t *testing.T) Config {
+	t.Helper()
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	rootDir := filepath.Join(tmpDir, "project")
+	docsDir := filepath.Join(rootDir, "docs")
+	require.NoError(t, os.MkdirAll(commandsDir, 0755))
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+
+	files := map[string]string{
+		filepath.Join(commandsDir, "guide.md"):  "shared guide",
+		filepath.Join(docsDir, "guide.md"):      "project guide",
+		filepath.Join(rootDir, "guide.md"):      "root guide",
+		filepath.Join(commandsDir, "commit.md"): "shared commit",
+		filepath.Join(docsDir, "deploy.md"):     "project deploy",
+		filepath.Join(commandsDir, "readme.md"): "shared readme",
+		filepath.Join(rootDir, "readme.md"):     "root readme",
+	}
+	for p, content := range files {
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+	return Config{CommandsDir: commandsDir, ProjectDocsDir: docsDir, ProjectRootDir: rootDir, MaxFileSize: 1024,
+		ServerName: "test-server"}
+}
+
+func TestServer_SourcePrecedence(t *testing.T) {
+	tbl := []struct {
+		name       string
+		precedence []scanner.Source
+		guide      string
+		readme     string
+		shadowed   map[string]string
+	}{
+		{name: "shared first by default", guide: "shared guide", readme: "shared readme",
+			shadowed: map[string]string{"project-docs:guide.md": "commands:guide.md", "project-root:guide.md": "commands:guide.md",
+				"project-root:readme.md": "commands:readme.md"}},
+		{name: "project first", precedence: projectFirst, guide: "project guide", readme: "root readme",
+			shadowed: map[string]string{"commands:guide.md": "project-docs:guide.md", "project-root:guide.md": "project-docs:guide.md",
+				"commands:readme.md": "project-root:readme.md"}},
+		{name: "custom order", precedence: []scanner.Source{scanner.SourceProjectRoot, scanner.SourceCommands},
+			guide: "root guide", readme: "root readme",
+			shadowed: map[string]string{"commands:guide.md": "project-root:guide.md", "commands:readme.md": "project-root:readme.md"}},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := setupPrecedence(t)
+			cfg.SourcePrecedence = tt.precedence
+			srv, err := New(cfg)
+			require.NoError(t, err)
+			defer srv.Close()
+
+			res, err := srv.readDoc(context.Background(), "guide.md", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.guide, res.Content)
+			res, err = srv.readDoc(context.Background(), "readme.md", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.readme, res.Content)
+
+			list, err := srv.listAllDocs(context.Background())
+			require.NoError(t, err)
+			shadowed := map[string]string{}
+			for _, d := range list.Docs {
+				if d.ShadowedBy != "" {
+					shadowed[d.Filename] = d.ShadowedBy
+				}
+			}
+			assert.Equal(t, tt.shadowed, shadowed)
+		})
+	}
+
+	t.Run("source not in precedence needs prefix", func(t *testing.T) {
+		cfg := setupPrecedence(t)
+		cfg.SourcePrecedence = []scanner.Source{scanner.SourceCommands}
+		srv, err := New(cfg)
+		require.NoError(t, err)
+		defer srv.Close()
+
+		_, err = srv.readDoc(context.Background(), "deploy.md", nil)
+		require.EqualError(t, err, "file not found in any source: deploy.md")
+		res, err := srv.readDoc(context.Background(), "project-docs:deploy.md", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "project deploy", res.Content)
+	})
+}
+
+func TestServer_ReadDocStrict(t *testing.T) {
+	cfg := setupPrecedence(t)
+	srv, err := New(cfg)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+
+	read := func(t *testing.T, args map[string]any) (string, bool) {
+		t.Helper()
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "read_doc", Arguments: args})
+		require.NoError(t, err)
+		return res.Content[0].(*mcp.TextContent).Text, res.IsError
+	}
+
+	text, isErr := read(t, map[string]any{"path": "guide.md"})
+	assert.False(t, isErr, "not strict by default")
+	var out ReadOutput
+	require.NoError(t, json.Unmarshal([]byte(text), &out))
+	assert.Equal(t, "shared guide", out.Content)
+
+	text, isErr = read(t, map[string]any{"path": "guide.md", "strict": true})
+	assert.True(t, isErr)
+	assert.Equal(t, "read failed: ambiguous path guide.md, found in commands:guide.md, project-docs:guide.md, "+
+		"project-root:guide.md, use a source prefix", text)
+
+	for _, args := range []map[string]any{
+		{"path": "project-docs:guide.md", "strict": true},
+		{"path": "guide.md", "source": "project-root", "strict": true},
+		{"path": "commit.md", "strict": true},
+	} {
+		_, isErr = read(t, args)
+		assert.False(t, isErr, "%v", args)
+	}
+
+	t.Run("strict by config", func(t *testing.T) {
+		cfg := setupPrecedence(t)
+		cfg.StrictPaths = true
+		strictSrv, err := New(cfg)
+		require.NoError(t, err)
+		defer strictSrv.Close()
+
+		_, _, err = strictSrv.handleReadDoc(ctx, nil, ReadInput{Path: "readme.md"})
+		var ambiguous *AmbiguousPathError
+		require.ErrorAs(t, err, &ambiguous)
+		assert.Equal(t, &AmbiguousPathError{Path: "readme.md", Candidates: []string{"commands:readme.md", "project-root:readme.md"}},
+			ambiguous)
+
+		err = strictSrv.checkAmbiguous(ctx, "deploy.md", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := srv.checkAmbiguous(canceled, "guide.md", nil)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestParseSourcePrecedence(t *testing.T) {
+	tbl := []struct {
+		value string
+		res   []scanner.Source
+		err   string
+	}{
+		{value: "", res: sharedFirst},
+		{value: "shared-first", res: sharedFirst},
+		{value: "project-first", res: projectFirst},
+		{value: "project-root, commands", res: []scanner.Source{scanner.SourceProjectRoot, scanner.SourceCommands}},
+		{value: "skills,commands", err: `invalid source "skills" in source precedence`},
+		{value: "project-last", err: `invalid source "project-last" in source precedence, expected shared-first, project-first`},
+		{value: "commands,commands", err: "duplicate source commands in source precedence"},
+	}
+	for _, tt := range tbl {
+		res, err := ParseSourcePrecedence(tt.value)
+		if tt.err != "" {
+			require.ErrorContains(t, err, tt.err, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.res, res, tt.value)
+	}
+
+	cfg := Config{ServerName: "test", MaxFileSize: 1, SourcePrecedence: []scanner.Source{scanner.SourceMemory}}
+	require.ErrorContains(t, cfg.Validate(), `invalid source "memory" in source precedence`)
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// maxProjects limits the number of projects loaded from the projects path, each one has its own file watcher
+// of project sources, shared sources are watched once
+const maxProjects = 32
+
+// ProjectInput represents input of tools which take nothing but the project
+type ProjectInput struct {
+	Project string `json:"project,omitempty"` // project name from list_projects, the default project if empty
+}
+
+// ProjectInfo describes a project available to tools
+type ProjectInfo struct {
+	Name    string `json:"name"`
+	Root    string `json:"root"`
+	Default bool   `json:"default,omitempty"` // used when project is not set
+	Loaded  bool   `json:"loaded"`            // scanned and watched already
+	HasDocs bool   `json:"has_docs"`          // docs directory exists
+}
+
+// ListProjectsOutput contains the default project and projects found on the projects path
+type ListProjectsOutput struct {
+	Projects []ProjectInfo `json:"projects"`
+}
+
+// projectName returns name of the default project, the base name of its root
+func (c *Config) projectName() string {
+	if c.ProjectRoot == "" {
+		return ""
+	}
+	return filepath.Base(c.ProjectRoot)
+}
+
+// projectConfig returns configuration of the project with the given root. project directories of the default
+// project are moved to the same place under the new root, directories outside of the default project root
+// are not used. shared sources, commands, skills and global memory, are kept, git sources are not.
+func (c *Config) projectConfig(root string) Config {
+	rebase := func(dir string) string {
+		if dir == "" {
+			return ""
+		}
+		rel, err := filepath.Rel(c.ProjectRoot, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return ""
+		}
+		return filepath.Join(root, rel)
+	}
+
+	res := *c
+	res.ProjectRoot = root
+	res.ProjectDocsDir = rebase(c.ProjectDocsDir)
+	res.ProjectRootDir = rebase(c.ProjectRootDir)
+	res.MemoryRootDir = rebase(c.MemoryRootDir)
+	res.PlansDir = rebase(c.PlansDir)
+	res.ProjectsPath = nil
+	res.GitSources = nil
+	return res
+}
+
+// forProject returns server of the named project, loading the project from the projects path on first use.
+// empty name or the name of the default project returns s.
+func (s *Server) forProject(name string) (*Server, error) {
+	if name == "" || name == s.config.projectName() {
+		return s, nil
+	}
+	if len(s.config.ProjectsPath) == 0 {
+		return nil, fmt.Errorf("project %q not found, projects path is not configured", name)
+	}
+	if strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid project name %q", name)
+	}
+
+	s.projectsMu.Lock()
+	p, ok := s.projects[name]
+	loaded := len(s.projects)
+	s.projectsMu.Unlock()
+	if ok {
+		return p, nil
+	}
+	if loaded >= maxProjects {
+		return nil, fmt.Errorf("too many projects loaded, max %d", maxProjects)
+	}
+
+	// scanning and watching the project takes a while, it is done without the lock
+	root := s.findProject(name)
+	if root == "" {
+		return nil, fmt.Errorf("project %q not found in projects path", name)
+	}
+	p, err := newProject(s.config.projectConfig(root), s.shared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project %s: %w", name, err)
+	}
+	p.gaps = s.gaps
+
+	s.projectsMu.Lock()
+	defer s.projectsMu.Unlock()
+	if existing, ok := s.projects[name]; ok { // loaded by a concurrent call
+		_ = p.Close()
+		return existing, nil
+	}
+	if len(s.projects) >= maxProjects {
+		_ = p.Close()
+		return nil, fmt.Errorf("too many projects loaded, max %d", maxProjects)
+	}
+	if s.projects == nil {
+		s.projects = map[string]*Server{}
+	}
+	s.projects[name] = p
+	slog.Info("project loaded", "name", name, "root", root)
+	return p, nil
+}
+
+// findProject returns root of the named project, the first directory with this name on the projects path
+func (s *Server) findProject(name string) string {
+	for _, dir := range s.config.ProjectsPath {
+		root := filepath.Join(dir, name)
+		if st, err := os.Stat(root); err == nil && st.IsDir() {
+			return root
+		}
+	}
+	return ""
+}
+
+// listProjects returns the default project followed by directories of the projects path sorted by name.
+// a name found in several directories of the path is reported once, as it resolves to the first one.
+func (s *Server) listProjects() *ListProjectsOutput {
+	res := &ListProjectsOutput{Projects: []ProjectInfo{}}
+	seen := map[string]bool{}
+	hasDocs := func(c Config) bool {
+		st, err := os.Stat(c.ProjectDocsDir)
+		return c.ProjectDocsDir != "" && err == nil && st.IsDir()
+	}
+
+	if name := s.config.projectName(); name != "" {
+		seen[name] = true
+		res.Projects = append(res.Projects, ProjectInfo{Name: name, Root: s.config.ProjectRoot, Default: true,
+			Loaded: true, HasDocs: hasDocs(s.config)})
+	}
+
+	s.projectsMu.Lock()
+	loaded := make(map[string]bool, len(s.projects))
+	for name := range s.projects {
+		loaded[name] = true
+	}
+	s.projectsMu.Unlock()
+
+	var found []ProjectInfo
+	for _, dir := range s.config.ProjectsPath {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			slog.Debug("failed to read projects dir", "dir", dir, "error", err)
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || seen[e.Name()] {
+				continue
+			}
+			seen[e.Name()] = true
+			root := filepath.Join(dir, e.Name())
+			found = append(found, ProjectInfo{Name: e.Name(), Root: root, Loaded: loaded[e.Name()],
+				HasDocs: hasDocs(s.config.projectConfig(root))})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	res.Projects = append(res.Projects, found...)
+	return res
+}
+
+// cacheStats returns stats of the shared scanner and scanners of the default and loaded projects, summed up.
+// content cache is shared by all of them and is counted once.
+func (s *Server) cacheStats() scanner.CacheStats {
+	scanners := []fileScanner{s.scanner}
+	s.projectsMu.Lock()
+	for _, p := range s.projects {
+		scanners = append(scanners, p.scanner)
+	}
+	s.projectsMu.Unlock()
+
+	res := s.shared.Stats()
+	for _, sc := range scanners {
+		st := sc.Stats()
+		res.Hits += st.Hits
+		res.Misses += st.Misses
+		res.Invalidations += st.Invalidations
+		res.Scans += st.Scans
+		res.ScanTime += st.ScanTime
+		res.WatcherEvents += st.WatcherEvents
+		res.WatcherErrors += st.WatcherErrors
+		res.WatchedDirs += st.WatchedDirs
+		res.ContentHits += st.ContentHits
+		res.ContentMisses += st.ContentMisses
+		for src, n := range st.Files {
+			if res.Files == nil {
+				res.Files = map[scanner.Source]int{}
+			}
+			res.Files[src] += n
+		}
+	}
+	return res
+}
+
+// closeProjects closes scanners and repositories of loaded projects
+func (s *Server) closeProjects() error {
+	s.projectsMu.Lock()
+	defer s.projectsMu.Unlock()
+	var errs []error
+	for _, p := range s.projects {
+		errs = append(errs, p.Close())
+	}
+	s.projects = nil
+	return errors.Join(errs...)
+}
+
+// registerProjectTools registers list_projects tool if projects path is configured
+func (s *Server) registerProjectTools() {
+	if len(s.config.ProjectsPath) == 0 {
+		return
+	}
+
+	addTool(s, &mcp.Tool{
+		Name: "list_projects",
+		Description: "List projects available to other tools with the project parameter: the default project and " +
+			"project directories of the projects path. Projects are loaded on first use.",
+	}, s.handleListProjects)
+}
+
+// handleListProjects handles list_projects tool calls.
+// input is required by MCP SDK signature but list_projects takes no parameters.
+func (s *Server) handleListProjects(_ context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+	slog.Debug("list_projects called")
+
+	result := s.listProjects()
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// setupProjects makes default project "main" and projects "api" and "web" in the projects dir, with a doc each
+func setupProjects(t *testing.T) (cfg Config, projectsDir string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	projectsDir = filepath.Join(tmpDir, "src")
+	require.NoError(t, os.MkdirAll(commandsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(commandsDir, "shared.md"), []byte("# Shared\n"), 0600))
+	for _, p := range []string{"main", "api", "web"} {
+		docsDir := filepath.Join(projectsDir, p, "docs")
+		require.NoError(t, os.MkdirAll(filepath.Join(docsDir, "plans"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(docsDir, p+"-guide.md"), []byte("# "+p+" guide\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(docsDir, "plans", p+"-plan.md"), []byte("# Plan\n- [x] done\n- [ ] todo\n"), 0600))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(projectsDir, ".hidden"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectsDir, "notes.txt"), []byte("not a project"), 0600))
+
+	root := filepath.Join(projectsDir, "main")
+	return Config{
+		CommandsDir:    commandsDir,
+		ProjectDocsDir: filepath.Join(root, "docs"),
+		PlansDir:       filepath.Join(root, "docs", "plans"),
+		ExcludeDirs:    []string{"plans"},
+		MaxFileSize:    1024,
+		ServerName:     "test-server",
+		ProjectRoot:    root,
+		ProjectsPath:   []string{projectsDir},
+	}, projectsDir
+}
+
+func TestServer_Projects(t *testing.T) {
+	cfg, projectsDir := setupProjects(t)
+	srv, err := New(cfg)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+
+	call := func(tool string, args map[string]any, out any) *mcp.CallToolResult {
+		res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		require.NoError(t, err)
+		if !res.IsError && out != nil {
+			require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), out))
+		}
+		return res
+	}
+
+	var list ListProjectsOutput
+	call("list_projects", map[string]any{}, &list)
+	assert.Equal(t, []ProjectInfo{
+		{Name: "main", Root: filepath.Join(projectsDir, "main"), Default: true, Loaded: true, HasDocs: true},
+		{Name: "api", Root: filepath.Join(projectsDir, "api"), HasDocs: true},
+		{Name: "web", Root: filepath.Join(projectsDir, "web"), HasDocs: true},
+	}, list.Projects)
+
+	t.Run("default project", func(t *testing.T) {
+		var out ListOutput
+		call("list_all_docs", map[string]any{}, &out)
+		assert.ElementsMatch(t, []string{"commands:shared.md", "project-docs:main-guide.md"}, docPaths(out.Docs))
+		call("list_all_docs", map[string]any{"project": "main"}, &out)
+		assert.ElementsMatch(t, []string{"commands:shared.md", "project-docs:main-guide.md"}, docPaths(out.Docs))
+	})
+
+	t.Run("named project shares commands", func(t *testing.T) {
+		var out ListOutput
+		call("list_all_docs", map[string]any{"project": "api"}, &out)
+		assert.ElementsMatch(t, []string{"commands:shared.md", "project-docs:api-guide.md"}, docPaths(out.Docs))
+
+		var search SearchOutput
+		call("search_docs", map[string]any{"query": "web guide", "project": "web"}, &search)
+		require.NotEmpty(t, search.Results)
+		assert.Equal(t, "project-docs:web-guide.md", search.Results[0].Path)
+
+		var read ReadOutput
+		call("read_doc", map[string]any{"path": "api-guide.md", "project": "api"}, &read)
+		assert.Equal(t, "# api guide\n", read.Content)
+		res := call("read_doc", map[string]any{"path": "api-guide.md"}, nil)
+		assert.True(t, res.IsError, "api doc is not in the default project")
+	})
+
+	t.Run("plans of named project", func(t *testing.T) {
+		var plans ListPlansOutput
+		call("list_plans", map[string]any{"project": "web"}, &plans)
+		require.Len(t, plans.Active, 1)
+		assert.Equal(t, "web-plan.md", plans.Active[0].Path)
+		assert.Equal(t, 50, plans.Active[0].Progress)
+	})
+
+	t.Run("unknown and invalid projects", func(t *testing.T) {
+		for _, p := range []string{"missing", "notes.txt", ".hidden", "../main", "api/docs"} {
+			res := call("list_all_docs", map[string]any{"project": p}, nil)
+			assert.True(t, res.IsError, p)
+		}
+	})
+
+	call("list_projects", map[string]any{}, &list)
+	loaded := map[string]bool{}
+	for _, p := range list.Projects {
+		loaded[p.Name] = p.Loaded
+	}
+	assert.Equal(t, map[string]bool{"main": true, "api": true, "web": true}, loaded)
+
+	// projects are loaded once
+	api, err := srv.forProject("api")
+	require.NoError(t, err)
+	again, err := srv.forProject("api")
+	require.NoError(t, err)
+	assert.Same(t, api, again)
+
+	require.NoError(t, srv.closeProjects())
+	assert.Empty(t, srv.projects)
+}
+
+func TestServer_ProjectsShareScanner(t *testing.T) {
+	cfg, _ := setupProjects(t)
+	cfg.ContentCacheSize = 1024
+	srv, err := New(cfg)
+	require.NoError(t, err)
+	defer srv.Close()
+	ctx := context.Background()
+
+	// concurrent first use loads the project once
+	var wg sync.WaitGroup
+	loaded := make([]*Server, 8)
+	for i := range loaded {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := srv.forProject([]string{"api", "web"}[i%2])
+			assert.NoError(t, err)
+			loaded[i] = p
+		}()
+	}
+	wg.Wait()
+	for i := range loaded {
+		assert.Same(t, loaded[i%2], loaded[i])
+	}
+	assert.Len(t, srv.projects, 2)
+	api, web := loaded[0], loaded[1]
+
+	for _, p := range []*Server{srv, api, web} {
+		files, err := p.scanner.Scan(ctx)
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		assert.Equal(t, "commands:shared.md", files[0].Filename, "shared files listed first, as commands")
+	}
+	stats := srv.cacheStats()
+	assert.Equal(t, map[scanner.Source]int{scanner.SourceCommands: 1, scanner.SourceProjectDocs: 3}, stats.Files,
+		"shared source is scanned once, docs of all projects are counted")
+	assert.Equal(t, int64(4), stats.Scans)
+
+	// content cache is shared
+	for _, p := range []*Server{api, web} {
+		res, err := p.readDoc(ctx, "commands:shared.md", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "# Shared\n", res.Content)
+	}
+	stats = srv.cacheStats()
+	assert.Equal(t, int64(1), stats.ContentHits)
+	assert.Equal(t, int64(1), stats.ContentMisses)
+	assert.Equal(t, 1, stats.ContentDocs)
+
+	// change of a shared doc is seen by all projects
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.CommandsDir, "new.md"), []byte("# New\n"), 0600))
+	for _, p := range []*Server{srv, api, web} {
+		assert.Eventually(t, func() bool {
+			res, err := p.readDoc(ctx, "commands:new.md", nil)
+			return err == nil && res.Content == "# New\n"
+		}, 2*time.Second, 50*time.Millisecond)
+	}
+}
+
+func TestServer_ProjectsNotConfigured(t *testing.T) {
+	srv, err := New(Config{MaxFileSize: 1024, ServerName: "test-server"})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	p, err := srv.forProject("")
+	require.NoError(t, err)
+	assert.Same(t, srv, p)
+	_, err = srv.forProject("api")
+	require.ErrorContains(t, err, "projects path is not configured")
+
+	_, err = New(Config{MaxFileSize: 1024, ServerName: "test-server", ProjectsPath: []string{t.TempDir()}})
+	require.ErrorContains(t, err, "project root is required")
+}
+
+func TestConfig_ProjectConfig(t *testing.T) {
+	c := Config{
+		CommandsDir:     "/home/u/.claude/commands",
+		ProjectDocsDir:  "/src/main/docs",
+		ProjectRootDir:  "/src/main",
+		MemoryRootDir:   "/src/main",
+		GlobalMemoryDir: "/home/u/.claude",
+		PlansDir:        "/elsewhere/plans",
+		ProjectRoot:     "/src/main",
+		ProjectsPath:    []string{"/src"},
+	}
+	res := c.projectConfig("/src/api")
+	assert.Equal(t, "/home/u/.claude/commands", res.CommandsDir)
+	assert.Equal(t, "/src/api/docs", res.ProjectDocsDir)
+	assert.Equal(t, "/src/api", res.ProjectRootDir)
+	assert.Equal(t, "/src/api", res.MemoryRootDir)
+	assert.Equal(t, "/home/u/.claude", res.GlobalMemoryDir)
+	assert.Empty(t, res.PlansDir, "dir outside of the default project is not used")
+	assert.Equal(t, "/src/api", res.ProjectRoot)
+	assert.Empty(t, res.ProjectsPath)
+	assert.Equal(t, "api", res.projectName())
+}
+
+func docPaths(docs []DocInfo) []string {
+	res := make([]string, 0, len(docs))
+	for _, d := range docs {
+		res = append(res, d.Filename)
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"path"
+	"strings"
+
+	"github.com/umputun/local-docs-mcp/app/query"
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// parseQuery parses search query, errors have position of the syntax error
+func parseQuery(q string) (*query.Query, error) {
+	return query.Parse(q) // nolint:wrapcheck // query error is descriptive
+}
+
+// qualifierOnlyScore is the score of a doc matched by qualifiers only, in a query with unqualified terms
+// matched by other docs. it ranks the doc below docs matching any of the terms.
+const qualifierOnlyScore = 0.01
+
+// scoreQuery returns score of the file for query with syntax, zero if the file doesn't match. the score is
+// the sum of scores of unqualified terms which are not negated, 1 if the query has only qualifiers and
+// qualifierOnlyScore if the query has unqualified terms but the file matched by qualifiers only.
+func (s *Server) scoreQuery(p ranking.Profile, q *query.Query, file scanner.FileInfo) float64 {
+	scores := map[query.Term]float64{}
+	termScore := func(t query.Term) float64 {
+		if v, ok := scores[t]; ok {
+			return v
+		}
+		var v float64
+		if t.Field == "" {
+			normalized := strings.ToLower(t.Value)
+			v = s.calculateScore(p, strings.ReplaceAll(normalized, " ", "-"), normalized, file)
+		} else if matchField(t, file) {
+			v = 1
+		}
+		scores[t] = v
+		return v
+	}
+
+	if !q.Match(func(t query.Term) bool { return termScore(t) > 0 }) {
+		return 0
+	}
+
+	var score float64
+	hasTerms := false
+	for _, t := range q.Terms() {
+		if t.Field == "" {
+			score += termScore(t)
+			hasTerms = true
+		}
+	}
+	switch {
+	case score > 0:
+		return score
+	case hasTerms:
+		return qualifierOnlyScore // matched by qualifiers of a different OR branch
+	default:
+		return 1
+	}
+}
+
+// matchField checks qualified term against the file, values are compared case-insensitively.
+// tag: matches a tag exactly, source: the source or git source name, path: a part of the path
+// or a glob pattern matching the path or file name, desc: a part of the description.
+func matchField(t query.Term, file scanner.FileInfo) bool {
+	value := strings.ToLower(t.Value)
+	switch t.Field {
+	case query.FieldTag:
+		for _, tag := range file.Tags {
+			if strings.EqualFold(tag, value) {
+				return true
+			}
+		}
+	case query.FieldSource:
+		return strings.EqualFold(string(file.Source), value) || strings.HasPrefix(strings.ToLower(file.Filename), value+":")
+	case query.FieldPath:
+		_, p, _ := strings.Cut(strings.ToLower(file.Filename), ":")
+		if strings.ContainsAny(value, "*?[") {
+			full, _ := path.Match(value, p)
+			base, _ := path.Match(value, path.Base(p))
+			return full || base
+		}
+		return strings.Contains(p, value)
+	case query.FieldDesc:
+		return strings.Contains(strings.ToLower(file.Description), value)
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/query"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+func TestServer_SearchDocsQuery(t *testing.T) {
+	files := map[string]string{
+		"commands/go-testing.md":  "---\ntags: [testing, go]\n---\n",
+		"docs/go-testing.md":      "---\ntags: [testing]\ndescription: table tests and fixtures\n---\n",
+		"docs/ops/deploy.md":      "---\ndescription: rollout and rollback\n---\n",
+		"docs/ops/release.md":     "# Release\n",
+		"docs/architecture.md":    "---\ntags: [design]\n---\n",
+		"docs/ops/monitoring.txt": "not markdown",
+	}
+
+	srv, _ := newTestServer(t, files, Config{})
+
+	tbl := []struct {
+		query string
+		paths []string
+	}{
+		{"tag:testing", []string{"commands:go-testing.md", "project-docs:go-testing.md"}},
+		{"tag:testing -source:commands", []string{"project-docs:go-testing.md"}},
+		{"tag:Testing source:project-docs", []string{"project-docs:go-testing.md"}},
+		{"path:ops", []string{"project-docs:ops/deploy.md", "project-docs:ops/release.md"}},
+		{"path:ops/d*.md", []string{"project-docs:ops/deploy.md"}},
+		{"path:release.*", []string{"project-docs:ops/release.md"}},
+		{"desc:rollback", []string{"project-docs:ops/deploy.md"}},
+		{`desc:"table tests"`, []string{"project-docs:go-testing.md"}},
+		{"(deploy OR release) path:ops", []string{"project-docs:ops/deploy.md", "project-docs:ops/release.md"}},
+		{"tag:design OR tag:go", []string{"commands:go-testing.md", "project-docs:architecture.md"}},
+		{`"go testing" -source:commands`, []string{"project-docs:go-testing.md"}},
+		{"tag:missing", nil},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.query, func(t *testing.T) {
+			res, err := srv.searchDocs(context.Background(), tt.query, searchOptions{})
+			require.NoError(t, err)
+			var paths []string
+			for _, r := range res.Results {
+				paths = append(paths, r.Path)
+				assert.Positive(t, r.Score)
+			}
+			assert.ElementsMatch(t, tt.paths, paths)
+			assert.Equal(t, len(tt.paths), res.Total)
+		})
+	}
+
+	t.Run("plain query keeps matching as a whole", func(t *testing.T) {
+		res, err := srv.searchDocs(context.Background(), "go testing", searchOptions{})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 2)
+		// both terms are exact path words with tag boost 0.3 in commands, only "testing" is a tag in project docs
+		assert.Equal(t, "commands:go-testing.md", res.Results[0].Path)
+		assert.InDelta(t, 1.3, res.Results[0].Score, 1e-9)
+		assert.Equal(t, "project-docs:go-testing.md", res.Results[1].Path)
+		assert.InDelta(t, (1.0+1.3)/2, res.Results[1].Score, 1e-9)
+	})
+
+	t.Run("qualifier only matches rank below term matches", func(t *testing.T) {
+		res, err := srv.searchDocs(context.Background(), "rollout OR tag:design", searchOptions{})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 2)
+		assert.Equal(t, "project-docs:ops/deploy.md", res.Results[0].Path, "matched by description boost only")
+		assert.InDelta(t, 0.5, res.Results[0].Score, 1e-9)
+		assert.Equal(t, "project-docs:architecture.md", res.Results[1].Path)
+		assert.InDelta(t, qualifierOnlyScore, res.Results[1].Score, 1e-9)
+
+		res, err = srv.searchDocs(context.Background(), "tag:design", searchOptions{})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		assert.InDelta(t, 1.0, res.Results[0].Score, 1e-9, "query of qualifiers only")
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := srv.searchDocs(context.Background(), "(deploy OR release", searchOptions{})
+		require.EqualError(t, err, "query syntax error at position 1: missing )")
+	})
+}
+
+func TestMatchField(t *testing.T) {
+	file := scanner.FileInfo{Filename: "release@v1:ops/Deploy.md", Source: scanner.SourceGit, Tags: []string{"Ops"},
+		Description: "Rollout steps"}
+	tbl := []struct {
+		term  query.Term
+		match bool
+	}{
+		{query.Term{Field: query.FieldTag, Value: "ops"}, true},
+		{query.Term{Field: query.FieldTag, Value: "op"}, false},
+		{query.Term{Field: query.FieldSource, Value: "git"}, true},
+		{query.Term{Field: query.FieldSource, Value: "release@v1"}, true},
+		{query.Term{Field: query.FieldSource, Value: "release"}, false},
+		{query.Term{Field: query.FieldPath, Value: "ops/dep"}, true},
+		{query.Term{Field: query.FieldPath, Value: "release"}, false},
+		{query.Term{Field: query.FieldPath, Value: "*/deploy.md"}, true},
+		{query.Term{Field: query.FieldPath, Value: "deploy.*"}, true},
+		{query.Term{Field: query.FieldDesc, Value: "rollout"}, true},
+		{query.Term{Field: query.FieldDesc, Value: "rollback"}, false},
+		{query.Term{Field: "other", Value: "x"}, false},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.match, matchField(tt.term, file), "%+v", tt.term)
+	}
+}
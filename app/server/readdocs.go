@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/umputun/local-docs-mcp/app/markdown"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+const (
+	maxReadDocs     = 20         // docs in a single read_docs call
+	readDocsWorkers = 4          // docs read concurrently
+	readDocsBudget  = 256 * 1024 // total content bytes of a read_docs call
+)
+
+// ReadDocsInput represents input for reading several docs in one call
+type ReadDocsInput struct {
+	Docs     []ReadDocsItem `json:"docs"`
+	MaxBytes int            `json:"max_bytes,omitempty"` // total content budget, lowers the server budget
+	Project  string         `json:"project,omitempty"`
+}
+
+// ReadDocsItem is a doc to read, the whole doc or one section of it
+type ReadDocsItem struct {
+	Path    string  `json:"path"`
+	Source  *string `json:"source,omitempty"`
+	Section string  `json:"section,omitempty"` // heading text, the doc part under it is returned
+	// ExpandIncludes replaces include directives with contents of included docs
+	ExpandIncludes bool `json:"expand_includes,omitempty"`
+	// Strict rejects path without source prefix found in several sources, listing the candidates
+	Strict bool `json:"strict,omitempty"`
+	// IfNoneMatch is the hash of a previous read, content is not returned if the doc has the same hash
+	IfNoneMatch string `json:"if_none_match,omitempty"`
+}
+
+// ReadDocsResult is the result of reading a single doc, in order of the input
+type ReadDocsResult struct {
+	Path    string      `json:"path"` // as requested
+	Section string      `json:"section,omitempty"`
+	Doc     *ReadOutput `json:"doc,omitempty"`
+	// Truncated is set if content was cut to fit the byte budget
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+
+	missing string // requested doc name if the doc doesn't exist, recorded in the audit log
+}
+
+// ReadDocsOutput contains results of reading several docs
+type ReadDocsOutput struct {
+	Results []ReadDocsResult `json:"results"`
+	Bytes   int              `json:"bytes"`  // total content bytes returned
+	Budget  int              `json:"budget"` // total content budget applied
+	Failed  int              `json:"failed"` // results with error
+}
+
+// readDocs reads docs concurrently, each one like read_doc does. failures are reported per doc, the byte budget
+// is spent in order of the input, the doc crossing it is truncated and docs after it return an error.
+func (s *Server) readDocs(ctx context.Context, input ReadDocsInput) (*ReadDocsOutput, error) {
+	if len(input.Docs) == 0 {
+		return nil, fmt.Errorf("no docs to read")
+	}
+	if len(input.Docs) > maxReadDocs {
+		return nil, fmt.Errorf("too many docs: %d, max %d", len(input.Docs), maxReadDocs)
+	}
+	budget := readDocsBudget
+	if input.MaxBytes > 0 {
+		budget = min(budget, input.MaxBytes)
+	}
+
+	results := make([]ReadDocsResult, len(input.Docs))
+	items := make(chan int)
+	var wg sync.WaitGroup
+	for range min(readDocsWorkers, len(input.Docs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				results[i] = s.readDocsItem(ctx, input.Docs[i])
+			}
+		}()
+	}
+	for i := range input.Docs {
+		items <- i
+	}
+	close(items)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err // nolint:wrapcheck // context errors should be returned as-is
+	}
+
+	res := &ReadDocsOutput{Results: results, Budget: budget}
+	for i := range results {
+		r := &results[i]
+		if r.Doc != nil {
+			switch left := budget - res.Bytes; {
+			case left <= 0:
+				r.Doc, r.Error = nil, fmt.Sprintf("byte budget of %d exhausted", budget)
+			case len(r.Doc.Content) > left:
+				// hash of the whole doc doesn't match truncated content, it can't be used in if_none_match
+				r.Doc.Content, r.Doc.Hash, r.Truncated = truncateUTF8(r.Doc.Content, left), "", true
+				r.Doc.Size = len(r.Doc.Content)
+			}
+		}
+		if r.Doc != nil {
+			res.Bytes += len(r.Doc.Content)
+		}
+		if r.Error != "" {
+			res.Failed++
+		}
+	}
+	return res, nil
+}
+
+// readDocsItem reads a single doc of read_docs like read_doc does, errors are returned in the result
+func (s *Server) readDocsItem(ctx context.Context, item ReadDocsItem) ReadDocsResult {
+	res := ReadDocsResult{Path: item.Path, Section: item.Section}
+	doc, err := s.readDocInput(ctx, ReadInput{Path: item.Path, Source: item.Source, ExpandIncludes: item.ExpandIncludes,
+		Strict: item.Strict, IfNoneMatch: item.IfNoneMatch})
+	if err != nil {
+		res.Error = err.Error()
+		if errors.Is(err, scanner.ErrNotFound) {
+			res.missing = requestedName(item.Path, item.Source)
+		}
+		return res
+	}
+	if item.Section != "" && !doc.NotModified {
+		section, ok := markdown.Section(doc.Content, item.Section)
+		if !ok {
+			res.Error = fmt.Sprintf("section %q not found in %s", item.Section, docName(doc.Source, doc.Path))
+			return res
+		}
+		doc.Content, doc.Size = section, len(section)
+	}
+	res.Doc = doc
+	return res
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a multi-byte rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func (r *ReadDocsOutput) servedPaths() []string {
+	var res []string
+	for _, item := range r.Results {
+		if item.Doc != nil {
+			res = append(res, item.Doc.servedPaths()...)
+		}
+	}
+	return res
+}
+
+// handleReadDocs handles read_docs tool calls
+func (s *Server) handleReadDocs(ctx context.Context, _ *mcp.CallToolRequest, input ReadDocsInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("read_docs called", "docs", len(input.Docs), "max_bytes", input.MaxBytes, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.readDocs(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read failed: %w", err)
+	}
+
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReadDocs(t *testing.T) Config {
+	t.Helper()
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	docsDir := filepath.Join(tmpDir, "docs")
+	files := map[string]string{
+		filepath.Join(commandsDir, "commit.md"):    "---\ndescription: commit\n---\n# Commit\n\nwrite a message\n",
+		filepath.Join(docsDir, "guide.md"):         "# Guide\n\n## Install\n\nrun make\n\n## Usage\n\nrun it\n",
+		filepath.Join(docsDir, "ops", "deploy.md"): "# Deploy\n\nship it\n",
+		filepath.Join(docsDir, "ops", "all.md"):    "# All\n\n<!-- include: deploy.md -->\n",
+		filepath.Join(docsDir, "unicode.md"):       "привет мир",
+	}
+	for p, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+	return Config{CommandsDir: commandsDir, ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test"}
+}
+
+func TestServer_ReadDocs(t *testing.T) {
+	srv, err := New(setupReadDocs(t))
+	require.NoError(t, err)
+	defer srv.Close()
+	ctx := context.Background()
+
+	t.Run("docs in order with per-doc errors", func(t *testing.T) {
+		res, err := srv.readDocs(ctx, ReadDocsInput{Docs: []ReadDocsItem{
+			{Path: "commit.md"},
+			{Path: "missing.md"},
+			{Path: "guide.md", Section: "install"},
+			{Path: "deploy"},
+			{Path: "guide.md", Section: "Configuration"},
+			{Path: "../etc/passwd"},
+		}})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 6)
+
+		assert.Equal(t, "# Commit\n\nwrite a message\n", res.Results[0].Doc.Content, "frontmatter stripped")
+		assert.Equal(t, "commands", res.Results[0].Doc.Source)
+		assert.Equal(t, "file not found in any source: missing.md", res.Results[1].Error)
+		assert.Nil(t, res.Results[1].Doc)
+		assert.Equal(t, "## Install\n\nrun make\n\n", res.Results[2].Doc.Content)
+		assert.Equal(t, len("## Install\n\nrun make\n\n"), res.Results[2].Doc.Size)
+		assert.Equal(t, "install", res.Results[2].Section)
+		assert.Equal(t, "deploy", res.Results[3].Doc.ResolvedFrom)
+		assert.Equal(t, `section "Configuration" not found in project-docs:guide.md`, res.Results[4].Error)
+		assert.NotEmpty(t, res.Results[5].Error)
+
+		assert.Equal(t, 3, res.Failed)
+		assert.Equal(t, readDocsBudget, res.Budget)
+		assert.Equal(t, len(res.Results[0].Doc.Content)+len(res.Results[2].Doc.Content)+len(res.Results[3].Doc.Content),
+			res.Bytes)
+		assert.Equal(t, []string{"commands:commit.md", "project-docs:guide.md", "project-docs:ops/deploy.md"},
+			res.servedPaths())
+	})
+
+	t.Run("byte budget", func(t *testing.T) {
+		res, err := srv.readDocs(ctx, ReadDocsInput{MaxBytes: 34, Docs: []ReadDocsItem{
+			{Path: "commit.md"}, {Path: "missing.md"}, {Path: "ops/deploy.md"}, {Path: "guide.md"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, 34, res.Budget)
+		assert.Equal(t, 34, res.Bytes)
+		assert.False(t, res.Results[0].Truncated)
+		assert.Equal(t, "# Deploy", res.Results[2].Doc.Content)
+		assert.True(t, res.Results[2].Truncated)
+		assert.Equal(t, len("# Deploy"), res.Results[2].Doc.Size)
+		assert.Empty(t, res.Results[2].Doc.Hash, "hash of the whole doc is not returned for truncated content")
+		assert.NotEmpty(t, res.Results[0].Doc.Hash)
+		assert.Nil(t, res.Results[3].Doc)
+		assert.Equal(t, "byte budget of 34 exhausted", res.Results[3].Error)
+		assert.Equal(t, 2, res.Failed)
+
+		res, err = srv.readDocs(ctx, ReadDocsInput{MaxBytes: readDocsBudget * 2, Docs: []ReadDocsItem{{Path: "commit.md"}}})
+		require.NoError(t, err)
+		assert.Equal(t, readDocsBudget, res.Budget, "max_bytes can't raise the server budget")
+	})
+
+	t.Run("truncated at rune boundary", func(t *testing.T) {
+		res, err := srv.readDocs(ctx, ReadDocsInput{MaxBytes: 5, Docs: []ReadDocsItem{{Path: "unicode.md"}}})
+		require.NoError(t, err)
+		assert.Equal(t, "пр", res.Results[0].Doc.Content)
+		assert.Equal(t, 4, res.Bytes)
+	})
+
+	t.Run("expand includes and strict", func(t *testing.T) {
+		res, err := srv.readDocs(ctx, ReadDocsInput{Docs: []ReadDocsItem{
+			{Path: "ops/all.md", ExpandIncludes: true},
+			{Path: "ops/all.md"},
+			{Path: "ops/all.md", ExpandIncludes: true, Section: "deploy"},
+			{Path: "guide.md", Strict: true},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, "# All\n\n# Deploy\n\nship it\n", res.Results[0].Doc.Content)
+		assert.Equal(t, []string{"project-docs:ops/deploy.md"}, res.Results[0].Doc.Includes)
+		assert.Equal(t, "# All\n\n<!-- include: deploy.md -->\n", res.Results[1].Doc.Content)
+		assert.Equal(t, "# Deploy\n\nship it\n", res.Results[2].Doc.Content)
+		require.NotNil(t, res.Results[3].Doc, "single candidate is not ambiguous")
+		assert.Equal(t, "project-docs", res.Results[3].Doc.Source)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := srv.readDocs(ctx, ReadDocsInput{})
+		require.EqualError(t, err, "no docs to read")
+		_, err = srv.readDocs(ctx, ReadDocsInput{Docs: make([]ReadDocsItem, maxReadDocs+1)})
+		require.EqualError(t, err, "too many docs: 21, max 20")
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = srv.readDocs(canceled, ReadDocsInput{Docs: []ReadDocsItem{{Path: "commit.md"}}})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("many docs", func(t *testing.T) {
+		docs := make([]ReadDocsItem, maxReadDocs)
+		for i := range docs {
+			docs[i] = ReadDocsItem{Path: []string{"commit.md", "guide.md", "ops/deploy.md"}[i%3]}
+		}
+		res, err := srv.readDocs(ctx, ReadDocsInput{Docs: docs})
+		require.NoError(t, err)
+		for i, r := range res.Results {
+			require.NotNil(t, r.Doc, r.Error)
+			assert.Equal(t, docs[i].Path, r.Doc.Path)
+		}
+	})
+}
+
+func TestServer_ReadDocsTool(t *testing.T) {
+	cfg := setupReadDocs(t)
+	cfg.StrictPaths = true
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.CommandsDir, "guide.md"), []byte("shared guide"), 0600))
+	srv, err := New(cfg)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	ctx := context.Background()
+	session := newTestSession(t, srv)
+
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "read_docs", Arguments: map[string]any{
+		"docs": []map[string]any{{"path": "guide.md"}, {"path": "project-docs:guide.md", "section": "usage"}},
+	}})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	var out ReadDocsOutput
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+	require.Len(t, out.Results, 2)
+	assert.True(t, strings.HasPrefix(out.Results[0].Error, "ambiguous path guide.md"), "strict paths apply to each doc")
+	assert.Equal(t, "## Usage\n\nrun it\n", out.Results[1].Doc.Content)
+
+	res, err = session.CallTool(ctx, &mcp.CallToolParams{Name: "read_docs", Arguments: map[string]any{"docs": []any{}}})
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Equal(t, "read failed: no docs to read", res.Content[0].(*mcp.TextContent).Text)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"maps"
+
+	"github.com/umputun/local-docs-mcp/app/redact"
+)
+
+// redactDoc masks secrets in content of the doc and adds redaction counts to its metadata.
+// content is returned as is if redaction is disabled.
+func (s *Server) redactDoc(doc *ReadOutput) {
+	content, counts := s.redactText(doc.Content)
+	if counts == nil {
+		return
+	}
+	doc.Content = content
+	doc.Size = len(content)
+	doc.Redactions = addCounts(doc.Redactions, counts)
+}
+
+// redactText masks secrets in text, returns the result and number of redactions by rule
+func (s *Server) redactText(text string) (string, map[string]int) {
+	if s.config.Redactor == nil {
+		return text, nil
+	}
+	res, findings := s.config.Redactor.Redact(text)
+	return res, redact.Counts(findings)
+}
+
+// addCounts adds counts to dst, allocating it if needed
+func addCounts(dst, counts map[string]int) map[string]int {
+	if counts == nil {
+		return dst
+	}
+	if dst == nil {
+		return maps.Clone(counts)
+	}
+	for k, v := range counts {
+		dst[k] += v
+	}
+	return dst
+}
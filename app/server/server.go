@@ -3,29 +3,59 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/sahilm/fuzzy"
 
+	"github.com/umputun/local-docs-mcp/app/audit"
+	"github.com/umputun/local-docs-mcp/app/gaps"
+	"github.com/umputun/local-docs-mcp/app/gitrepo"
+	"github.com/umputun/local-docs-mcp/app/lint"
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/redact"
 	"github.com/umputun/local-docs-mcp/app/scanner"
 )
 
 // Config defines server configuration
 type Config struct {
-	CommandsDir    string
-	ProjectDocsDir string
-	ProjectRootDir string
-	ExcludeDirs    []string
-	MaxFileSize    int64
-	ServerName     string
-	Version        string
-	CacheTTL       time.Duration
+	CommandsDir     string
+	ProjectDocsDir  string
+	ProjectRootDir  string
+	ExcludeDirs     []string
+	MaxFileSize     int64
+	ServerName      string
+	Version         string
+	CacheTTL        time.Duration
+	LintSchema      lint.Schema
+	GitSources      []scanner.GitSource
+	SkillsDir       string
+	MemoryRootDir   string
+	GlobalMemoryDir string
+	PlansDir        string           // plans directory, plan tools are disabled if empty
+	EnablePlanEdit  bool             // allow complete_task to change plan files
+	Redactor        *redact.Redactor // masks secrets in returned content, redaction is disabled if nil
+	AuditLog        string           // JSONL log of tool calls, disabled if empty
+	AuditMaxSize    int64            // audit log size to rotate at, no rotation if zero
+	AuditBackups    int              // rotated audit logs to keep
+	MetricsListen   string           // address to serve Prometheus metrics on, disabled if empty
+	EnablePprof     bool             // serve pprof handlers on the metrics address
+	GapsFile        string           // store of unmet search queries, disabled if empty
+	GapsThreshold   float64          // top search score below which a query is recorded as a gap
+	ProjectRoot     string           // root of the default project, its name is the base name of the root
+	ProjectsPath    []string         // directories with roots of other projects, loaded by name on first use
+	// RankingProfiles are named weights of search scoring, the built-in profile is used as the default one if not set
+	RankingProfiles map[string]ranking.Profile
+	// SourcePrecedence is the order of sources tried for doc paths without source prefix, shared first if empty
+	SourcePrecedence []scanner.Source
+	StrictPaths      bool  // reject doc paths without source prefix found in several sources
+	ContentCacheSize int64 // bytes of doc contents cached in memory, shared by all projects, disabled if zero
 }
 
 // Validate checks if the configuration is valid
@@ -36,7 +66,51 @@ func (c *Config) Validate() error {
 	if c.MaxFileSize <= 0 {
 		return fmt.Errorf("max file size must be greater than zero")
 	}
-	return nil
+	if len(c.ProjectsPath) > 0 && c.ProjectRoot == "" {
+		return fmt.Errorf("project root is required with projects path")
+	}
+	seen := map[string]bool{}
+	for _, g := range c.GitSources {
+		if seen[g.Prefix()] {
+			return fmt.Errorf("duplicate git source %s", g.Prefix())
+		}
+		seen[g.Prefix()] = true
+	}
+	return validatePrecedence(c.SourcePrecedence)
+}
+
+// ScannerParams returns parameters for creating a scanner matching this configuration
+func (c *Config) ScannerParams() scanner.Params {
+	return scanner.Params{
+		CommandsDir:     c.CommandsDir,
+		ProjectDocsDir:  c.ProjectDocsDir,
+		ProjectRootDir:  c.ProjectRootDir,
+		MaxFileSize:     c.MaxFileSize,
+		ExcludeDirs:     c.ExcludeDirs,
+		GitSources:      c.GitSources,
+		SkillsDir:       c.SkillsDir,
+		MemoryRootDir:   c.MemoryRootDir,
+		GlobalMemoryDir: c.GlobalMemoryDir,
+	}
+}
+
+// sharedScannerParams returns parameters of the scanner of sources shared by all projects: commands, skills
+// and global memory
+func (c *Config) sharedScannerParams() scanner.Params {
+	return scanner.Params{
+		CommandsDir:     c.CommandsDir,
+		MaxFileSize:     c.MaxFileSize,
+		ExcludeDirs:     c.ExcludeDirs,
+		SkillsDir:       c.SkillsDir,
+		GlobalMemoryDir: c.GlobalMemoryDir,
+	}
+}
+
+// projectScannerParams returns parameters of the scanner of project sources, without the shared ones
+func (c *Config) projectScannerParams() scanner.Params {
+	res := c.ScannerParams()
+	res.CommandsDir, res.SkillsDir, res.GlobalMemoryDir = "", "", ""
+	return res
 }
 
 // fileScanner defines what the server needs from a scanner
@@ -45,6 +119,14 @@ type fileScanner interface {
 	CommandsDir() string
 	ProjectDocsDir() string
 	ProjectRootDir() string
+	SkillsDir() string
+	ResolveMemoryPath(name string, maxSize int64) (string, error)
+	ReadGitFile(prefix, path string, maxSize int64) ([]byte, error)
+	Expansion(path string) (*scanner.Expansion, bool)
+	SetExpansion(path string, exp *scanner.Expansion)
+	Version(path string) (scanner.DocVersion, error)
+	Document(path string) (scanner.DocContent, error)
+	Stats() scanner.CacheStats
 	Close() error
 }
 
@@ -53,6 +135,19 @@ type Server struct {
 	config  Config
 	scanner fileScanner
 	mcp     *mcp.Server
+
+	reposMu sync.Mutex
+	repos   map[string]*gitrepo.Repo // repositories opened by history tools, by path
+
+	plansMu sync.Mutex // serializes plan file changes
+
+	audit   *audit.Logger  // nil if audit log is disabled
+	metrics *serverMetrics // nil if metrics are disabled
+	gaps    *gaps.Store    // nil if gaps recording is disabled
+
+	shared     *scanner.CachedScanner // scanner of commands, skills and global memory, nil for loaded projects
+	projectsMu sync.Mutex
+	projects   map[string]*Server // projects loaded from the projects path, by name
 }
 
 // New creates a new MCP server instance
@@ -62,32 +157,44 @@ func New(config Config) (*Server, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	// create base scanner
-	baseScanner := scanner.NewScanner(scanner.Params{
-		CommandsDir:    config.CommandsDir,
-		ProjectDocsDir: config.ProjectDocsDir,
-		ProjectRootDir: config.ProjectRootDir,
-		MaxFileSize:    config.MaxFileSize,
-		ExcludeDirs:    config.ExcludeDirs,
-	})
-
-	// wrap with caching (always enabled)
-	sc, err := scanner.NewCachedScanner(baseScanner, config.CacheTTL)
+	shared, err := scanner.NewCachedScanner(scanner.NewScanner(config.sharedScannerParams()), config.CacheTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cached scanner: %w", err)
 	}
-	slog.Info("file list caching enabled", "ttl", config.CacheTTL)
+	shared.WithContentCache(config.ContentCacheSize)
+	slog.Info("file list caching enabled", "ttl", config.CacheTTL, "content_cache_size", config.ContentCacheSize)
+
+	server, err := newProject(config, shared)
+	if err != nil {
+		_ = shared.Close()
+		return nil, err
+	}
+	server.shared = shared
 
 	// create MCP server
-	mcpServer := mcp.NewServer(&mcp.Implementation{
+	server.mcp = mcp.NewServer(&mcp.Implementation{
 		Name:    config.ServerName,
 		Version: config.Version,
 	}, nil)
 
-	server := &Server{
-		config:  config,
-		scanner: sc,
-		mcp:     mcpServer,
+	if config.MetricsListen != "" {
+		server.metrics = newServerMetrics(server.cacheStats)
+	}
+
+	if config.AuditLog != "" {
+		if server.audit, err = audit.NewLogger(config.AuditLog, config.AuditMaxSize, config.AuditBackups); err != nil {
+			_ = server.Close()
+			return nil, err // nolint:wrapcheck // audit error is descriptive
+		}
+		slog.Info("audit log enabled", "path", config.AuditLog)
+	}
+
+	if config.GapsFile != "" {
+		if server.gaps, err = gaps.Open(config.GapsFile, 0); err != nil {
+			_ = server.Close()
+			return nil, err // nolint:wrapcheck // gaps error is descriptive
+		}
+		slog.Info("documentation gaps recording enabled", "path", config.GapsFile, "threshold", config.GapsThreshold)
 	}
 
 	// register tools
@@ -96,16 +203,34 @@ func New(config Config) (*Server, error) {
 	return server, nil
 }
 
+// newProject creates server of a single project with its own cached scanner of project sources, using shared
+// sources of the given scanner, without MCP server and tools. it serves the default project, and projects loaded
+// from the projects path for tool calls with project set.
+func newProject(config Config, shared *scanner.CachedScanner) (*Server, error) {
+	// create base scanner
+	baseScanner := scanner.NewScanner(config.projectScannerParams())
+
+	// wrap with caching (always enabled)
+	sc, err := scanner.NewCachedScanner(baseScanner, config.CacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cached scanner: %w", err)
+	}
+	sc.WithShared(shared)
+
+	return &Server{config: config, scanner: sc}, nil
+}
+
 const (
-	// fuzzyThreshold is minimum score for fuzzy matching
-	fuzzyThreshold = 0.3
 	// maxSearchResults is maximum number of results to return
 	maxSearchResults = 10
 )
 
 // SearchInput represents input for searching documentation
 type SearchInput struct {
-	Query string `json:"query"`
+	Query   string `json:"query"`
+	Project string `json:"project,omitempty"`
+	Explain bool   `json:"explain,omitempty"` // add score breakdown to each result
+	Profile string `json:"profile,omitempty"` // ranking profile, the default profile if empty
 }
 
 // SearchMatch represents a single search result
@@ -114,6 +239,10 @@ type SearchMatch struct {
 	Name   string  `json:"name"`
 	Score  float64 `json:"score"`
 	Source string  `json:"source"`
+	// Terms lists terms of a multi-term query matched by the doc and where they matched
+	Terms []TermMatch `json:"terms,omitempty"`
+	// Explain is a breakdown of the score, set if requested
+	Explain *ScoreExplanation `json:"explain,omitempty"`
 }
 
 // SearchOutput contains search results
@@ -124,27 +253,54 @@ type SearchOutput struct {
 
 // ReadInput represents input for reading a documentation file
 type ReadInput struct {
-	Path   string  `json:"path"`
-	Source *string `json:"source,omitempty"`
+	Path     string  `json:"path"`
+	Source   *string `json:"source,omitempty"`
+	Revision *string `json:"revision,omitempty"`
+	// ExpandIncludes replaces include directives with contents of included docs
+	ExpandIncludes bool   `json:"expand_includes,omitempty"`
+	Project        string `json:"project,omitempty"`
+	// Strict rejects path without source prefix found in several sources, listing the candidates
+	Strict bool `json:"strict,omitempty"`
+	// IfNoneMatch is the hash of a previous read, content is not returned if the doc has the same hash
+	IfNoneMatch string `json:"if_none_match,omitempty"`
 }
 
 // ReadOutput contains the result of reading a documentation file
 type ReadOutput struct {
-	Path    string `json:"path"`
-	Content string `json:"content"`
-	Size    int    `json:"size"`
-	Source  string `json:"source"`
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	Size     int    `json:"size"`
+	Source   string `json:"source"`
+	Revision string `json:"revision,omitempty"` // commit hash, set if read at a revision
+	// Includes lists docs expanded into content, UnresolvedIncludes directives left as is with the reason
+	Includes           []string `json:"includes,omitempty"`
+	UnresolvedIncludes []string `json:"unresolved_includes,omitempty"`
+	// Redactions counts secrets masked in content, by rule
+	Redactions map[string]int `json:"redactions,omitempty"`
+	// ResolvedFrom is the requested path, set if it had no exact match and the closest doc was read
+	ResolvedFrom string `json:"resolved_from,omitempty"`
+	// Hash is sha256 of the doc file, or of content with expanded includes
+	Hash    string `json:"hash,omitempty"`
+	ModTime string `json:"mod_time,omitempty"` // RFC3339, not set for docs read from git
+	// NotModified is set if the doc has the hash passed in if_none_match, content is not returned then
+	NotModified bool `json:"not_modified,omitempty"`
 }
 
 // DocInfo represents information about a documentation file
 type DocInfo struct {
-	Name        string   `json:"name"`
-	Filename    string   `json:"filename"`
-	Source      string   `json:"source"`
-	Size        int64    `json:"size,omitempty"`
-	TooLarge    bool     `json:"too_large,omitempty"`
-	Description string   `json:"description,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
+	Name        string       `json:"name"`
+	Filename    string       `json:"filename"`
+	Source      string       `json:"source"`
+	Size        int64        `json:"size,omitempty"`
+	TooLarge    bool         `json:"too_large,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Priority    int          `json:"priority,omitempty"`
+	Audience    []string     `json:"audience,omitempty"`
+	Resources   []string     `json:"resources,omitempty"` // files bundled with a skill
+	Command     *CommandInfo `json:"command,omitempty"`   // slash command metadata, commands source only
+	// ShadowedBy is the doc read instead of this one for the path without source prefix
+	ShadowedBy string `json:"shadowed_by,omitempty"`
 }
 
 // ListOutput contains the result of listing all documentation files
@@ -153,8 +309,23 @@ type ListOutput struct {
 	Total int       `json:"total"`
 }
 
+// searchOptions are per-call options of searchDocs
+type searchOptions struct {
+	explain bool   // add score breakdown to results
+	profile string // ranking profile name, the default profile if empty
+}
+
+// Search searches docs of the project as search_docs tool does, without recording gaps
+func (s *Server) Search(ctx context.Context, input SearchInput) (*SearchOutput, error) {
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, err
+	}
+	return ps.searchDocs(ctx, input.Query, searchOptions{explain: input.Explain, profile: input.Profile})
+}
+
 // searchDocs searches for documentation files matching the query
-func (s *Server) searchDocs(ctx context.Context, query string) (*SearchOutput, error) {
+func (s *Server) searchDocs(ctx context.Context, query string, opts searchOptions) (*SearchOutput, error) {
 	if query == "" {
 		return &SearchOutput{
 			Results: []SearchMatch{},
@@ -162,6 +333,15 @@ func (s *Server) searchDocs(ctx context.Context, query string) (*SearchOutput, e
 		}, nil
 	}
 
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ranking.Select(s.config.RankingProfiles, opts.profile)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // ranking error is descriptive
+	}
+
 	// get all files
 	files, err := s.scanner.Scan(ctx)
 	if err != nil {
@@ -171,8 +351,13 @@ func (s *Server) searchDocs(ctx context.Context, query string) (*SearchOutput, e
 	// normalize query for filename matching (lowercase, replace spaces with hyphens)
 	normalizedQuery := strings.ToLower(query)
 	filenameQuery := strings.ReplaceAll(normalizedQuery, " ", "-")
+	terms := queryTerms(query)
 
 	var matches []SearchMatch
+	var explained map[string]scanner.FileInfo // matched files by path, kept to explain scores of returned results
+	if opts.explain {
+		explained = map[string]scanner.FileInfo{}
+	}
 
 	// score each file
 	for _, f := range files {
@@ -183,14 +368,31 @@ func (s *Server) searchDocs(ctx context.Context, query string) (*SearchOutput, e
 		default:
 		}
 
-		score := s.calculateScore(filenameQuery, normalizedQuery, f)
+		// plain queries are matched as a whole and, if they have several terms, term by term.
+		// queries with syntax are matched term by term.
+		var score float64
+		var matched []TermMatch
+		if parsed.Plain() {
+			score = s.calculateScore(profile, filenameQuery, normalizedQuery, f)
+			if len(terms) > 1 {
+				var termsScore float64
+				termsScore, matched = s.scoreTerms(profile, terms, f)
+				score = max(score, termsScore)
+			}
+		} else {
+			score = s.scoreQuery(profile, parsed, f)
+		}
 		if score > 0 {
 			matches = append(matches, SearchMatch{
 				Path:   f.Filename,
 				Name:   f.Name,
 				Score:  score,
 				Source: string(f.Source),
+				Terms:  matched,
 			})
+			if explained != nil {
+				explained[f.Filename] = f
+			}
 		}
 	}
 
@@ -204,6 +406,11 @@ func (s *Server) searchDocs(ctx context.Context, query string) (*SearchOutput, e
 	if len(matches) > maxSearchResults {
 		matches = matches[:maxSearchResults]
 	}
+	if explained != nil {
+		for i := range matches {
+			matches[i].Explain = s.explainScore(profile, parsed, terms, filenameQuery, normalizedQuery, explained[matches[i].Path])
+		}
+	}
 
 	return &SearchOutput{
 		Results: matches,
@@ -211,68 +418,68 @@ func (s *Server) searchDocs(ctx context.Context, query string) (*SearchOutput, e
 	}, nil
 }
 
-// calculateScore computes match score for a file
+// calculateScore computes match score for a file with weights of the ranking profile
 // filenameQuery is normalized with hyphens for filename matching
 // frontmatterQuery preserves spaces for frontmatter matching
-func (s *Server) calculateScore(filenameQuery, frontmatterQuery string, file scanner.FileInfo) float64 {
-	var score float64
+func (s *Server) calculateScore(p ranking.Profile, filenameQuery, frontmatterQuery string, file scanner.FileInfo) float64 {
+	return s.scoreName(p, filenameQuery, frontmatterQuery, file).Score
+}
 
+// scoreName computes match score for a file as calculateScore does and returns it with the breakdown
+func (s *Server) scoreName(p ranking.Profile, filenameQuery, frontmatterQuery string, file scanner.FileInfo) NameScore {
+	res := NameScore{Query: frontmatterQuery, Match: nameMatchNone}
 	normalizedName := file.Normalized
 
-	// check for exact match
-	if normalizedName == filenameQuery || normalizedName == filenameQuery+".md" {
-		return s.applyFrontmatterBoost(1.0, frontmatterQuery, file)
-	}
-
-	// check for substring match
-	if strings.Contains(normalizedName, filenameQuery) {
-		score = 0.8 * (float64(len(filenameQuery)) / float64(len(normalizedName)))
-		return s.applyFrontmatterBoost(score, frontmatterQuery, file)
-	}
-
-	// try fuzzy match on filename
-	matches := fuzzy.Find(filenameQuery, []string{normalizedName})
-	if len(matches) > 0 && matches[0].Score > 0 {
-		fuzzyScore := float64(matches[0].Score) / 100.0
-		if fuzzyScore > 1.0 {
-			fuzzyScore = 1.0
-		}
-		if fuzzyScore >= fuzzyThreshold {
-			score = fuzzyScore * 0.7
+	switch {
+	case normalizedName == filenameQuery || normalizedName == filenameQuery+".md": // exact match
+		res.Match, res.Base = nameMatchExact, p.Exact
+	case strings.Contains(normalizedName, filenameQuery): // substring match
+		res.Match, res.Base = nameMatchSubstring, p.Substring*(float64(len(filenameQuery))/float64(len(normalizedName)))
+	default: // try fuzzy match on filename
+		matches := fuzzy.Find(filenameQuery, []string{normalizedName})
+		if len(matches) > 0 && matches[0].Score > 0 {
+			res.FuzzyRaw = matches[0].Score
+			fuzzyScore := min(float64(matches[0].Score)/100.0, 1.0)
+			if fuzzyScore >= p.FuzzyThreshold {
+				res.Match, res.Base = nameMatchFuzzy, fuzzyScore*p.Fuzzy
+			}
 		}
 	}
 
-	return s.applyFrontmatterBoost(score, frontmatterQuery, file)
+	s.frontmatterBoost(p, frontmatterQuery, file, &res)
+	res.Score = res.Base + res.Boost
+	return res
 }
 
-// applyFrontmatterBoost adds score boost based on frontmatter matches.
-// Maximum boost from frontmatter is capped at 1.0 to prevent files with extensive
-// metadata from completely dominating search results.
-func (s *Server) applyFrontmatterBoost(score float64, query string, file scanner.FileInfo) float64 {
+// frontmatterBoost sets frontmatter boost of the name score and its breakdown
+func (s *Server) frontmatterBoost(p ranking.Profile, query string, file scanner.FileInfo, ns *NameScore) {
 	var boost float64
 
 	// boost score based on frontmatter matches
 	normalizedDesc := strings.ToLower(file.Description)
 	if normalizedDesc != "" && strings.Contains(normalizedDesc, query) {
-		boost += 0.5 // boost for description match
+		ns.DescriptionBoost = p.Description // boost for description match
+		boost += ns.DescriptionBoost
 	}
 
 	// boost for tag matches
 	for _, tag := range file.Tags {
 		normalizedTag := strings.ToLower(tag)
 		if normalizedTag == query {
-			boost += 0.3 // exact tag match
+			ns.TagBoosts = append(ns.TagBoosts, TagBoost{Tag: tag, Match: nameMatchExact, Boost: p.TagExact}) // exact tag match
+			boost += p.TagExact
 		} else if strings.Contains(normalizedTag, query) {
-			boost += 0.15 // partial tag match
+			ns.TagBoosts = append(ns.TagBoosts, TagBoost{Tag: tag, Match: nameMatchSubstring, Boost: p.TagPartial}) // partial tag match
+			boost += p.TagPartial
 		}
 	}
 
-	// cap total frontmatter boost at 1.0
-	if boost > 1.0 {
-		boost = 1.0
+	// cap total frontmatter boost
+	ns.BoostCap = p.BoostCap
+	if boost > p.BoostCap {
+		boost, ns.Capped = p.BoostCap, true
 	}
-
-	return score + boost
+	ns.Boost = boost
 }
 
 // readDoc reads a specific documentation file
@@ -284,109 +491,167 @@ func (s *Server) readDoc(ctx context.Context, path string, source *string) (*Rea
 	default:
 	}
 
-	// parse source prefix from path if present
-	var sourceStr string
-	cleanPath := path
+	sourceStr, cleanPath := parseDocPath(path, source)
 
-	if strings.Contains(path, ":") {
-		parts := strings.SplitN(path, ":", 2)
-		sourceStr = parts[0]
-		cleanPath = parts[1]
-	} else if source != nil {
-		sourceStr = *source
+	// git sources are addressed as "name@ref"
+	if strings.Contains(sourceStr, "@") {
+		return s.readGitDoc(sourceStr, cleanPath)
 	}
 
-	// map source string to directory
-	var baseDir string
-	var actualSource scanner.Source
+	resolvedPath, actualSource, err := s.resolveDocFile(ctx, sourceStr, cleanPath)
+	if err != nil {
+		return nil, err
+	}
 
-	if sourceStr != "" {
-		switch sourceStr {
-		case "commands":
-			baseDir = s.scanner.CommandsDir()
-			actualSource = scanner.SourceCommands
-		case "project-docs":
-			baseDir = s.scanner.ProjectDocsDir()
-			actualSource = scanner.SourceProjectDocs
-		case "project-root":
-			baseDir = s.scanner.ProjectRootDir()
-			actualSource = scanner.SourceProjectRoot
-		default:
-			return nil, fmt.Errorf("invalid source: %s", sourceStr)
-		}
+	// check context before reading
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+	default:
+	}
 
-		// try to resolve and read from specified source
-		resolvedPath, err := scanner.SafeResolvePath(baseDir, cleanPath, s.config.MaxFileSize)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve path in %s: %w", sourceStr, err)
-		}
+	// content with frontmatter stripped, served from content cache if enabled
+	doc, err := s.scanner.Document(resolvedPath)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // scanner error is descriptive
+	}
 
-		// check context before reading
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
-		default:
+	res := &ReadOutput{
+		Path:    cleanPath,
+		Content: string(doc.Body),
+		Size:    len(doc.Body),
+		Source:  string(actualSource),
+		Hash:    doc.Version.Hash,
+		ModTime: doc.Version.ModTime.UTC().Format(time.RFC3339),
+	}
+	s.redactDoc(res)
+	return res, nil
+}
+
+// readDocInput reads the doc as read_doc does: checks ambiguous path if strict, reads it at revision, the closest
+// doc if there is no exact match, expands includes and returns not modified output if the hash matches if_none_match
+func (s *Server) readDocInput(ctx context.Context, input ReadInput) (result *ReadOutput, err error) {
+	if input.Strict || s.config.StrictPaths {
+		if err = s.checkAmbiguous(ctx, input.Path, input.Source); err != nil {
+			return nil, err
 		}
+	}
 
-		// #nosec G304 - path is validated by SafeResolvePath
-		content, err := os.ReadFile(resolvedPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
+	atRevision := input.Revision != nil && *input.Revision != ""
+	if input.IfNoneMatch != "" && !input.ExpandIncludes && !atRevision {
+		result = s.unchangedDoc(ctx, input.Path, input.Source, input.IfNoneMatch) // nil if changed
+	}
+	switch {
+	case result != nil:
+	case atRevision:
+		result, err = s.readDocAt(ctx, input.Path, input.Source, *input.Revision)
+	default:
+		result, err = s.readDocClosest(ctx, input.Path, input.Source)
+		if err == nil && input.ExpandIncludes {
+			err = s.expandIncludes(ctx, result)
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if input.IfNoneMatch != "" && result.Hash == input.IfNoneMatch {
+		result = notModified(result)
+	}
+	return result, nil
+}
 
-		// strip frontmatter from content
-		_, strippedContent := scanner.ParseFrontmatter(content)
+// parseDocPath splits source prefix from path if present, otherwise uses optional source parameter
+func parseDocPath(path string, source *string) (sourceStr, cleanPath string) {
+	if before, after, ok := strings.Cut(path, ":"); ok {
+		return before, after
+	}
+	if source != nil {
+		return *source, path
+	}
+	return "", path
+}
 
-		return &ReadOutput{
-			Path:    cleanPath,
-			Content: string(strippedContent),
-			Size:    len(strippedContent),
-			Source:  string(actualSource),
-		}, nil
+// sourceDir maps source name to its directory
+func (s *Server) sourceDir(sourceStr string) (string, scanner.Source, error) {
+	switch sourceStr {
+	case "commands":
+		return s.scanner.CommandsDir(), scanner.SourceCommands, nil
+	case "project-docs":
+		return s.scanner.ProjectDocsDir(), scanner.SourceProjectDocs, nil
+	case "project-root":
+		return s.scanner.ProjectRootDir(), scanner.SourceProjectRoot, nil
+	case "skills":
+		return s.scanner.SkillsDir(), scanner.SourceSkills, nil
+	default:
+		return "", "", fmt.Errorf("invalid source: %s", sourceStr)
+	}
+}
+
+// resolveDocFile resolves doc path to a file in the given directory source.
+// with empty source commands, project docs and project root are tried in order of source precedence,
+// skills, memory and git sources are reachable with explicit source only.
+func (s *Server) resolveDocFile(ctx context.Context, sourceStr, cleanPath string) (string, scanner.Source, error) {
+	if sourceStr == string(scanner.SourceMemory) {
+		// memory files come from several directories, scanner maps their names
+		resolvedPath, err := s.scanner.ResolveMemoryPath(cleanPath, s.config.MaxFileSize)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve path in %s: %w", sourceStr, err)
+		}
+		return resolvedPath, scanner.SourceMemory, nil
 	}
 
-	// no source specified, try all sources in order
-	sources := []struct {
-		name string
-		dir  string
-		src  scanner.Source
-	}{
-		{"commands", s.scanner.CommandsDir(), scanner.SourceCommands},
-		{"project-docs", s.scanner.ProjectDocsDir(), scanner.SourceProjectDocs},
-		{"project-root", s.scanner.ProjectRootDir(), scanner.SourceProjectRoot},
+	if sourceStr != "" {
+		baseDir, src, err := s.sourceDir(sourceStr)
+		if err != nil {
+			return "", "", err
+		}
+		resolvedPath, err := scanner.SafeResolvePath(baseDir, cleanPath, s.config.MaxFileSize)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve path in %s: %w", sourceStr, err)
+		}
+		return resolvedPath, src, nil
 	}
 
-	for _, src := range sources {
+	// no source specified, try sources in order of precedence
+	for _, src := range s.config.sourcePrecedence() {
 		// check context
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
+			return "", "", ctx.Err() // nolint:wrapcheck // context errors should be returned as-is
 		default:
 		}
 
-		resolvedPath, err := scanner.SafeResolvePath(src.dir, cleanPath, s.config.MaxFileSize)
-		if err != nil {
-			continue // try next source
-		}
-
-		// #nosec G304 - path is validated by SafeResolvePath
-		content, err := os.ReadFile(resolvedPath)
+		baseDir, _, _ := s.sourceDir(string(src))
+		resolvedPath, err := scanner.SafeResolvePath(baseDir, cleanPath, s.config.MaxFileSize)
 		if err != nil {
 			continue // try next source
 		}
+		return resolvedPath, src, nil
+	}
 
-		// strip frontmatter from content
-		_, strippedContent := scanner.ParseFrontmatter(content)
+	return "", "", fmt.Errorf("%w in any source: %s", scanner.ErrNotFound, cleanPath)
+}
 
-		return &ReadOutput{
-			Path:    cleanPath,
-			Content: string(strippedContent),
-			Size:    len(strippedContent),
-			Source:  string(src.src),
-		}, nil
+// readGitDoc reads a documentation file from the git source with "name@ref" prefix.
+// returned path includes the prefix, as the same path can exist in several refs.
+func (s *Server) readGitDoc(prefix, path string) (*ReadOutput, error) {
+	content, err := s.scanner.ReadGitFile(prefix, path, s.config.MaxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from %s: %w", prefix, err)
 	}
 
-	return nil, fmt.Errorf("file not found in any source: %s", cleanPath)
+	// strip frontmatter from content
+	_, strippedContent := scanner.ParseFrontmatter(content)
+
+	res := &ReadOutput{
+		Path:    prefix + ":" + path,
+		Content: string(strippedContent),
+		Size:    len(strippedContent),
+		Source:  string(scanner.SourceGit),
+		Hash:    scanner.HashContent(content),
+	}
+	s.redactDoc(res)
+	return res, nil
 }
 
 // listAllDocs returns a list of all available documentation files from all sources
@@ -396,6 +661,7 @@ func (s *Server) listAllDocs(ctx context.Context) (*ListOutput, error) {
 		return nil, err // nolint:wrapcheck // scanner error is descriptive
 	}
 
+	shadowed := s.shadowedDocs(files)
 	docs := make([]DocInfo, 0, len(files))
 	for _, f := range files {
 		// check context cancellation
@@ -412,6 +678,11 @@ func (s *Server) listAllDocs(ctx context.Context) (*ListOutput, error) {
 			Size:        f.Size,
 			Description: f.Description,
 			Tags:        f.Tags,
+			Priority:    f.Priority,
+			Audience:    f.Audience,
+			Resources:   f.Resources,
+			Command:     newCommandInfo(f.Command),
+			ShadowedBy:  shadowed[f.Filename],
 		}
 
 		// mark files that exceed max size
@@ -428,35 +699,103 @@ func (s *Server) listAllDocs(ctx context.Context) (*ListOutput, error) {
 	}, nil
 }
 
+// lintDocs validates frontmatter of all documentation files against the configured schema
+func (s *Server) lintDocs(ctx context.Context) (*lint.Report, error) {
+	files, err := s.scanner.Scan(ctx)
+	if err != nil {
+		return nil, err // nolint:wrapcheck // scanner error is descriptive
+	}
+	return lint.New(s.config.LintSchema, s.config.MaxFileSize).Lint(ctx, files) // nolint:wrapcheck // context errors should be returned as-is
+}
+
 // registerTools registers all MCP tools
 func (s *Server) registerTools() {
+	precedence := make([]string, 0, len(s.config.sourcePrecedence()))
+	for _, src := range s.config.sourcePrecedence() {
+		precedence = append(precedence, string(src))
+	}
+	precedenceText := strings.Join(precedence, ", ")
+
 	// register search_docs tool
-	mcp.AddTool(s.mcp, &mcp.Tool{
-		Name:        "search_docs",
-		Description: "Search for documentation files matching the query with fuzzy matching. Returns top 10 results sorted by relevance.",
+	addTool(s, &mcp.Tool{
+		Name: "search_docs",
+		Description: "Search for documentation files matching the query with fuzzy matching. Returns top 10 results sorted by relevance. " +
+			`Supports tag:, source:, path: and desc: qualifiers, "quoted phrases", AND, OR, -term exclusion and parentheses, ` +
+			`e.g. tag:testing -source:commands or (deploy OR release) path:ops/*. ` +
+			"Set explain to get a breakdown of each score, profile to rank with a named ranking profile.",
 	}, s.handleSearchDocs)
 
 	// register read_doc tool
-	mcp.AddTool(s.mcp, &mcp.Tool{
-		Name:        "read_doc",
-		Description: "Read a specific documentation file. Supports source prefixes (e.g., 'commands:action/commit.md') or tries all sources if not specified.",
+	addTool(s, &mcp.Tool{
+		Name: "read_doc",
+		Description: "Read a specific documentation file. Supports source prefixes (e.g., 'commands:action/commit.md', 'skills:pdf/SKILL.md', 'memory:CLAUDE.md', or 'name@ref:guide.md' for git sources) or tries " + precedenceText + " in order if not specified. Optional revision reads the file as it was at a git commit, branch or tag. " +
+			"Optional expand_includes replaces '<!-- include: path -->' and '{{< include \"path\" >}}' directives with included docs. " +
+			"Optional strict returns an error listing candidates if the path without prefix is found in several sources. " +
+			"A path without exact match, e.g. 'commit' or a misspelled directory, reads the single closest doc by basename, command name or spelling and sets resolved_from, " +
+			"several equally close docs are returned as suggestions in the error. " +
+			"Result has hash of the doc, pass it in if_none_match of the next read to get not_modified without content if the doc is unchanged.",
 	}, s.handleReadDoc)
 
+	// register read_docs tool
+	addTool(s, &mcp.Tool{
+		Name: "read_docs",
+		Description: fmt.Sprintf("Read up to %d documentation files in one call, e.g. the top results of a search. Each doc is a path with optional source, section, expand_includes and strict, read as read_doc does; "+
+			"section returns only the part of the doc under the heading with this text, if_none_match skips content of a doc with this hash. Docs are read concurrently and returned in order with per-doc errors, one failed doc does not fail the call. "+
+			"Content is limited to %d bytes in total, or max_bytes if lower: the doc crossing the limit is truncated and docs after it are not returned.", maxReadDocs, readDocsBudget),
+	}, s.handleReadDocs)
+
 	// register list_all_docs tool
-	mcp.AddTool(s.mcp, &mcp.Tool{
-		Name:        "list_all_docs",
-		Description: "List all available documentation files from all sources (commands, project-docs, project-root).",
+	addTool(s, &mcp.Tool{
+		Name: "list_all_docs",
+		Description: "List all available documentation files from all sources (commands, project-docs, project-root, skills, memory, git). Skills include their bundled resource files. " +
+			"Docs shadowed by a doc with the same path in a source tried first by read_doc have shadowed_by set to the doc read instead.",
 	}, s.handleListAllDocs)
+
+	// register lint_docs tool
+	addTool(s, &mcp.Tool{
+		Name:        "lint_docs",
+		Description: "Validate documentation files: malformed or unclosed frontmatter, frontmatter beyond the indexed header, unknown keys, missing required fields, duplicate paths across sources and oversize files.",
+	}, s.handleLintDocs)
+
+	// register extract tool
+	addTool(s, &mcp.Tool{
+		Name: "extract",
+		Description: "Extract structured elements from a documentation file instead of reading it whole. " +
+			"Kind is one of: code (fenced code blocks with language, optionally filtered by language), " +
+			"table (header and rows), checklist (task items with checked state) or links. " +
+			"Each element has the nearest section heading and line number.",
+	}, s.handleExtract)
+
+	// register list_commands tool
+	s.registerCommandTools()
+
+	// register list_plans, plan_status and complete_task tools
+	s.registerPlanTools()
+
+	// register doc_history, read_doc_at and doc_diff tools
+	s.registerHistoryTools()
+
+	// register doc_gaps tool
+	s.registerGapsTools()
+
+	// register list_projects tool
+	s.registerProjectTools()
 }
 
 // handleSearchDocs handles search_docs tool calls
 func (s *Server) handleSearchDocs(ctx context.Context, _ *mcp.CallToolRequest, input SearchInput) (*mcp.CallToolResult, any, error) {
-	slog.Debug("search_docs called", "query", input.Query)
+	slog.Debug("search_docs called", "query", input.Query, "project", input.Project)
 
-	result, err := s.searchDocs(ctx, input.Query)
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.searchDocs(ctx, input.Query, searchOptions{explain: input.Explain, profile: input.Profile})
 	if err != nil {
 		return nil, nil, fmt.Errorf("search failed: %w", err)
 	}
+	ps.recordGap(ctx, input.Query, result)
 
 	// convert to JSON for response
 	content, err := json.Marshal(result)
@@ -475,9 +814,14 @@ func (s *Server) handleSearchDocs(ctx context.Context, _ *mcp.CallToolRequest, i
 
 // handleReadDoc handles read_doc tool calls
 func (s *Server) handleReadDoc(ctx context.Context, _ *mcp.CallToolRequest, input ReadInput) (*mcp.CallToolResult, any, error) {
-	slog.Debug("read_doc called", "path", input.Path, "source", input.Source)
+	slog.Debug("read_doc called", "path", input.Path, "source", input.Source, "revision", input.Revision, "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	result, err := s.readDoc(ctx, input.Path, input.Source)
+	result, err := ps.readDocInput(ctx, input)
 	if err != nil {
 		return nil, nil, fmt.Errorf("read failed: %w", err)
 	}
@@ -497,12 +841,16 @@ func (s *Server) handleReadDoc(ctx context.Context, _ *mcp.CallToolRequest, inpu
 	}, result, nil
 }
 
-// handleListAllDocs handles list_all_docs tool calls.
-// input is required by MCP SDK signature but list_all_docs takes no parameters.
-func (s *Server) handleListAllDocs(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
-	slog.Debug("list_all_docs called")
+// handleListAllDocs handles list_all_docs tool calls
+func (s *Server) handleListAllDocs(ctx context.Context, _ *mcp.CallToolRequest, input ProjectInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("list_all_docs called", "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	result, err := s.listAllDocs(ctx)
+	result, err := ps.listAllDocs(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("list failed: %w", err)
 	}
@@ -522,6 +870,35 @@ func (s *Server) handleListAllDocs(ctx context.Context, _ *mcp.CallToolRequest,
 	}, result, nil
 }
 
+// handleLintDocs handles lint_docs tool calls
+func (s *Server) handleLintDocs(ctx context.Context, _ *mcp.CallToolRequest, input ProjectInput) (*mcp.CallToolResult, any, error) {
+	slog.Debug("lint_docs called", "project", input.Project)
+
+	ps, err := s.forProject(input.Project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := ps.lintDocs(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lint failed: %w", err)
+	}
+
+	// convert to JSON for response
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(content),
+			},
+		},
+	}, result, nil
+}
+
 // Run starts the MCP server with stdio transport
 func (s *Server) Run(ctx context.Context) error {
 	slog.Info("starting MCP server", "name", s.config.ServerName, "version", s.config.Version)
@@ -530,14 +907,30 @@ func (s *Server) Run(ctx context.Context) error {
 	// ensure cleanup on exit
 	defer s.Close()
 
+	if s.metrics != nil {
+		if err := s.startMetricsServer(ctx); err != nil {
+			return err
+		}
+	}
+
 	// run server with stdio transport
 	return s.mcp.Run(ctx, &mcp.StdioTransport{}) // nolint:wrapcheck // MCP SDK error is descriptive
 }
 
 // Close cleans up server resources
 func (s *Server) Close() error {
+	errs := []error{s.closeRepos(), s.closeProjects()}
+	if s.audit != nil {
+		errs = append(errs, s.audit.Close())
+	}
 	if s.scanner != nil {
-		return s.scanner.Close() // nolint:wrapcheck // scanner error is descriptive
+		errs = append(errs, s.scanner.Close())
+	}
+	if s.shared != nil {
+		errs = append(errs, s.shared.Close())
+	}
+	if s.gaps != nil && s.shared != nil { // gaps store is shared by projects, closed by the root server only
+		errs = append(errs, s.gaps.Close())
 	}
-	return nil
+	return errors.Join(errs...)
 }
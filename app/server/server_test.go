@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -14,9 +15,51 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/umputun/local-docs-mcp/app/lint"
+	"github.com/umputun/local-docs-mcp/app/ranking"
 	"github.com/umputun/local-docs-mcp/app/scanner"
 )
 
+// newTestServer writes files, keyed by path relative to a temp dir, and makes server with commands and project
+// docs in "commands" and "docs" dirs of it. max file size and server name of cfg default to 1024 and "test-server".
+// the server is closed on cleanup, returned with the temp dir.
+func newTestServer(t *testing.T, files map[string]string, cfg Config) (srv *Server, dir string) {
+	t.Helper()
+	dir = t.TempDir()
+	cfg.CommandsDir, cfg.ProjectDocsDir = filepath.Join(dir, "commands"), filepath.Join(dir, "docs")
+	require.NoError(t, os.MkdirAll(cfg.CommandsDir, 0755))
+	require.NoError(t, os.MkdirAll(cfg.ProjectDocsDir, 0755))
+	for p, content := range files {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+	if cfg.MaxFileSize == 0 {
+		cfg.MaxFileSize = 1024
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = "test-server"
+	}
+
+	srv, err := New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = srv.Close() })
+	return srv, dir
+}
+
+// newTestSession connects in-memory client to the server, the session is closed on cleanup
+func newTestSession(t *testing.T, srv *Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	ct, st := mcp.NewInMemoryTransports()
+	_, err := srv.mcp.Connect(ctx, st, nil)
+	require.NoError(t, err)
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, ct, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = session.Close() })
+	return session
+}
+
 func TestNew(t *testing.T) {
 	tmpDir := t.TempDir()
 	commandsDir := filepath.Join(tmpDir, "commands")
@@ -315,7 +358,7 @@ func TestServerListAllDocsHandler(t *testing.T) {
 	// call the handler directly
 	ctx := context.Background()
 	req := &mcp.CallToolRequest{}
-	input := struct{}{}
+	input := ProjectInput{}
 
 	result, output, err := srv.handleListAllDocs(ctx, req, input)
 	require.NoError(t, err)
@@ -388,6 +431,19 @@ func TestServerConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max file size must be greater than zero",
 		},
+		{
+			name: "duplicate git source",
+			config: Config{
+				MaxFileSize: 1024,
+				ServerName:  "test",
+				GitSources: []scanner.GitSource{
+					{Name: "app", Repo: "/repos/app", Ref: "main"},
+					{Name: "app", Repo: "/repos/other", Ref: "main"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "duplicate git source app@main",
+		},
 	}
 
 	for _, tt := range tests {
@@ -465,7 +521,7 @@ func TestServer_SearchDocs_ScoreSorting(t *testing.T) {
 	srv, err := New(config)
 	require.NoError(t, err)
 
-	result, err := srv.searchDocs(context.Background(), "test")
+	result, err := srv.searchDocs(context.Background(), "test", searchOptions{})
 	require.NoError(t, err)
 	require.NotEmpty(t, result.Results)
 
@@ -505,7 +561,7 @@ func TestServer_SearchDocs_LimitResults(t *testing.T) {
 	srv, err := New(config)
 	require.NoError(t, err)
 
-	result, err := srv.searchDocs(context.Background(), "test")
+	result, err := srv.searchDocs(context.Background(), "test", searchOptions{})
 	require.NoError(t, err)
 
 	// should return max 10 results but total should be 15
@@ -530,7 +586,7 @@ func TestServer_SearchDocs_EmptyQuery(t *testing.T) {
 	srv, err := New(config)
 	require.NoError(t, err)
 
-	result, err := srv.searchDocs(context.Background(), "")
+	result, err := srv.searchDocs(context.Background(), "", searchOptions{})
 	require.NoError(t, err)
 	assert.Empty(t, result.Results)
 	assert.Equal(t, 0, result.Total)
@@ -557,7 +613,7 @@ func TestServer_SearchDocs_NormalizedMatching(t *testing.T) {
 	require.NoError(t, err)
 
 	// search with spaces should match file with hyphens
-	result, err := srv.searchDocs(context.Background(), "git commit")
+	result, err := srv.searchDocs(context.Background(), "git commit", searchOptions{})
 	require.NoError(t, err)
 	require.NotEmpty(t, result.Results)
 	assert.Contains(t, result.Results[0].Name, "git-commit")
@@ -583,7 +639,7 @@ func TestServer_SearchDocs_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // cancel immediately
 
-	_, err = srv.searchDocs(ctx, "test")
+	_, err = srv.searchDocs(ctx, "test", searchOptions{})
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, context.Canceled)
 }
@@ -709,6 +765,73 @@ func TestServer_ReadDoc_InvalidSource(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid source")
 }
 
+func TestServer_ReadDoc_GitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary is not available")
+	}
+
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "docs"), 0755))
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_CONFIG_NOSYSTEM=1", "HOME="+tmpDir)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	gitCmd("init", "-q", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "docs", "guide.md"),
+		[]byte("---\ndescription: release guide\n---\n# Release Guide\n"), 0600))
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "docs")
+	gitCmd("branch", "release")
+	// working tree changes are not visible through the git source
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "docs", "guide.md"), []byte("# Uncommitted\n"), 0600))
+
+	config := Config{
+		CommandsDir:    filepath.Join(tmpDir, "commands"),
+		ProjectDocsDir: filepath.Join(tmpDir, "docs"),
+		MaxFileSize:    1024 * 1024,
+		ServerName:     "test-server",
+		Version:        "1.0.0",
+		GitSources:     []scanner.GitSource{{Name: "app", Repo: repoDir, Ref: "release", Subdir: "docs"}},
+	}
+	srv, err := New(config)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	list, err := srv.listAllDocs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list.Docs, 1)
+	assert.Equal(t, "app@release:guide.md", list.Docs[0].Filename)
+	assert.Equal(t, "git", list.Docs[0].Source)
+	assert.Equal(t, "release guide", list.Docs[0].Description)
+
+	result, err := srv.readDoc(context.Background(), "app@release:guide.md", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "# Release Guide\n", result.Content)
+	assert.Equal(t, "app@release:guide.md", result.Path)
+	assert.Equal(t, "git", result.Source)
+
+	source := "app@release"
+	result, err = srv.readDoc(context.Background(), "guide", &source)
+	require.NoError(t, err)
+	assert.Equal(t, "# Release Guide\n", result.Content)
+
+	_, err = srv.readDoc(context.Background(), "app@release:missing.md", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file not found")
+
+	_, err = srv.readDoc(context.Background(), "app@main:guide.md", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown git source")
+
+	_, err = srv.readDoc(context.Background(), "guide.md", nil)
+	require.Error(t, err, "git sources are not searched without prefix")
+}
+
 func TestServer_ListAllDocs_TooLargeFiles(t *testing.T) {
 	tmpDir := t.TempDir()
 	commandsDir := filepath.Join(tmpDir, "commands")
@@ -956,7 +1079,7 @@ func TestServer_CalculateScore(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// for these tests, query has no spaces, so both params are the same
-			score := srv.calculateScore(tt.query, tt.query, tt.file)
+			score := srv.calculateScore(ranking.Default(), tt.query, tt.query, tt.file)
 
 			if tt.checkFunc != nil {
 				tt.checkFunc(t, score)
@@ -1010,7 +1133,7 @@ func TestServer_CalculateScore_FuzzyThreshold(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// for these tests, query has no spaces, so both params are the same
-			score := srv.calculateScore(tt.query, tt.query, tt.file)
+			score := srv.calculateScore(ranking.Default(), tt.query, tt.query, tt.file)
 			assert.Equal(t, 0.0, score, "poor fuzzy matches should be below threshold")
 		})
 	}
@@ -1049,7 +1172,7 @@ func TestServer_CalculateScore_FuzzyMatching(t *testing.T) {
 
 	for _, tt := range substringCaught {
 		// for these tests, query has no spaces, so both params are the same
-		score := srv.calculateScore(tt.query, tt.query, tt.file)
+		score := srv.calculateScore(ranking.Default(), tt.query, tt.query, tt.file)
 		// these all match via substring, not fuzzy
 		assert.Greater(t, score, 0.0, "should match via substring")
 		assert.LessOrEqual(t, score, 0.8, "substring matches score <= 0.8")
@@ -1068,7 +1191,7 @@ func TestServer_CalculateScore_FuzzyMatching(t *testing.T) {
 
 	for _, tt := range fuzzyMatches {
 		// for these tests, query has no spaces, so both params are the same
-		score := srv.calculateScore(tt.query, tt.query, tt.file)
+		score := srv.calculateScore(ranking.Default(), tt.query, tt.query, tt.file)
 		assert.Greater(t, score, 0.0, "fuzzy match should score > 0")
 		assert.Less(t, score, 0.8, "fuzzy matches score < substring matches")
 	}
@@ -1086,113 +1209,99 @@ func TestServer_CalculateScore_FuzzyMatching(t *testing.T) {
 
 	for _, tt := range noFuzzyMatch {
 		// for these tests, query has no spaces, so both params are the same
-		score := srv.calculateScore(tt.query, tt.query, tt.file)
+		score := srv.calculateScore(ranking.Default(), tt.query, tt.query, tt.file)
 		assert.Equal(t, 0.0, score, "should not match: %s", tt.reason)
 	}
 }
 
-func TestServer_ApplyFrontmatterBoost(t *testing.T) {
-	tmpDir := t.TempDir()
-	commandsDir := filepath.Join(tmpDir, "commands")
-	require.NoError(t, os.MkdirAll(commandsDir, 0755))
-
-	config := Config{
-		CommandsDir:    commandsDir,
-		ProjectDocsDir: tmpDir,
-		ProjectRootDir: "",
-		MaxFileSize:    1024 * 1024,
-		ServerName:     "test-server",
-		Version:        "1.0.0",
-	}
-
-	srv, err := New(config)
-	require.NoError(t, err)
+func TestServer_FrontmatterBoost(t *testing.T) {
+	srv := &Server{}
 
 	tests := []struct {
-		name      string
-		baseScore float64
-		query     string
-		file      scanner.FileInfo
-		wantScore float64
+		name       string
+		query      string
+		file       scanner.FileInfo
+		wantBoost  float64
+		wantCapped bool
 	}{
 		{
-			name:      "description match boosts score",
-			baseScore: 0.5,
+			name:      "description match",
 			query:     "testing",
 			file:      scanner.FileInfo{Description: "This is for testing purposes", Tags: nil},
-			wantScore: 1.0, // 0.5 + 0.5
+			wantBoost: 0.5, // description
 		},
 		{
-			name:      "exact tag match boosts score",
-			baseScore: 0.5,
+			name:      "exact tag match",
 			query:     "golang",
 			file:      scanner.FileInfo{Description: "", Tags: []string{"golang", "tutorial"}},
-			wantScore: 0.8, // 0.5 + 0.3
+			wantBoost: 0.3, // exact tag
 		},
 		{
-			name:      "partial tag match boosts score",
-			baseScore: 0.5,
+			name:      "partial tag match",
 			query:     "go",
 			file:      scanner.FileInfo{Description: "", Tags: []string{"golang"}},
-			wantScore: 0.65, // 0.5 + 0.15
+			wantBoost: 0.15, // partial tag
 		},
 		{
 			name:      "multiple tag matches - exact and partial",
-			baseScore: 0.5,
 			query:     "test",
 			file:      scanner.FileInfo{Description: "", Tags: []string{"test", "testing"}},
-			wantScore: 0.95, // 0.5 + 0.3 (exact) + 0.15 (partial), note: might have float precision
+			wantBoost: 0.45, // 0.3 (exact) + 0.15 (partial)
 		},
 		{
 			name:      "description and tag match combined",
-			baseScore: 0.3,
 			query:     "api",
 			file:      scanner.FileInfo{Description: "API documentation guide", Tags: []string{"api", "rest"}},
-			wantScore: 1.1, // 0.3 + 0.5 (desc) + 0.3 (tag)
+			wantBoost: 0.8, // 0.5 (desc) + 0.3 (tag)
 		},
 		{
 			name:      "case insensitive description match",
-			baseScore: 0.4,
 			query:     "testing",
 			file:      scanner.FileInfo{Description: "TESTING AND DEVELOPMENT", Tags: nil},
-			wantScore: 0.9, // 0.4 + 0.5
+			wantBoost: 0.5, // description
 		},
 		{
 			name:      "case insensitive tag match",
-			baseScore: 0.4,
 			query:     "golang",
 			file:      scanner.FileInfo{Description: "", Tags: []string{"GoLang"}},
-			wantScore: 0.7, // 0.4 + 0.3
+			wantBoost: 0.3, // exact tag
 		},
 		{
 			name:      "no boost - no matches",
-			baseScore: 0.6,
 			query:     "test",
 			file:      scanner.FileInfo{Description: "something else", Tags: []string{"other"}},
-			wantScore: 0.6, // no boost
+			wantBoost: 0, // no boost
 		},
 		{
 			name:      "no boost - empty frontmatter",
-			baseScore: 0.7,
 			query:     "test",
 			file:      scanner.FileInfo{Description: "", Tags: nil},
-			wantScore: 0.7, // no boost
+			wantBoost: 0, // no boost
 		},
 		{
-			name:      "boost capped at 1.0 - excessive metadata",
-			baseScore: 0.5,
-			query:     "test",
-			file:      scanner.FileInfo{Description: "test description", Tags: []string{"test", "testing", "tester", "tested"}},
-			wantScore: 1.5, // 0.5 + 1.0 (capped: would be 0.5 + 0.3 + 0.15*3 = 1.25 without cap)
+			name:       "boost capped - excessive metadata",
+			query:      "test",
+			file:       scanner.FileInfo{Description: "test description", Tags: []string{"test", "testing", "tester", "tested"}},
+			wantBoost:  1, // capped, 0.5 + 0.3 + 0.15*3 = 1.25 without cap
+			wantCapped: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			score := srv.applyFrontmatterBoost(tt.baseScore, tt.query, tt.file)
-			assert.InDelta(t, tt.wantScore, score, 0.0001, "score should match within precision")
+			var ns NameScore
+			srv.frontmatterBoost(ranking.Default(), tt.query, tt.file, &ns)
+			assert.InDelta(t, tt.wantBoost, ns.Boost, 0.0001, "boost should match within precision")
+			assert.Equal(t, tt.wantCapped, ns.Capped)
+			assert.InDelta(t, ranking.Default().BoostCap, ns.BoostCap, 0.0001)
 		})
 	}
+
+	// name score adds the boost to the name match
+	ns := srv.scoreName(ranking.Default(), "api", "api", scanner.FileInfo{Normalized: "api.md",
+		Description: "API documentation guide", Tags: []string{"api", "rest"}})
+	assert.Equal(t, nameMatchExact, ns.Match)
+	assert.InDelta(t, 1.0+0.5+0.3, ns.Score, 0.0001, "exact name + description + exact tag")
 }
 
 func TestServer_CalculateScore_MultiWordQuery(t *testing.T) {
@@ -1286,7 +1395,7 @@ func TestServer_CalculateScore_MultiWordQuery(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			score := srv.calculateScore(tt.filenameQuery, tt.frontmatterQuery, tt.file)
+			score := srv.calculateScore(ranking.Default(), tt.filenameQuery, tt.frontmatterQuery, tt.file)
 			assert.GreaterOrEqual(t, score, tt.minScore,
 				"score should be at least %f (got %f)", tt.minScore, score)
 
@@ -1438,6 +1547,33 @@ tags: [testing, development, cli]
 	assert.Empty(t, docWithoutFM.Tags)
 }
 
+func TestServer_ListAllDocs_InheritedMeta(t *testing.T) {
+	tmpDir := t.TempDir()
+	runbooksDir := filepath.Join(tmpDir, "docs", "runbooks")
+	require.NoError(t, os.MkdirAll(runbooksDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooksDir, "_meta.yml"),
+		[]byte("tags: [ops, oncall]\npriority: 2\naudience: [sre]\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooksDir, "restart.md"),
+		[]byte("---\ndescription: Restart service\ntags: [systemd]\n---\n# Restart"), 0600))
+
+	srv, err := New(Config{
+		ProjectDocsDir: filepath.Join(tmpDir, "docs"),
+		MaxFileSize:    1024 * 1024,
+		ServerName:     "test-server",
+		Version:        "1.0.0",
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	result, err := srv.listAllDocs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Docs, 1)
+	assert.Equal(t, "Restart service", result.Docs[0].Description)
+	assert.Equal(t, []string{"ops", "oncall", "systemd"}, result.Docs[0].Tags)
+	assert.Equal(t, 2, result.Docs[0].Priority)
+	assert.Equal(t, []string{"sre"}, result.Docs[0].Audience)
+}
+
 func TestServer_SearchDocs_FrontmatterBoostingEndToEnd(t *testing.T) {
 	tmpDir := t.TempDir()
 	commandsDir := filepath.Join(tmpDir, "commands")
@@ -1471,7 +1607,7 @@ tags: [golang, programming]
 	require.NoError(t, err)
 
 	// search for "golang" - should rank golang-guide.md higher due to frontmatter boost
-	result, err := srv.searchDocs(context.Background(), "golang")
+	result, err := srv.searchDocs(context.Background(), "golang", searchOptions{})
 	require.NoError(t, err)
 	require.NotEmpty(t, result.Results, "should have search results")
 
@@ -1479,3 +1615,163 @@ tags: [golang, programming]
 	assert.Equal(t, "golang-guide.md", result.Results[0].Name, "file with frontmatter match should rank highest")
 	assert.Greater(t, result.Results[0].Score, 1.0, "score should include frontmatter boost")
 }
+
+func TestServer_LintDocsHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	commandsDir := filepath.Join(tmpDir, "commands")
+	docsDir := filepath.Join(tmpDir, "docs")
+	require.NoError(t, os.MkdirAll(commandsDir, 0755))
+	require.NoError(t, os.MkdirAll(docsDir, 0755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(commandsDir, "good.md"), []byte("---\ndescription: ok\n---\ncontent"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "bad.md"), []byte("---\ndescription: oops\ncontent"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "notags.md"), []byte("---\ndescription: x\n---\ncontent"), 0600))
+
+	srv, err := New(Config{
+		CommandsDir:    commandsDir,
+		ProjectDocsDir: docsDir,
+		MaxFileSize:    1024 * 1024,
+		ServerName:     "test-server",
+		Version:        "1.0.0",
+		LintSchema:     lint.Schema{Required: []string{"tags"}},
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	result, output, err := srv.handleLintDocs(context.Background(), &mcp.CallToolRequest{}, ProjectInput{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	report, ok := output.(*lint.Report)
+	require.True(t, ok)
+	assert.Equal(t, 3, report.Files)
+
+	rules := map[string]string{}
+	for _, issue := range report.Issues {
+		rules[issue.Path] += issue.Rule + " "
+	}
+	assert.Equal(t, map[string]string{
+		"commands:good.md":       lint.RuleMissingField + " ",
+		"project-docs:bad.md":    lint.RuleUnclosed + " ",
+		"project-docs:notags.md": lint.RuleMissingField + " ",
+	}, rules)
+
+	var decoded lint.Report
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &decoded))
+	assert.Equal(t, report.Errors, decoded.Errors)
+}
+
+func TestServer_SkillsAndMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	skillsDir := filepath.Join(tmpDir, "skills")
+	projectDir := filepath.Join(tmpDir, "project")
+	globalDir := filepath.Join(tmpDir, "global")
+	for path, content := range map[string]string{
+		filepath.Join(skillsDir, "pdf", "SKILL.md"):        "---\nname: pdf-tools\ndescription: work with PDF files\n---\n# PDF skill\n",
+		filepath.Join(skillsDir, "pdf", "forms.md"):        "# Forms\n",
+		filepath.Join(skillsDir, "pdf", "scripts", "x.py"): "print(1)\n",
+		filepath.Join(projectDir, "CLAUDE.md"):             "# Project memory\n",
+		filepath.Join(projectDir, "app", "AGENTS.md"):      "# App agents\n",
+		filepath.Join(projectDir, "docs", "guide.md"):      "# Guide\n",
+		filepath.Join(globalDir, "CLAUDE.md"):              "# Global memory\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	srv, err := New(Config{
+		CommandsDir:     filepath.Join(tmpDir, "commands"),
+		ProjectDocsDir:  filepath.Join(projectDir, "docs"),
+		SkillsDir:       skillsDir,
+		MemoryRootDir:   projectDir,
+		GlobalMemoryDir: globalDir,
+		MaxFileSize:     1024 * 1024,
+		ServerName:      "test-server",
+		Version:         "1.0.0",
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	list, err := srv.listAllDocs(context.Background())
+	require.NoError(t, err)
+	docs := map[string]DocInfo{}
+	for _, d := range list.Docs {
+		docs[d.Filename] = d
+	}
+	require.Contains(t, docs, "skills:pdf/SKILL.md")
+	assert.Equal(t, "pdf-tools", docs["skills:pdf/SKILL.md"].Name)
+	assert.Equal(t, "work with PDF files", docs["skills:pdf/SKILL.md"].Description)
+	assert.Equal(t, []string{"forms.md", "scripts/x.py"}, docs["skills:pdf/SKILL.md"].Resources)
+	for _, name := range []string{"memory:CLAUDE.md", "memory:app/AGENTS.md", "memory:global/CLAUDE.md", "project-docs:guide.md"} {
+		assert.Contains(t, docs, name)
+	}
+
+	search, err := srv.searchDocs(context.Background(), "pdf tools", searchOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, search.Results)
+	assert.Equal(t, "skills:pdf/SKILL.md", search.Results[0].Path)
+
+	tests := []struct {
+		path, source, content string
+	}{
+		{path: "skills:pdf/SKILL.md", source: "skills", content: "# PDF skill\n"},
+		{path: "skills:pdf/forms.md", source: "skills", content: "# Forms\n"},
+		{path: "memory:CLAUDE.md", source: "memory", content: "# Project memory\n"},
+		{path: "memory:app/AGENTS", source: "memory", content: "# App agents\n"},
+		{path: "memory:global/CLAUDE.md", source: "memory", content: "# Global memory\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			res, err := srv.readDoc(context.Background(), tt.path, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, res.Content)
+			assert.Equal(t, tt.source, res.Source)
+		})
+	}
+
+	// memory prefix serves memory files only, and memory files are not found without prefix
+	_, err = srv.readDoc(context.Background(), "memory:docs/guide.md", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a memory file")
+	_, err = srv.readDoc(context.Background(), "CLAUDE.md", nil)
+	require.Error(t, err)
+}
+
+func TestServer_SearchDocsRankingProfiles(t *testing.T) {
+	docsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "testing.md"), []byte("# Testing\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(docsDir, "guide.md"), []byte("---\ntags: [testing]\n---\n"), 0600))
+
+	tags := ranking.Default()
+	tags.TagExact, tags.BoostCap = 2, 2
+	srv, err := New(Config{ProjectDocsDir: docsDir, MaxFileSize: 1024, ServerName: "test-server",
+		RankingProfiles: map[string]ranking.Profile{ranking.DefaultName: ranking.Default(), "tags": tags}})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	paths := func(out *SearchOutput) []string {
+		var res []string
+		for _, r := range out.Results {
+			res = append(res, r.Path)
+		}
+		return res
+	}
+
+	res, err := srv.searchDocs(context.Background(), "testing", searchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project-docs:testing.md", "project-docs:guide.md"}, paths(res))
+	assert.InDelta(t, 0.3, res.Results[1].Score, 0.0001)
+
+	res, err = srv.searchDocs(context.Background(), "testing", searchOptions{profile: "tags", explain: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project-docs:guide.md", "project-docs:testing.md"}, paths(res))
+	assert.InDelta(t, 2.0, res.Results[0].Score, 0.0001)
+	assert.InDelta(t, 2.0, res.Results[0].Explain.Name.BoostCap, 0.0001)
+
+	res, err = srv.Search(context.Background(), SearchInput{Query: "testing", Profile: "tags"})
+	require.NoError(t, err)
+	assert.Equal(t, "project-docs:guide.md", res.Results[0].Path)
+
+	_, err = srv.searchDocs(context.Background(), "testing", searchOptions{profile: "other"})
+	require.EqualError(t, err, `unknown ranking profile "other", available: default, tags`)
+}
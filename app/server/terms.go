@@ -0,0 +1,152 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/sahilm/fuzzy"
+
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+// places where a query term can match a doc
+const (
+	matchInPath        = "path"
+	matchInDescription = "description"
+	matchInTags        = "tags"
+)
+
+// minPartialTerm is the length a term needs to match inside a word, shorter terms like "go"
+// would match too many words ("good", "algorithm")
+const minPartialTerm = 3
+
+// stopWords are dropped from multi-term queries, they match too much and lower coverage of useful terms
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "for": true, "to": true, "in": true, "on": true,
+	"and": true, "or": true, "with": true, "how": true, "is": true, "do": true, "i": true,
+}
+
+// TermMatch tells where a term of a multi-term query matched the doc and its score
+type TermMatch struct {
+	Term  string   `json:"term"`
+	In    []string `json:"in"` // path, description or tags
+	Score float64  `json:"score"`
+}
+
+// queryTerms splits plain query to distinct lowercase terms. stop words are dropped,
+// unless the query has nothing but stop words.
+func queryTerms(query string) []string {
+	var all, res []string
+	seen := map[string]bool{}
+	for _, t := range strings.Fields(strings.ToLower(query)) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		all = append(all, t)
+		if !stopWords[t] {
+			res = append(res, t)
+		}
+	}
+	if len(res) == 0 {
+		return all
+	}
+	return res
+}
+
+// pathWords returns words of the doc path, directories and the file name without extension,
+// split on dashes, underscores, dots and spaces. the doc name is added for skills named in frontmatter.
+func pathWords(file scanner.FileInfo) []string {
+	_, p, _ := strings.Cut(strings.ToLower(file.Filename), ":")
+	p = strings.TrimSuffix(p, ".md")
+	split := func(r rune) bool { return r == '/' || r == '-' || r == '_' || r == '.' || r == ' ' }
+	return append(strings.FieldsFunc(p, split), strings.FieldsFunc(strings.TrimSuffix(file.Normalized, ".md"), split)...)
+}
+
+// scoreTerms scores each term against path words, description and tags of the doc and combines term scores
+// with coverage weighting: the average term score multiplied by the share of matched terms, so docs matching
+// all terms in any order rank above docs matching some of them. returns matched terms only.
+func (s *Server) scoreTerms(p ranking.Profile, terms []string, file scanner.FileInfo) (float64, []TermMatch) {
+	if len(terms) == 0 {
+		return 0, nil
+	}
+	words := pathWords(file)
+	var sum float64
+	var matched []TermMatch
+	for _, t := range terms {
+		m := s.scoreTerm(p, t, words, file)
+		if m.Score > 0 {
+			sum += m.Score
+			matched = append(matched, m)
+		}
+	}
+	n := float64(len(terms))
+	return sum / n * float64(len(matched)) / n, matched
+}
+
+// containsTerm reports whether lowercase text contains the term. terms shorter than minPartialTerm
+// match whole words only.
+func containsTerm(text, term string) bool {
+	if len(term) >= minPartialTerm {
+		return strings.Contains(text, term)
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	return slices.Contains(words, term)
+}
+
+// scoreTerm scores a single term with weights of the profile. in path, a word equal to the term scores as
+// an exact match, a word starting with it as a substring match and a word containing it three quarters of that,
+// scaled by the matched part of the word; otherwise a fuzzy match is tried. description and tag matches add
+// the same boosts as frontmatter matches of the whole query, capped at the boost cap of the profile.
+func (s *Server) scoreTerm(p ranking.Profile, term string, words []string, file scanner.FileInfo) TermMatch {
+	res := TermMatch{Term: term}
+
+	var pathScore float64
+	for _, w := range words {
+		ratio := float64(len(term)) / float64(len(w))
+		switch {
+		case w == term:
+			pathScore = max(pathScore, p.Exact)
+		case strings.HasPrefix(w, term):
+			pathScore = max(pathScore, p.Substring*ratio)
+		case len(term) >= minPartialTerm && strings.Contains(w, term):
+			pathScore = max(pathScore, 0.75*p.Substring*ratio)
+		}
+	}
+	if pathScore == 0 && len(term) >= minPartialTerm {
+		for _, m := range fuzzy.Find(term, words) {
+			if fuzzyScore := min(float64(m.Score)/100.0, 1.0); fuzzyScore >= p.FuzzyThreshold {
+				pathScore = max(pathScore, fuzzyScore*p.Fuzzy)
+			}
+		}
+	}
+	if pathScore > 0 {
+		res.Score += pathScore
+		res.In = append(res.In, matchInPath)
+	}
+
+	var boost float64
+	if containsTerm(strings.ToLower(file.Description), term) {
+		boost += p.Description
+		res.In = append(res.In, matchInDescription)
+	}
+
+	var tagScore float64
+	for _, tag := range file.Tags {
+		tag = strings.ToLower(tag)
+		switch {
+		case tag == term:
+			tagScore = max(tagScore, p.TagExact)
+		case containsTerm(tag, term):
+			tagScore = max(tagScore, p.TagPartial)
+		}
+	}
+	if tagScore > 0 {
+		boost += tagScore
+		res.In = append(res.In, matchInTags)
+	}
+	res.Score += min(boost, p.BoostCap)
+	return res
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/umputun/local-docs-mcp/app/ranking"
+	"github.com/umputun/local-docs-mcp/app/scanner"
+)
+
+func TestServer_SearchDocsTerms(t *testing.T) {
+	files := map[string]string{
+		"commands/git/commit-message.md": "---\ndescription: conventions and style of messages\n---\n",
+		"commands/commit.md":             "# Commit\n",
+		"docs/code-style.md":             "---\ntags: [style, lint]\n---\n",
+		"docs/architecture.md":           "# Architecture\n",
+	}
+
+	srv, _ := newTestServer(t, files, Config{})
+
+	t.Run("terms in any order", func(t *testing.T) {
+		for _, q := range []string{"commit message style", "style of commit message", "message commit style"} {
+			res, err := srv.searchDocs(context.Background(), q, searchOptions{})
+			require.NoError(t, err)
+			require.NotEmpty(t, res.Results, q)
+			assert.Equal(t, "commands:git/commit-message.md", res.Results[0].Path, q)
+
+			terms := map[string][]string{}
+			for _, m := range res.Results[0].Terms {
+				terms[m.Term] = m.In
+			}
+			assert.Equal(t, map[string][]string{"commit": {"path"}, "message": {"path", "description"},
+				"style": {"description"}}, terms, q)
+		}
+	})
+
+	t.Run("partial coverage ranks lower", func(t *testing.T) {
+		res, err := srv.searchDocs(context.Background(), "commit message style", searchOptions{})
+		require.NoError(t, err)
+		var paths []string
+		for _, r := range res.Results {
+			paths = append(paths, r.Path)
+		}
+		require.Len(t, paths, 3)
+		assert.Equal(t, "commands:git/commit-message.md", paths[0])
+		assert.ElementsMatch(t, []string{"commands:commit.md", "project-docs:code-style.md"}, paths[1:])
+		for _, r := range res.Results[1:] {
+			assert.Len(t, r.Terms, 1, r.Path)
+			assert.Less(t, r.Score, res.Results[0].Score, r.Path)
+		}
+	})
+
+	t.Run("single term has no term details", func(t *testing.T) {
+		res, err := srv.searchDocs(context.Background(), "architecture", searchOptions{})
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Results)
+		assert.Equal(t, "project-docs:architecture.md", res.Results[0].Path)
+		assert.Empty(t, res.Results[0].Terms)
+	})
+}
+
+func TestQueryTerms(t *testing.T) {
+	tbl := []struct {
+		query string
+		terms []string
+	}{
+		{"commit", []string{"commit"}},
+		{"Commit  Message commit", []string{"commit", "message"}},
+		{"how to write a commit message", []string{"write", "commit", "message"}},
+		{"how to", []string{"how", "to"}},
+		{"  ", nil},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.terms, queryTerms(tt.query), tt.query)
+	}
+}
+
+func TestPathWords(t *testing.T) {
+	assert.Equal(t, []string{"ops", "deploy", "steps", "deploy", "steps"},
+		pathWords(scanner.FileInfo{Filename: "release@v1:ops/Deploy_Steps.md", Normalized: "deploy_steps.md"}))
+	assert.Equal(t, []string{"review", "skill", "code", "review"},
+		pathWords(scanner.FileInfo{Filename: "skills:review/SKILL.md", Normalized: "code-review"}))
+}
+
+func TestServer_ScoreTerms(t *testing.T) {
+	srv := &Server{}
+	file := scanner.FileInfo{Filename: "commands:git/commit-message.md", Normalized: "commit-message.md",
+		Description: "Message conventions", Tags: []string{"Git", "workflow"}}
+
+	tbl := []struct {
+		name    string
+		terms   []string
+		score   float64
+		matched []TermMatch
+	}{
+		{name: "all terms in path", terms: []string{"message", "commit"}, score: 1.25, matched: []TermMatch{
+			{Term: "message", In: []string{"path", "description"}, Score: 1.5},
+			{Term: "commit", In: []string{"path"}, Score: 1.0}}},
+		{name: "prefix of path word and tag", terms: []string{"com", "git"}, score: (0.8*3/6 + 1.3) / 2, matched: []TermMatch{
+			{Term: "com", In: []string{"path"}, Score: 0.8 * 3 / 6},
+			{Term: "git", In: []string{"path", "tags"}, Score: 1.3}}},
+		{name: "substring of tag", terms: []string{"flow", "zzz"}, score: 0.15 / 2 / 2, matched: []TermMatch{
+			{Term: "flow", In: []string{"tags"}, Score: 0.15}}},
+		{name: "short term inside description and tag words", terms: []string{"on", "ow"}, score: 0, matched: nil},
+		{name: "weak fuzzy match ignored", terms: []string{"cmmt"}, score: 0, matched: nil},
+		{name: "nothing matched", terms: []string{"deploy", "rollback"}, score: 0, matched: nil},
+		{name: "no terms", terms: nil, score: 0, matched: nil},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			score, matched := srv.scoreTerms(ranking.Default(), tt.terms, file)
+			assert.InDelta(t, tt.score, score, 0.001)
+			require.Len(t, matched, len(tt.matched))
+			for i := range matched {
+				assert.Equal(t, tt.matched[i].Term, matched[i].Term)
+				assert.Equal(t, tt.matched[i].In, matched[i].In)
+				assert.InDelta(t, tt.matched[i].Score, matched[i].Score, 0.001)
+			}
+		})
+	}
+}
+
+func TestContainsTerm(t *testing.T) {
+	tbl := []struct {
+		text, term string
+		want       bool
+	}{
+		{text: "deploy go services", term: "go", want: true},
+		{text: "good practices", term: "go", want: false},
+		{text: "ci/cd pipeline", term: "cd", want: true},
+		{text: "workflow", term: "flow", want: true},
+		{text: "workflow", term: "ow", want: false},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.want, containsTerm(tt.text, tt.term), "%q in %q", tt.term, tt.text)
+	}
+}
+
+func TestServer_ScoreTermsBoostCap(t *testing.T) {
+	srv := &Server{}
+	file := scanner.FileInfo{Filename: "commands:git/commit-message.md", Normalized: "commit-message.md",
+		Description: "Message conventions for git", Tags: []string{"git", "workflow"}}
+	p := ranking.Default()
+	p.BoostCap = 0.4
+
+	score, matched := srv.scoreTerms(p, []string{"git", "message"}, file)
+	require.Len(t, matched, 2)
+	assert.Equal(t, []string{"path", "description", "tags"}, matched[0].In)
+	assert.InDelta(t, 1.4, matched[0].Score, 0.001, "description and exact tag boosts of 0.8 capped")
+	assert.InDelta(t, 1.4, matched[1].Score, 0.001, "description boost of 0.5 capped")
+	assert.InDelta(t, 1.4, score, 0.001)
+}
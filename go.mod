@@ -3,6 +3,7 @@ module github.com/umputun/local-docs-mcp
 go 1.25.3
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/go-pkgz/expirable-cache/v3 v3.1.0
 	github.com/jessevdk/go-flags v1.6.1